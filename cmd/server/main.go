@@ -13,6 +13,7 @@ import (
 	"jabber-bot/internal/config"
 	"jabber-bot/internal/filemanager"
 	"jabber-bot/internal/models"
+	"jabber-bot/internal/scheduler"
 	"jabber-bot/internal/webhook"
 	"jabber-bot/internal/xmpp"
 	"jabber-bot/pkg/logger"
@@ -42,6 +43,7 @@ func main() {
 
 	zapLogger.Info("Configuration loaded successfully",
 		zap.String("xmpp_jid", cfg.XMPP.JID),
+		zap.Int("xmpp_accounts", len(cfg.Accounts)+1),
 		zap.Int("api_port", cfg.API.Port),
 		zap.String("webhook_url", cfg.Webhook.URL),
 	)
@@ -64,8 +66,9 @@ func main() {
 	// Set up callback for successful webhook delivery to send XEP-0184 receipts
 	webhookManager.GetService().SetOnMessageSent(func(msg models.Message) {
 		if msg.ReceiptRequested && msg.From != "" {
-			if err := xmppManager.SendDeliveryReceipt(msg.From, msg.ID); err != nil {
+			if err := xmppManager.SendDeliveryReceipt(msg.Account, msg.To, msg.From, msg.ID); err != nil {
 				zapLogger.Error("Failed to send delivery receipt",
+					zap.String("account", msg.Account),
 					zap.String("to", msg.From),
 					zap.String("message_id", msg.ID),
 					zap.Error(err),
@@ -82,6 +85,15 @@ func main() {
 	// Initialize API server
 	apiServer := api.NewServer(cfg, zapLogger, xmppManager)
 
+	// Initialize scheduler for messages sent later and recurring schedules
+	messageScheduler := scheduler.NewManager(cfg, zapLogger, xmppManager)
+	messageScheduler.SetRenderer(apiServer.Templates())
+	if err := messageScheduler.Start(ctx); err != nil {
+		zapLogger.Fatal("Failed to start scheduler", zap.Error(err))
+	}
+	apiServer.SetScheduler(messageScheduler)
+	apiServer.SetWebhookManager(webhookManager)
+
 	// Start API server in goroutine
 	go func() {
 		if err := apiServer.Start(); err != nil {
@@ -112,6 +124,11 @@ func main() {
 		zapLogger.Error("Error stopping webhook manager", zap.Error(err))
 	}
 
+	// Stop scheduler before XMPP, pending messages stay on disk
+	if err := messageScheduler.Stop(); err != nil {
+		zapLogger.Error("Error stopping scheduler", zap.Error(err))
+	}
+
 	// Stop file manager
 	if err := fileManager.Stop(); err != nil {
 		zapLogger.Error("Error stopping file manager", zap.Error(err))
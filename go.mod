@@ -59,3 +59,5 @@ require (
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	nhooyr.io/websocket v1.8.17 // indirect
 )
+
+replace gosrc.io/xmpp => ./third_party/xmpp
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/templates"
+	"jabber-bot/internal/xmpp"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// maxBroadcastRecipients is the largest number of JIDs and rooms of a broadcast
+const maxBroadcastRecipients = 500
+
+// handleBroadcast handles POST /api/v1/broadcast
+func (s *Server) handleBroadcast(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	var req models.BroadcastRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := s.validateBroadcastRequest(&req); err != nil {
+		logger.Warn("Request validation failed",
+			zap.Error(err),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return err
+	}
+
+	recipients := broadcastRecipients(&req)
+
+	// Recipients whose template fails to render are reported without stopping the broadcast
+	results := make([]models.BroadcastResult, len(recipients))
+	var messages []models.BroadcastMessage
+	var indexes []int
+	for i, recipient := range recipients {
+		body := req.Body
+		if req.Template != "" {
+			rendered, err := s.renderBody(req.Template, recipientData(&req, recipient.To), req.Body)
+			if _, ok := errors.AsType[*templates.Error](err); ok {
+				results[i] = models.BroadcastResult{To: recipient.To, Room: recipient.Room, Error: err.Error()}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			body = rendered
+		}
+		if strings.TrimSpace(body) == "" || len(body) > xmpp.MaxBroadcastBodyLength {
+			results[i] = models.BroadcastResult{To: recipient.To, Room: recipient.Room, Error: fmt.Sprintf("rendered body is empty or too long (max %d characters)", xmpp.MaxBroadcastBodyLength)}
+			continue
+		}
+
+		recipient.Body = body
+		recipient.Type = req.Type
+		messages = append(messages, recipient)
+		indexes = append(indexes, i)
+	}
+
+	logger.Info("Broadcasting message",
+		zap.String("account", req.Account),
+		zap.Int("jids", len(req.JIDs)),
+		zap.Int("rooms", len(req.Rooms)),
+		zap.String("template", req.Template),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	for i, result := range manager.Broadcast(req.Account, messages) {
+		results[indexes[i]] = result
+	}
+
+	sent, queued, failed := 0, 0, 0
+	for _, result := range results {
+		switch {
+		case !result.Success:
+			failed++
+		case result.Queued:
+			queued++
+		default:
+			sent++
+		}
+	}
+
+	if failed > 0 {
+		logger.Warn("Broadcast partially failed",
+			zap.Int("failed", failed),
+			zap.Int("recipients", len(results)),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+	}
+
+	// 207 tells clients to look at the results of the individual recipients
+	status := fiber.StatusOK
+	message := "Broadcast sent successfully"
+	if failed > 0 {
+		status = fiber.StatusMultiStatus
+		message = fmt.Sprintf("Broadcast failed for %d of %d recipients", failed, len(results))
+	}
+
+	return c.Status(status).JSON(models.APIResponse{
+		Success: failed == 0,
+		Message: message,
+		Data: map[string]interface{}{
+			"sent":       sent,
+			"queued":     queued,
+			"failed":     failed,
+			"results":    results,
+			"sent_at":    time.Now().UTC().Format(time.RFC3339),
+			"request_id": c.GetRespHeader("X-Request-ID"),
+		},
+	})
+}
+
+func (s *Server) validateBroadcastRequest(req *models.BroadcastRequest) error {
+	if len(req.JIDs)+len(req.Rooms) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "jids or rooms field is required")
+	}
+	if len(req.JIDs)+len(req.Rooms) > maxBroadcastRecipients {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("too many recipients (max %d)", maxBroadcastRecipients))
+	}
+
+	for _, jid := range req.JIDs {
+		if !strings.Contains(jid, "@") {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid JID format: %q", jid))
+		}
+	}
+	for _, room := range req.Rooms {
+		if !strings.Contains(room, "@") {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid room JID format: %q", room))
+		}
+	}
+
+	if req.Template == "" {
+		if strings.TrimSpace(req.Body) == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "body or template field is required")
+		}
+		if len(req.Body) > xmpp.MaxBroadcastBodyLength {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("body field too long (max %d characters)", xmpp.MaxBroadcastBodyLength))
+		}
+	}
+
+	return s.validateAccount(req.Account)
+}
+
+// broadcastRecipients returns the JIDs and rooms of a broadcast without duplicates
+func broadcastRecipients(req *models.BroadcastRequest) []models.BroadcastMessage {
+	seen := make(map[string]bool)
+	var recipients []models.BroadcastMessage
+	for _, jid := range req.JIDs {
+		if !seen[jid] {
+			seen[jid] = true
+			recipients = append(recipients, models.BroadcastMessage{To: jid})
+		}
+	}
+	for _, room := range req.Rooms {
+		if !seen[room] {
+			seen[room] = true
+			recipients = append(recipients, models.BroadcastMessage{To: room, Room: true})
+		}
+	}
+	return recipients
+}
+
+// recipientData merges the template variables of a recipient over the shared ones
+func recipientData(req *models.BroadcastRequest, to string) map[string]interface{} {
+	data := make(map[string]interface{}, len(req.Data)+len(req.Variables[to]))
+	for key, value := range req.Data {
+		data[key] = value
+	}
+	for key, value := range req.Variables[to] {
+		data[key] = value
+	}
+	return data
+}
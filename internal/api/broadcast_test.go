@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/templates"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleBroadcast(t *testing.T) {
+	store, err := templates.NewStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, store.Put("maintenance", "Hi {{.name}}, maintenance starts at {{.time}}"))
+
+	manager := &MockXMPPManager{}
+	manager.On("Broadcast", "", []models.BroadcastMessage{
+		{To: "alice@example.com", Body: "Hi Alice, maintenance starts at 22:00", Type: "chat"},
+		{To: "ops@conference.example.com", Room: true, Body: "Hi team, maintenance starts at 22:00", Type: "chat"},
+	}).Return([]models.BroadcastResult{
+		{To: "alice@example.com", Success: true},
+		{To: "ops@conference.example.com", Room: true, Error: "XMPP client is not connected"},
+	})
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	server.templates = store
+	app.Post("/api/v1/broadcast", server.handleBroadcast)
+
+	resp := doJSON(t, app, "POST", "/api/v1/broadcast", models.BroadcastRequest{
+		JIDs:     []string{"alice@example.com", "bob@example.com", "alice@example.com"},
+		Rooms:    []string{"ops@conference.example.com"},
+		Template: "maintenance",
+		Data:     map[string]interface{}{"name": "team"},
+		Variables: map[string]map[string]interface{}{
+			"alice@example.com":          {"name": "Alice", "time": "22:00"},
+			"ops@conference.example.com": {"time": "22:00"},
+			// bob's time is missing, so his message fails to render
+			"bob@example.com": {"name": "Bob"},
+		},
+		Type: "chat",
+	})
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+
+	var response struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Sent    int                      `json:"sent"`
+			Failed  int                      `json:"failed"`
+			Results []models.BroadcastResult `json:"results"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.False(t, response.Success)
+	assert.Equal(t, 1, response.Data.Sent)
+	assert.Equal(t, 2, response.Data.Failed)
+	require.Len(t, response.Data.Results, 3)
+	assert.True(t, response.Data.Results[0].Success)
+	assert.Equal(t, "bob@example.com", response.Data.Results[1].To)
+	assert.Contains(t, response.Data.Results[1].Error, "template maintenance:1:")
+	assert.Equal(t, "XMPP client is not connected", response.Data.Results[2].Error)
+
+	manager.AssertExpectations(t)
+}
+
+func TestHandleBroadcast_Validation(t *testing.T) {
+	app, server := newTestServer(t, &config.Config{}, &MockXMPPManager{})
+	app.Post("/api/v1/broadcast", server.handleBroadcast)
+
+	tests := []struct {
+		name string
+		req  models.BroadcastRequest
+	}{
+		{name: "no recipients", req: models.BroadcastRequest{Body: "Hello"}},
+		{name: "no body", req: models.BroadcastRequest{JIDs: []string{"alice@example.com"}}},
+		{name: "invalid JID", req: models.BroadcastRequest{JIDs: []string{"alice"}, Body: "Hello"}},
+		{name: "unknown account", req: models.BroadcastRequest{Rooms: []string{"ops@conference.example.com"}, Body: "Hello", Account: "payroll"}},
+		{name: "templates not configured", req: models.BroadcastRequest{JIDs: []string{"alice@example.com"}, Template: "alert"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doJSON(t, app, "POST", "/api/v1/broadcast", tt.req)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
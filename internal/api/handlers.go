@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"jabber-bot/internal/config"
 	"jabber-bot/internal/models"
 	"jabber-bot/internal/xmpp"
 
@@ -31,6 +33,14 @@ func (s *Server) handleSendMessage(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
+	if req.Template != "" {
+		body, err := s.renderBody(req.Template, req.Data, req.Body)
+		if err != nil {
+			return templateErrorResponse(c, err)
+		}
+		req.Body = body
+	}
+
 	// Validate request
 	if err := s.validateSendMessageRequest(&req); err != nil {
 		logger.Warn("Request validation failed",
@@ -40,7 +50,18 @@ func (s *Server) handleSendMessage(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
+	if req.SendAt != "" || req.Delay != "" {
+		return s.scheduleMessage(c, models.ScheduledMessage{
+			Account: req.Account,
+			From:    req.From,
+			To:      req.To,
+			Body:    req.Body,
+			Type:    req.Type,
+		}, req.SendAt, req.Delay)
+	}
+
 	logger.Info("Sending message",
+		zap.String("account", req.Account),
 		zap.String("to", req.To),
 		zap.String("type", req.Type),
 		zap.Int("body_length", len(req.Body)),
@@ -48,7 +69,17 @@ func (s *Server) handleSendMessage(c *fiber.Ctx) error {
 	)
 
 	// Send message via XMPP manager
-	err := manager.SendMessage(req.To, req.Body, req.Type)
+	result, err := manager.SendMessage(req.Account, req.From, req.To, req.Body, req.Type)
+	if errors.Is(err, xmpp.ErrOutboxFull) {
+		logger.Warn("Outbound queue full, message rejected",
+			zap.String("to", req.To),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return fiber.NewError(fiber.StatusServiceUnavailable, "XMPP is disconnected and the outbound queue is full")
+	}
+	if errors.Is(err, xmpp.ErrRateLimited) {
+		return rateLimitedResponse(logger, c)
+	}
 	if err != nil {
 		logger.Error("Failed to send XMPP message",
 			zap.Error(err),
@@ -65,6 +96,21 @@ func (s *Server) handleSendMessage(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
 
+	if result.Queued {
+		return c.Status(fiber.StatusAccepted).JSON(models.APIResponse{
+			Success: true,
+			Message: "XMPP is disconnected, message queued",
+			Data: map[string]interface{}{
+				"message_id":  result.MessageID,
+				"to":          req.To,
+				"type":        req.Type,
+				"body_length": len(req.Body),
+				"expires_at":  result.ExpiresAt.UTC().Format(time.RFC3339),
+				"request_id":  c.GetRespHeader("X-Request-ID"),
+			},
+		})
+	}
+
 	// Success response
 	response := models.APIResponse{
 		Success: true,
@@ -74,6 +120,7 @@ func (s *Server) handleSendMessage(c *fiber.Ctx) error {
 			"type":        req.Type,
 			"body_length": len(req.Body),
 			"sent_at":     time.Now().UTC().Format(time.RFC3339),
+			"delay_ms":    result.Delay.Milliseconds(),
 			"request_id":  c.GetRespHeader("X-Request-ID"),
 		},
 	}
@@ -81,6 +128,15 @@ func (s *Server) handleSendMessage(c *fiber.Ctx) error {
 	return c.JSON(response)
 }
 
+// rateLimitedResponse answers a send rejected because too many sends wait for the rate limit
+func rateLimitedResponse(logger *zap.Logger, c *fiber.Ctx) error {
+	logger.Warn("Outbound rate limit queue full, message rejected",
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+	c.Set(fiber.HeaderRetryAfter, "1")
+	return fiber.NewError(fiber.StatusTooManyRequests, "Outbound rate limit exceeded, retry later")
+}
+
 // handleSendMUCMessage handles POST /api/v1/send-muc
 func (s *Server) handleSendMUCMessage(c *fiber.Ctx) error {
 	logger := c.Locals("logger").(*zap.Logger)
@@ -96,6 +152,14 @@ func (s *Server) handleSendMUCMessage(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
+	if req.Template != "" {
+		body, err := s.renderBody(req.Template, req.Data, req.Body)
+		if err != nil {
+			return templateErrorResponse(c, err)
+		}
+		req.Body = body
+	}
+
 	// Validate request
 	if err := s.validateSendMUCMessageRequest(&req); err != nil {
 		logger.Warn("Request validation failed",
@@ -105,15 +169,37 @@ func (s *Server) handleSendMUCMessage(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
+	if req.SendAt != "" || req.Delay != "" {
+		return s.scheduleMessage(c, models.ScheduledMessage{
+			Account:  req.Account,
+			Room:     req.Room,
+			Body:     req.Body,
+			Subject:  req.Subject,
+			Mentions: req.Mentions,
+		}, req.SendAt, req.Delay)
+	}
+
 	logger.Info("Sending MUC message",
+		zap.String("account", req.Account),
 		zap.String("room", req.Room),
 		zap.String("subject", req.Subject),
+		zap.Strings("mentions", req.Mentions),
 		zap.Int("body_length", len(req.Body)),
 		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
 	)
 
 	// Send MUC message via XMPP manager
-	err := manager.SendMUCMessage(req.Room, req.Body, req.Subject)
+	result, err := manager.SendMUCMessage(req.Account, req.Room, req.Body, req.Subject, req.Mentions)
+	if errors.Is(err, xmpp.ErrOutboxFull) {
+		logger.Warn("Outbound queue full, message rejected",
+			zap.String("room", req.Room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return fiber.NewError(fiber.StatusServiceUnavailable, "XMPP is disconnected and the outbound queue is full")
+	}
+	if errors.Is(err, xmpp.ErrRateLimited) {
+		return rateLimitedResponse(logger, c)
+	}
 	if err != nil {
 		logger.Error("Failed to send MUC message",
 			zap.Error(err),
@@ -130,6 +216,21 @@ func (s *Server) handleSendMUCMessage(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
 
+	if result.Queued {
+		return c.Status(fiber.StatusAccepted).JSON(models.APIResponse{
+			Success: true,
+			Message: "XMPP is disconnected, message queued",
+			Data: map[string]interface{}{
+				"message_id":  result.MessageID,
+				"room":        req.Room,
+				"subject":     req.Subject,
+				"body_length": len(req.Body),
+				"expires_at":  result.ExpiresAt.UTC().Format(time.RFC3339),
+				"request_id":  c.GetRespHeader("X-Request-ID"),
+			},
+		})
+	}
+
 	// Success response
 	response := models.APIResponse{
 		Success: true,
@@ -139,6 +240,7 @@ func (s *Server) handleSendMUCMessage(c *fiber.Ctx) error {
 			"subject":     req.Subject,
 			"body_length": len(req.Body),
 			"sent_at":     time.Now().UTC().Format(time.RFC3339),
+			"delay_ms":    result.Delay.Milliseconds(),
 			"request_id":  c.GetRespHeader("X-Request-ID"),
 		},
 	}
@@ -170,13 +272,14 @@ func (s *Server) handleSendChatState(c *fiber.Ctx) error {
 	}
 
 	logger.Info("Sending chat state",
+		zap.String("account", req.Account),
 		zap.String("to", req.To),
 		zap.String("state", req.State),
 		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
 	)
 
 	state := xmpp.ChatState(req.State)
-	err := manager.SendChatState(req.To, state)
+	err := manager.SendChatState(req.Account, req.To, state)
 	if err != nil {
 		logger.Error("Failed to send chat state",
 			zap.Error(err),
@@ -225,6 +328,7 @@ func (s *Server) handleStatus(c *fiber.Ctx) error {
 		APIRunning:    true,
 		WebhookConfig: s.config.Webhook.URL,
 		Version:       "1.0.0",
+		Accounts:      manager.GetAccountsStatus(),
 	}
 
 	return c.JSON(response)
@@ -238,8 +342,11 @@ func (s *Server) handleWebhookStatus(c *fiber.Ctx) error {
 		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
 	)
 
-	// For now, return basic webhook status
-	// In a real implementation, you would get this from the webhook manager
+	if s.webhooks != nil {
+		return c.JSON(s.webhooks.GetStatus())
+	}
+
+	// Without a webhook manager, return basic webhook status
 	webhookStatus := map[string]interface{}{
 		"running":      false, // Would come from webhook manager
 		"healthy":      false, // Would come from webhook manager
@@ -285,15 +392,20 @@ func (s *Server) handleRoot(c *fiber.Ctx) error {
 		"version":     "1.0.0",
 		"description": "XMPP Jabber bot with RESTful API",
 		"endpoints": map[string]string{
-			"send":         "/api/v1/send - Send XMPP message",
-			"send_muc":     "/api/v1/send-muc - Send MUC message",
-			"send_file":    "/api/v1/send-file - Send file via XMPP",
-			"status":       "/api/v1/status - Get bot status",
-			"health":       "/health - Health check",
-			"webhook":      "/api/v1/webhook/status - Get webhook status",
-			"docs":         "/docs - API documentation",
-			"openapi":      "/openapi.yaml - OpenAPI specification (YAML)",
-			"openapi_json": "/openapi.json - OpenAPI specification (JSON)",
+			"send":          "/api/v1/send - Send XMPP message",
+			"send_muc":      "/api/v1/send-muc - Send MUC message",
+			"send_file":     "/api/v1/send-file - Send file via XMPP",
+			"muc_invite":    "/api/v1/muc/invite - Invite users into a MUC room",
+			"muc_admin":     "/api/v1/muc/{room}/affiliations|roles|kick|subject - Administer a MUC room",
+			"muc_rooms":     "/api/v1/muc/rooms - Create and configure a MUC room",
+			"muc_occupants": "/api/v1/muc/{room}/occupants - List occupants of a joined MUC room",
+			"muc_private":   "/api/v1/muc/{room}/private - Send a private message to a room occupant",
+			"status":        "/api/v1/status - Get bot status",
+			"health":        "/health - Health check",
+			"webhook":       "/api/v1/webhook/status - Get webhook status",
+			"docs":          "/docs - API documentation",
+			"openapi":       "/openapi.yaml - OpenAPI specification (YAML)",
+			"openapi_json":  "/openapi.json - OpenAPI specification (JSON)",
 		},
 	}
 
@@ -505,6 +617,28 @@ func (s *Server) validateSendMessageRequest(req *models.SendMessageRequest) erro
 		return fiber.NewError(fiber.StatusBadRequest, "invalid JID format")
 	}
 
+	if err := s.validateAccount(req.Account); err != nil {
+		return err
+	}
+
+	return s.validateSender(req.From, req.Account)
+}
+
+// validateSender checks a sender localpart, which can only be chosen when the default
+// account runs as an external component
+func (s *Server) validateSender(from, account string) error {
+	if from == "" {
+		return nil
+	}
+
+	if s.config.XMPP.Mode != config.ModeComponent || (account != "" && account != config.DefaultAccount) {
+		return fiber.NewError(fiber.StatusBadRequest, "from field is only supported in component mode")
+	}
+
+	if strings.ContainsAny(from, "@/ ") {
+		return fiber.NewError(fiber.StatusBadRequest, "from field must be a localpart, e.g. alerts")
+	}
+
 	return nil
 }
 
@@ -527,7 +661,13 @@ func (s *Server) validateSendMUCMessageRequest(req *models.SendMUCMessageRequest
 		return fiber.NewError(fiber.StatusBadRequest, "invalid room JID format")
 	}
 
-	return nil
+	for _, nick := range req.Mentions {
+		if strings.TrimSpace(nick) == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "mentions must not contain empty nicks")
+		}
+	}
+
+	return s.validateAccount(req.Account)
 }
 
 var validChatStates = map[string]bool{
@@ -555,7 +695,22 @@ func (s *Server) validateSendChatStateRequest(req *models.SendChatStateRequest)
 		return fiber.NewError(fiber.StatusBadRequest, "invalid state. Must be one of: active, composing, paused, inactive, gone")
 	}
 
-	return nil
+	return s.validateAccount(req.Account)
+}
+
+// validateAccount checks that a request selects a configured account. Empty selects the default account.
+func (s *Server) validateAccount(account string) error {
+	if account == "" || account == config.DefaultAccount {
+		return nil
+	}
+
+	for _, acc := range s.config.Accounts {
+		if acc.Name == account {
+			return nil
+		}
+	}
+
+	return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown account %q", account))
 }
 
 // handleSendFile handles POST /api/v1/send-file
@@ -566,6 +721,7 @@ func (s *Server) handleSendFile(c *fiber.Ctx) error {
 	// Get fields
 	to := c.FormValue("to")
 	description := c.FormValue("description")
+	account := c.FormValue("account")
 	file, err := c.FormFile("file")
 	if err != nil {
 		logger.Warn("File not provided",
@@ -584,6 +740,10 @@ func (s *Server) handleSendFile(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid JID format")
 	}
 
+	if err := s.validateAccount(account); err != nil {
+		return err
+	}
+
 	// Check file size limit
 	if file.Size > s.config.FileTransfer.MaxSize {
 		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("File too large. Maximum size is %d bytes", s.config.FileTransfer.MaxSize))
@@ -663,7 +823,7 @@ func (s *Server) handleSendFile(c *fiber.Ctx) error {
 		)
 
 		// Send file via XEP-0363 (HTTP upload through XMPP server)
-		err = manager.SendFileXEP0363(to, destPath, file.Filename, fileType)
+		err = manager.SendFileXEP0363(account, to, destPath, file.Filename, fileType)
 		if err != nil {
 			logger.Error("Failed to send file via XEP-0363",
 				zap.Error(err),
@@ -714,7 +874,7 @@ func (s *Server) handleSendFile(c *fiber.Ctx) error {
 		)
 
 		// Send file via XMPP manager (XEP-0066 OOB)
-		err = manager.SendFile(to, fileURL, file.Filename, fileType)
+		err = manager.SendFile(account, to, fileURL, file.Filename, fileType)
 		if err != nil {
 			logger.Error("Failed to send file via XMPP",
 				zap.Error(err),
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"jabber-bot/internal/config"
 	"jabber-bot/internal/models"
@@ -25,18 +26,94 @@ type MockXMPPManager struct {
 	mock.Mock
 }
 
-func (m *MockXMPPManager) SendMessage(to, body, messageType string) error {
-	args := m.Called(to, body, messageType)
+func (m *MockXMPPManager) SendMessage(account, from, to, body, messageType string) (models.SendResult, error) {
+	args := m.Called(account, from, to, body, messageType)
+	return args.Get(0).(models.SendResult), args.Error(1)
+}
+
+func (m *MockXMPPManager) SendMUCMessage(account, room, body, subject string, mentions []string) (models.SendResult, error) {
+	args := m.Called(account, room, body, subject, mentions)
+	return args.Get(0).(models.SendResult), args.Error(1)
+}
+
+func (m *MockXMPPManager) Broadcast(account string, messages []models.BroadcastMessage) []models.BroadcastResult {
+	args := m.Called(account, messages)
+	return args.Get(0).([]models.BroadcastResult)
+}
+
+func (m *MockXMPPManager) InviteToRoom(account, room string, jids []string, reason string, mediated bool) error {
+	args := m.Called(account, room, jids, reason, mediated)
+	return args.Error(0)
+}
+
+func (m *MockXMPPManager) GetAffiliations(account, room, affiliation string) ([]models.MUCItem, error) {
+	args := m.Called(account, room, affiliation)
+	return args.Get(0).([]models.MUCItem), args.Error(1)
+}
+
+func (m *MockXMPPManager) SetAffiliation(account, room, jid, affiliation, reason string) error {
+	args := m.Called(account, room, jid, affiliation, reason)
+	return args.Error(0)
+}
+
+func (m *MockXMPPManager) GetRoles(account, room, role string) ([]models.MUCItem, error) {
+	args := m.Called(account, room, role)
+	return args.Get(0).([]models.MUCItem), args.Error(1)
+}
+
+func (m *MockXMPPManager) SetRole(account, room, nick, role, reason string) error {
+	args := m.Called(account, room, nick, role, reason)
+	return args.Error(0)
+}
+
+func (m *MockXMPPManager) KickOccupant(account, room, nick, reason string) error {
+	args := m.Called(account, room, nick, reason)
 	return args.Error(0)
 }
 
-func (m *MockXMPPManager) SendMUCMessage(room, body, subject string) error {
-	args := m.Called(room, body, subject)
+func (m *MockXMPPManager) SetRoomSubject(account, room, subject string) error {
+	args := m.Called(account, room, subject)
 	return args.Error(0)
 }
 
-func (m *MockXMPPManager) SendChatState(to string, state xmpp.ChatState) error {
-	args := m.Called(to, state)
+func (m *MockXMPPManager) CreateRoom(account, room, nick string, settings models.MUCRoomSettings) error {
+	args := m.Called(account, room, nick, settings)
+	return args.Error(0)
+}
+
+func (m *MockXMPPManager) ConfigureRoom(account, room string, settings models.MUCRoomSettings) error {
+	args := m.Called(account, room, settings)
+	return args.Error(0)
+}
+
+func (m *MockXMPPManager) DestroyRoom(account, room, alternateVenue, reason string) error {
+	args := m.Called(account, room, alternateVenue, reason)
+	return args.Error(0)
+}
+
+func (m *MockXMPPManager) GetRoomState(account, room string) (*models.MUCRoomState, error) {
+	args := m.Called(account, room)
+	if state := args.Get(0); state != nil {
+		return state.(*models.MUCRoomState), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockXMPPManager) SendMUCPrivateMessage(account, room, nick, body string) error {
+	args := m.Called(account, room, nick, body)
+	return args.Error(0)
+}
+
+func (m *MockXMPPManager) GetAccountsStatus() []models.AccountStatus {
+	args := m.Called()
+	if accounts := args.Get(0); accounts != nil {
+		return accounts.([]models.AccountStatus)
+	}
+	return nil
+}
+
+func (m *MockXMPPManager) SendChatState(account, to string, state xmpp.ChatState) error {
+	args := m.Called(account, to, state)
 	return args.Error(0)
 }
 
@@ -55,13 +132,13 @@ func (m *MockXMPPManager) GetWebhookChannel() <-chan models.Message {
 	return args.Get(0).(<-chan models.Message)
 }
 
-func (m *MockXMPPManager) SendFile(to, fileURL, fileName, fileType string) error {
-	args := m.Called(to, fileURL, fileName, fileType)
+func (m *MockXMPPManager) SendFile(account, to, fileURL, fileName, fileType string) error {
+	args := m.Called(account, to, fileURL, fileName, fileType)
 	return args.Error(0)
 }
 
-func (m *MockXMPPManager) SendFileXEP0363(to, filePath, fileName, fileType string) error {
-	args := m.Called(to, filePath, fileName, fileType)
+func (m *MockXMPPManager) SendFileXEP0363(account, to, filePath, fileName, fileType string) error {
+	args := m.Called(account, to, filePath, fileName, fileType)
 	return args.Error(0)
 }
 
@@ -110,7 +187,7 @@ func TestHandleSendMessage_Success(t *testing.T) {
 	}
 
 	manager := &MockXMPPManager{}
-	manager.On("SendMessage", "test@example.com", "Hello, world!", "chat").Return(nil)
+	manager.On("SendMessage", "", "", "test@example.com", "Hello, world!", "chat").Return(models.SendResult{}, nil)
 
 	app := fiber.New()
 	server := &Server{app: app, config: cfg, logger: logger, manager: manager}
@@ -219,7 +296,7 @@ func TestHandleSendMessage_XMPPError(t *testing.T) {
 	manager := &MockXMPPManager{}
 
 	expectedError := xmpp.ErrNoDefaultClient
-	manager.On("SendMessage", "test@example.com", "Hello, world!", "chat").Return(expectedError)
+	manager.On("SendMessage", "", "", "test@example.com", "Hello, world!", "chat").Return(models.SendResult{}, expectedError)
 
 	app := fiber.New()
 	server := &Server{app: app, config: cfg, logger: logger, manager: manager}
@@ -260,7 +337,7 @@ func TestHandleSendMUCMessage_Success(t *testing.T) {
 	}
 
 	manager := &MockXMPPManager{}
-	manager.On("SendMUCMessage", "room@conference.example.com", "Hello room!", "Room Topic").Return(nil)
+	manager.On("SendMUCMessage", "", "room@conference.example.com", "Hello room!", "Room Topic", []string(nil)).Return(models.SendResult{}, nil)
 
 	app := fiber.New()
 	server := &Server{app: app, config: cfg, logger: logger, manager: manager}
@@ -311,6 +388,12 @@ func TestHandleStatus(t *testing.T) {
 
 	manager := &MockXMPPManager{}
 	manager.On("IsConnected").Return(true)
+	manager.On("GetAccountsStatus").Return([]models.AccountStatus{
+		{Name: "default", JID: "bot@example.com", Connected: true, Rooms: []models.MUCRoomHealth{
+			{Room: "ops@conference.example.com", Nick: "bot", Joined: true, Healthy: true},
+		}},
+		{Name: "alerts", JID: "alerts@example.com", Connected: false},
+	})
 
 	app := fiber.New()
 	server := &Server{app: app, config: cfg, logger: logger, manager: manager}
@@ -340,6 +423,11 @@ func TestHandleStatus(t *testing.T) {
 	assert.True(t, response.APIRunning)
 	assert.Equal(t, "https://example.com/webhook", response.WebhookConfig)
 	assert.Equal(t, "1.0.0", response.Version)
+	require.Len(t, response.Accounts, 2)
+	require.Len(t, response.Accounts[0].Rooms, 1)
+	assert.True(t, response.Accounts[0].Rooms[0].Healthy)
+	assert.Equal(t, "alerts", response.Accounts[1].Name)
+	assert.False(t, response.Accounts[1].Connected)
 
 	manager.AssertExpectations(t)
 }
@@ -520,6 +608,15 @@ func TestValidateSendMUCMessageRequest(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "empty mention",
+			req: &models.SendMUCMessageRequest{
+				Room:     "room@conference.example.com",
+				Body:     "Hello room",
+				Mentions: []string{"alice", " "},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -543,7 +640,7 @@ func TestHandleSendChatState_Success(t *testing.T) {
 	}
 
 	manager := &MockXMPPManager{}
-	manager.On("SendChatState", "test@example.com", xmpp.ChatStateComposing).Return(nil)
+	manager.On("SendChatState", "", "test@example.com", xmpp.ChatStateComposing).Return(nil)
 
 	app := fiber.New()
 	server := &Server{app: app, config: cfg, logger: logger, manager: manager}
@@ -681,7 +778,7 @@ func TestHandleSendChatState_XMPPError(t *testing.T) {
 	manager := &MockXMPPManager{}
 
 	expectedError := xmpp.ErrNoDefaultClient
-	manager.On("SendChatState", "test@example.com", xmpp.ChatStateComposing).Return(expectedError)
+	manager.On("SendChatState", "", "test@example.com", xmpp.ChatStateComposing).Return(expectedError)
 
 	app := fiber.New()
 	server := &Server{app: app, config: cfg, logger: logger, manager: manager}
@@ -866,7 +963,7 @@ func TestHandleSendFile_Success(t *testing.T) {
 
 	manager := &MockXMPPManager{}
 	// Use flexible matching for file path and type
-	manager.On("SendFile", "user@example.com", mock.Anything, "test.txt", mock.Anything).Return(nil)
+	manager.On("SendFile", "", "user@example.com", mock.Anything, "test.txt", mock.Anything).Return(nil)
 
 	app := fiber.New()
 	server := &Server{app: app, config: cfg, logger: logger, manager: manager}
@@ -1079,7 +1176,7 @@ func TestHandleSendFile_XMPPError(t *testing.T) {
 	manager := &MockXMPPManager{}
 	expectedError := xmpp.ErrNoDefaultClient
 	// Use flexible matching for file path and type
-	manager.On("SendFile", "user@example.com", mock.Anything, "test.txt", mock.Anything).Return(expectedError)
+	manager.On("SendFile", "", "user@example.com", mock.Anything, "test.txt", mock.Anything).Return(expectedError)
 
 	app := fiber.New()
 	server := &Server{app: app, config: cfg, logger: logger, manager: manager}
@@ -1127,3 +1224,155 @@ func (s *Server) validateSendFileRequest(to, fileName string) error {
 
 	return nil
 }
+
+func TestHandleSendMessage_Account(t *testing.T) {
+	cfg := &config.Config{
+		Accounts: []config.AccountConfig{{Name: "alerts", JID: "alerts@example.com"}},
+	}
+
+	manager := &MockXMPPManager{}
+	manager.On("SendMessage", "alerts", "", "oncall@example.com", "Disk full", "chat").Return(models.SendResult{}, nil)
+
+	app, server := newTestServer(t, cfg, manager)
+	app.Post("/api/v1/send", server.handleSendMessage)
+	app.Get("/api/v1/muc/:room/occupants", server.handleGetOccupants)
+
+	resp := doJSON(t, app, "POST", "/api/v1/send", models.SendMessageRequest{
+		To:      "oncall@example.com",
+		Body:    "Disk full",
+		Type:    "chat",
+		Account: "alerts",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Unknown accounts are rejected before reaching the manager
+	resp = doJSON(t, app, "POST", "/api/v1/send", models.SendMessageRequest{
+		To:      "oncall@example.com",
+		Body:    "Disk full",
+		Account: "payroll",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = doJSON(t, app, "GET", "/api/v1/muc/ops@conference.example.com/occupants?account=payroll", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	manager.AssertExpectations(t)
+}
+
+func TestHandleSendMessage_From(t *testing.T) {
+	componentCfg := &config.Config{
+		XMPP: config.XMPPConfig{Mode: config.ModeComponent, Domain: "bot.example.org"},
+	}
+
+	manager := &MockXMPPManager{}
+	manager.On("SendMessage", "", "deploy", "alice@example.com", "Deployed", "").Return(models.SendResult{}, nil)
+
+	app, server := newTestServer(t, componentCfg, manager)
+	app.Post("/api/v1/send", server.handleSendMessage)
+
+	resp := doJSON(t, app, "POST", "/api/v1/send", models.SendMessageRequest{
+		To:   "alice@example.com",
+		Body: "Deployed",
+		From: "deploy",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doJSON(t, app, "POST", "/api/v1/send", models.SendMessageRequest{
+		To:   "alice@example.com",
+		Body: "Deployed",
+		From: "deploy@bot.example.org",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Client mode cannot choose the sender
+	clientApp, clientServer := newTestServer(t, &config.Config{}, manager)
+	clientApp.Post("/api/v1/send", clientServer.handleSendMessage)
+
+	resp = doJSON(t, clientApp, "POST", "/api/v1/send", models.SendMessageRequest{
+		To:   "alice@example.com",
+		Body: "Deployed",
+		From: "deploy",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	manager.AssertExpectations(t)
+}
+
+func TestHandleSendMessage_Queued(t *testing.T) {
+	expiresAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	manager := &MockXMPPManager{}
+	manager.On("SendMessage", "", "", "oncall@example.com", "Disk full", "chat").
+		Return(models.SendResult{Queued: true, MessageID: "outbox-1", ExpiresAt: expiresAt}, nil).Once()
+	manager.On("SendMessage", "", "", "oncall@example.com", "Disk full", "chat").
+		Return(models.SendResult{}, xmpp.ErrOutboxFull).Once()
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Post("/api/v1/send", server.handleSendMessage)
+
+	request := models.SendMessageRequest{To: "oncall@example.com", Body: "Disk full", Type: "chat"}
+	resp := doJSON(t, app, "POST", "/api/v1/send", request)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var response models.APIResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	data := response.Data.(map[string]interface{})
+	assert.Equal(t, "outbox-1", data["message_id"])
+	assert.Equal(t, "2026-01-02T03:04:05Z", data["expires_at"])
+
+	resp = doJSON(t, app, "POST", "/api/v1/send", request)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	manager.AssertExpectations(t)
+}
+
+func TestHandleSendMUCMessage_Queued(t *testing.T) {
+	expiresAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	manager := &MockXMPPManager{}
+	manager.On("SendMUCMessage", "", "ops@conference.example.com", "Disk full", "", []string(nil)).
+		Return(models.SendResult{Queued: true, MessageID: "outbox-1", ExpiresAt: expiresAt}, nil).Once()
+	manager.On("SendMUCMessage", "", "ops@conference.example.com", "Disk full", "", []string(nil)).
+		Return(models.SendResult{}, xmpp.ErrOutboxFull).Once()
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Post("/api/v1/send-muc", server.handleSendMUCMessage)
+
+	request := models.SendMUCMessageRequest{Room: "ops@conference.example.com", Body: "Disk full"}
+	resp := doJSON(t, app, "POST", "/api/v1/send-muc", request)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var response models.APIResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	data := response.Data.(map[string]interface{})
+	assert.Equal(t, "outbox-1", data["message_id"])
+	assert.Equal(t, "2026-01-02T03:04:05Z", data["expires_at"])
+
+	resp = doJSON(t, app, "POST", "/api/v1/send-muc", request)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	manager.AssertExpectations(t)
+}
+
+func TestHandleSendMessage_RateLimited(t *testing.T) {
+	manager := &MockXMPPManager{}
+	manager.On("SendMessage", "", "", "oncall@example.com", "Disk full", "chat").
+		Return(models.SendResult{Delay: 1500 * time.Millisecond}, nil).Once()
+	manager.On("SendMessage", "", "", "oncall@example.com", "Disk full", "chat").
+		Return(models.SendResult{}, xmpp.ErrRateLimited).Once()
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Post("/api/v1/send", server.handleSendMessage)
+
+	request := models.SendMessageRequest{To: "oncall@example.com", Body: "Disk full", Type: "chat"}
+	resp := doJSON(t, app, "POST", "/api/v1/send", request)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response models.APIResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, float64(1500), response.Data.(map[string]interface{})["delay_ms"])
+
+	resp = doJSON(t, app, "POST", "/api/v1/send", request)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	manager.AssertExpectations(t)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"jabber-bot/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// newTestServer creates a server with locals injected, without routes or auth middleware
+func newTestServer(t *testing.T, cfg *config.Config, manager XMPPManagerInterface) (*fiber.App, *Server) {
+	t.Helper()
+	logger := zaptest.NewLogger(t)
+
+	app := fiber.New()
+	server := &Server{app: app, config: cfg, logger: logger, manager: manager}
+
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("logger", logger)
+		c.Locals("config", cfg)
+		c.Locals("manager", manager)
+		return c.Next()
+	})
+
+	return app, server
+}
+
+// doJSON performs a JSON request against the test app
+func doJSON(t *testing.T, app *fiber.App, method, path string, body interface{}) *http.Response {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(bodyBytes)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
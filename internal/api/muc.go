@@ -0,0 +1,710 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/xmpp"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// handleMUCInvite handles POST /api/v1/muc/invite
+func (s *Server) handleMUCInvite(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	var req models.MUCInviteRequest
+	if err := c.BodyParser(&req); err != nil {
+		logger.Warn("Invalid request body",
+			zap.Error(err),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := s.validateMUCInviteRequest(&req); err != nil {
+		logger.Warn("Request validation failed",
+			zap.Error(err),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	logger.Info("Sending MUC invitations",
+		zap.String("room", req.Room),
+		zap.Strings("jids", req.JIDs),
+		zap.Bool("mediated", req.Mediated),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	if err := manager.InviteToRoom(req.Account, req.Room, req.JIDs, req.Reason, req.Mediated); err != nil {
+		logger.Error("Failed to send MUC invitations",
+			zap.Error(err),
+			zap.String("room", req.Room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+
+		response := models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to send invitations: " + err.Error(),
+			Code:    fiber.StatusInternalServerError,
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	response := models.APIResponse{
+		Success: true,
+		Message: "Invitations sent successfully",
+		Data: map[string]interface{}{
+			"room":       req.Room,
+			"jids":       req.JIDs,
+			"mediated":   req.Mediated,
+			"sent_at":    time.Now().UTC().Format(time.RFC3339),
+			"request_id": c.GetRespHeader("X-Request-ID"),
+		},
+	}
+
+	return c.JSON(response)
+}
+
+// validateMUCInviteRequest validates MUC invite request
+func (s *Server) validateMUCInviteRequest(req *models.MUCInviteRequest) error {
+	if strings.TrimSpace(req.Room) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "room field is required")
+	}
+
+	if !strings.Contains(req.Room, "@") {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid room JID format")
+	}
+
+	if len(req.JIDs) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "jids field is required")
+	}
+
+	for _, jid := range req.JIDs {
+		if !strings.Contains(jid, "@") {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid JID format: "+jid)
+		}
+	}
+
+	return s.validateAccount(req.Account)
+}
+
+var validAffiliations = map[string]bool{
+	"owner":   true,
+	"admin":   true,
+	"member":  true,
+	"outcast": true,
+	"none":    true,
+}
+
+var validRoles = map[string]bool{
+	"moderator":   true,
+	"participant": true,
+	"visitor":     true,
+	"none":        true,
+}
+
+// handleGetOccupants handles GET /api/v1/muc/:room/occupants
+func (s *Server) handleGetOccupants(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	room, err := roomParam(c)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.accountQuery(c)
+	if err != nil {
+		return err
+	}
+
+	state, err := manager.GetRoomState(account, room)
+	if err != nil {
+		if errors.Is(err, xmpp.ErrRoomNotJoined) {
+			return fiber.NewError(fiber.StatusNotFound, "not joined to room "+room)
+		}
+
+		logger.Error("Failed to get MUC room state",
+			zap.Error(err),
+			zap.String("room", room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return mucErrorResponse(c, "Failed to get occupants", err)
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    state,
+	})
+}
+
+// handleSendMUCPrivateMessage handles POST /api/v1/muc/:room/private
+func (s *Server) handleSendMUCPrivateMessage(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	room, err := roomParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.MUCPrivateMessageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if strings.TrimSpace(req.Nick) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "nick field is required")
+	}
+
+	if strings.TrimSpace(req.Body) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "body field is required")
+	}
+
+	if len(req.Body) > 10000 {
+		return fiber.NewError(fiber.StatusBadRequest, "body field too long (max 10000 characters)")
+	}
+
+	if err := s.validateAccount(req.Account); err != nil {
+		return err
+	}
+
+	logger.Info("Sending MUC private message",
+		zap.String("room", room),
+		zap.String("nick", req.Nick),
+		zap.Int("body_length", len(req.Body)),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	if err := manager.SendMUCPrivateMessage(req.Account, room, req.Nick, req.Body); err != nil {
+		logger.Error("Failed to send MUC private message",
+			zap.Error(err),
+			zap.String("room", room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return mucErrorResponse(c, "Failed to send private message", err)
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Private message sent successfully",
+		Data: map[string]interface{}{
+			"room":        room,
+			"nick":        req.Nick,
+			"body_length": len(req.Body),
+			"sent_at":     time.Now().UTC().Format(time.RFC3339),
+			"request_id":  c.GetRespHeader("X-Request-ID"),
+		},
+	})
+}
+
+// handleGetAffiliations handles GET /api/v1/muc/:room/affiliations
+func (s *Server) handleGetAffiliations(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	room, err := roomParam(c)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.accountQuery(c)
+	if err != nil {
+		return err
+	}
+
+	affiliation := c.Query("affiliation")
+	if affiliation != "" && (!validAffiliations[affiliation] || affiliation == "none") {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid affiliation. Must be one of: owner, admin, member, outcast")
+	}
+
+	items, err := manager.GetAffiliations(account, room, affiliation)
+	if err != nil {
+		logger.Error("Failed to list MUC affiliations",
+			zap.Error(err),
+			zap.String("room", room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return mucErrorResponse(c, "Failed to list affiliations", err)
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"room":  room,
+			"items": items,
+		},
+	})
+}
+
+// handleSetAffiliation handles PUT /api/v1/muc/:room/affiliations
+func (s *Server) handleSetAffiliation(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	room, err := roomParam(c)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.accountQuery(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.MUCAffiliationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if !strings.Contains(req.JID, "@") {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid JID format")
+	}
+
+	if !validAffiliations[req.Affiliation] {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid affiliation. Must be one of: owner, admin, member, outcast, none")
+	}
+
+	logger.Info("Changing MUC affiliation",
+		zap.String("room", room),
+		zap.String("jid", req.JID),
+		zap.String("affiliation", req.Affiliation),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	if err := manager.SetAffiliation(account, room, req.JID, req.Affiliation, req.Reason); err != nil {
+		logger.Error("Failed to change MUC affiliation",
+			zap.Error(err),
+			zap.String("room", room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return mucErrorResponse(c, "Failed to set affiliation", err)
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Affiliation changed successfully",
+		Data: map[string]interface{}{
+			"room":        room,
+			"jid":         req.JID,
+			"affiliation": req.Affiliation,
+			"request_id":  c.GetRespHeader("X-Request-ID"),
+		},
+	})
+}
+
+// handleGetRoles handles GET /api/v1/muc/:room/roles
+func (s *Server) handleGetRoles(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	room, err := roomParam(c)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.accountQuery(c)
+	if err != nil {
+		return err
+	}
+
+	role := c.Query("role", "moderator")
+	if !validRoles[role] || role == "none" {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid role. Must be one of: moderator, participant, visitor")
+	}
+
+	items, err := manager.GetRoles(account, room, role)
+	if err != nil {
+		logger.Error("Failed to list MUC roles",
+			zap.Error(err),
+			zap.String("room", room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return mucErrorResponse(c, "Failed to list roles", err)
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"room":  room,
+			"role":  role,
+			"items": items,
+		},
+	})
+}
+
+// handleSetRole handles PUT /api/v1/muc/:room/roles
+func (s *Server) handleSetRole(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	room, err := roomParam(c)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.accountQuery(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.MUCRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if strings.TrimSpace(req.Nick) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "nick field is required")
+	}
+
+	if !validRoles[req.Role] {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid role. Must be one of: moderator, participant, visitor, none")
+	}
+
+	logger.Info("Changing MUC role",
+		zap.String("room", room),
+		zap.String("nick", req.Nick),
+		zap.String("role", req.Role),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	if err := manager.SetRole(account, room, req.Nick, req.Role, req.Reason); err != nil {
+		logger.Error("Failed to change MUC role",
+			zap.Error(err),
+			zap.String("room", room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return mucErrorResponse(c, "Failed to set role", err)
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Role changed successfully",
+		Data: map[string]interface{}{
+			"room":       room,
+			"nick":       req.Nick,
+			"role":       req.Role,
+			"request_id": c.GetRespHeader("X-Request-ID"),
+		},
+	})
+}
+
+// handleKickOccupant handles POST /api/v1/muc/:room/kick
+func (s *Server) handleKickOccupant(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	room, err := roomParam(c)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.accountQuery(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.MUCKickRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if strings.TrimSpace(req.Nick) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "nick field is required")
+	}
+
+	logger.Info("Kicking MUC occupant",
+		zap.String("room", room),
+		zap.String("nick", req.Nick),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	if err := manager.KickOccupant(account, room, req.Nick, req.Reason); err != nil {
+		logger.Error("Failed to kick MUC occupant",
+			zap.Error(err),
+			zap.String("room", room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return mucErrorResponse(c, "Failed to kick occupant", err)
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Occupant kicked successfully",
+		Data: map[string]interface{}{
+			"room":       room,
+			"nick":       req.Nick,
+			"request_id": c.GetRespHeader("X-Request-ID"),
+		},
+	})
+}
+
+// handleSetRoomSubject handles PUT /api/v1/muc/:room/subject
+func (s *Server) handleSetRoomSubject(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	room, err := roomParam(c)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.accountQuery(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.MUCSubjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	logger.Info("Changing MUC subject",
+		zap.String("room", room),
+		zap.String("subject", req.Subject),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	if err := manager.SetRoomSubject(account, room, req.Subject); err != nil {
+		logger.Error("Failed to change MUC subject",
+			zap.Error(err),
+			zap.String("room", room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return mucErrorResponse(c, "Failed to set subject", err)
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Subject changed successfully",
+		Data: map[string]interface{}{
+			"room":       room,
+			"subject":    req.Subject,
+			"request_id": c.GetRespHeader("X-Request-ID"),
+		},
+	})
+}
+
+var validWhois = map[string]bool{
+	"moderators": true,
+	"anyone":     true,
+}
+
+// handleCreateRoom handles POST /api/v1/muc/rooms
+func (s *Server) handleCreateRoom(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	var req models.MUCCreateRoomRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if strings.TrimSpace(req.Room) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "room field is required")
+	}
+
+	if !strings.Contains(req.Room, "@") {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid room JID format")
+	}
+
+	if err := validateRoomSettings(&req.MUCRoomSettings); err != nil {
+		return err
+	}
+
+	if err := s.validateAccount(req.Account); err != nil {
+		return err
+	}
+
+	logger.Info("Creating MUC room",
+		zap.String("room", req.Room),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	if err := manager.CreateRoom(req.Account, req.Room, req.Nick, req.MUCRoomSettings); err != nil {
+		logger.Error("Failed to create MUC room",
+			zap.Error(err),
+			zap.String("room", req.Room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return mucErrorResponse(c, "Failed to create room", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Success: true,
+		Message: "Room created successfully",
+		Data: map[string]interface{}{
+			"room":       req.Room,
+			"request_id": c.GetRespHeader("X-Request-ID"),
+		},
+	})
+}
+
+// handleConfigureRoom handles PUT /api/v1/muc/:room/config
+func (s *Server) handleConfigureRoom(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	room, err := roomParam(c)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.accountQuery(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.MUCRoomSettings
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := validateRoomSettings(&req); err != nil {
+		return err
+	}
+
+	logger.Info("Configuring MUC room",
+		zap.String("room", room),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	if err := manager.ConfigureRoom(account, room, req); err != nil {
+		logger.Error("Failed to configure MUC room",
+			zap.Error(err),
+			zap.String("room", room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return mucErrorResponse(c, "Failed to configure room", err)
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Room configured successfully",
+		Data: map[string]interface{}{
+			"room":       room,
+			"request_id": c.GetRespHeader("X-Request-ID"),
+		},
+	})
+}
+
+// handleDestroyRoom handles DELETE /api/v1/muc/:room
+func (s *Server) handleDestroyRoom(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+	manager := c.Locals("manager").(XMPPManagerInterface)
+
+	room, err := roomParam(c)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.accountQuery(c)
+	if err != nil {
+		return err
+	}
+
+	// The body is optional for a plain destroy
+	var req models.MUCDestroyRoomRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	if req.AlternateVenue != "" && !strings.Contains(req.AlternateVenue, "@") {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid alternate_venue JID format")
+	}
+
+	logger.Info("Destroying MUC room",
+		zap.String("room", room),
+		zap.String("alternate_venue", req.AlternateVenue),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	if err := manager.DestroyRoom(account, room, req.AlternateVenue, req.Reason); err != nil {
+		logger.Error("Failed to destroy MUC room",
+			zap.Error(err),
+			zap.String("room", room),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return mucErrorResponse(c, "Failed to destroy room", err)
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Room destroyed successfully",
+		Data: map[string]interface{}{
+			"room":            room,
+			"alternate_venue": req.AlternateVenue,
+			"request_id":      c.GetRespHeader("X-Request-ID"),
+		},
+	})
+}
+
+// validateRoomSettings validates room configuration settings
+func validateRoomSettings(settings *models.MUCRoomSettings) error {
+	if settings.Whois != "" && !validWhois[settings.Whois] {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid whois. Must be one of: moderators, anyone")
+	}
+
+	return nil
+}
+
+// roomParam returns the unescaped room JID from the route
+func roomParam(c *fiber.Ctx) (string, error) {
+	room, err := url.PathUnescape(c.Params("room"))
+	if err != nil || !strings.Contains(room, "@") {
+		return "", fiber.NewError(fiber.StatusBadRequest, "invalid room JID format")
+	}
+	return room, nil
+}
+
+// accountQuery returns the account selected by the account query parameter of room endpoints
+func (s *Server) accountQuery(c *fiber.Ctx) (string, error) {
+	account := c.Query("account")
+	if err := s.validateAccount(account); err != nil {
+		return "", err
+	}
+	return account, nil
+}
+
+// mucErrorResponse returns an error response for a failed MUC operation. Errors returned by the
+// room get the status matching their condition; transport failures and timeouts are 500.
+func mucErrorResponse(c *fiber.Ctx, message string, err error) error {
+	status := fiber.StatusInternalServerError
+	if iqErr, ok := errors.AsType[*xmpp.IQError](err); ok {
+		status = iqErrorStatus(iqErr.Condition)
+	}
+
+	response := models.ErrorResponse{
+		Success: false,
+		Error:   message + ": " + err.Error(),
+		Code:    status,
+	}
+
+	return c.Status(status).JSON(response)
+}
+
+// iqErrorStatus maps the condition of an XMPP stanza error (RFC 6120 section 8.3.3) to an HTTP status
+func iqErrorStatus(condition string) int {
+	switch condition {
+	case "forbidden", "not-allowed", "not-authorized", "registration-required":
+		return fiber.StatusForbidden
+	case "item-not-found", "recipient-unavailable", "remote-server-not-found":
+		return fiber.StatusNotFound
+	case "conflict":
+		return fiber.StatusConflict
+	case "bad-request", "jid-malformed", "not-acceptable", "policy-violation":
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
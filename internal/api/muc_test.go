@@ -0,0 +1,362 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/xmpp"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleMUCInvite_Success(t *testing.T) {
+	manager := &MockXMPPManager{}
+	manager.On("InviteToRoom", "", "inc-1234@conference.example.com", []string{"alice@example.com"}, "Incident", false).Return(nil)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Post("/api/v1/muc/invite", server.handleMUCInvite)
+
+	resp := doJSON(t, app, "POST", "/api/v1/muc/invite", models.MUCInviteRequest{
+		Room:   "inc-1234@conference.example.com",
+		JIDs:   []string{"alice@example.com"},
+		Reason: "Incident",
+	})
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	manager.AssertExpectations(t)
+}
+
+func TestHandleMUCInvite_XMPPError(t *testing.T) {
+	manager := &MockXMPPManager{}
+	manager.On("InviteToRoom", "", "room@conference.example.com", []string{"alice@example.com"}, "", true).Return(assert.AnError)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Post("/api/v1/muc/invite", server.handleMUCInvite)
+
+	resp := doJSON(t, app, "POST", "/api/v1/muc/invite", models.MUCInviteRequest{
+		Room:     "room@conference.example.com",
+		JIDs:     []string{"alice@example.com"},
+		Mediated: true,
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	manager.AssertExpectations(t)
+}
+
+func TestValidateMUCInviteRequest(t *testing.T) {
+	server := &Server{}
+
+	tests := []struct {
+		name    string
+		req     models.MUCInviteRequest
+		wantErr string
+	}{
+		{"valid", models.MUCInviteRequest{Room: "room@conference.example.com", JIDs: []string{"a@example.com"}}, ""},
+		{"missing room", models.MUCInviteRequest{JIDs: []string{"a@example.com"}}, "room field is required"},
+		{"invalid room", models.MUCInviteRequest{Room: "room", JIDs: []string{"a@example.com"}}, "invalid room JID format"},
+		{"missing jids", models.MUCInviteRequest{Room: "room@conference.example.com"}, "jids field is required"},
+		{"invalid jid", models.MUCInviteRequest{Room: "room@conference.example.com", JIDs: []string{"alice"}}, "invalid JID format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := server.validateMUCInviteRequest(&tt.req)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHandleGetAffiliations(t *testing.T) {
+	manager := &MockXMPPManager{}
+	manager.On("GetAffiliations", "", "room@conference.example.com", "outcast").Return([]models.MUCItem{
+		{JID: "spammer@example.com", Affiliation: "outcast"},
+	}, nil)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Get("/api/v1/muc/:room/affiliations", server.handleGetAffiliations)
+
+	resp := doJSON(t, app, "GET", "/api/v1/muc/room@conference.example.com/affiliations?affiliation=outcast", nil)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	manager.AssertExpectations(t)
+}
+
+func TestHandleSetAffiliation(t *testing.T) {
+	manager := &MockXMPPManager{}
+	manager.On("SetAffiliation", "", "room@conference.example.com", "spammer@example.com", "outcast", "Spam").Return(nil)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Put("/api/v1/muc/:room/affiliations", server.handleSetAffiliation)
+
+	resp := doJSON(t, app, "PUT", "/api/v1/muc/room%40conference.example.com/affiliations", models.MUCAffiliationRequest{
+		JID:         "spammer@example.com",
+		Affiliation: "outcast",
+		Reason:      "Spam",
+	})
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	manager.AssertExpectations(t)
+}
+
+func TestHandleSetAffiliation_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		req  models.MUCAffiliationRequest
+	}{
+		{"invalid room", "/api/v1/muc/room/affiliations", models.MUCAffiliationRequest{JID: "a@example.com", Affiliation: "member"}},
+		{"invalid jid", "/api/v1/muc/room@conference.example.com/affiliations", models.MUCAffiliationRequest{JID: "alice", Affiliation: "member"}},
+		{"invalid affiliation", "/api/v1/muc/room@conference.example.com/affiliations", models.MUCAffiliationRequest{JID: "a@example.com", Affiliation: "god"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := &MockXMPPManager{}
+			app, server := newTestServer(t, &config.Config{}, manager)
+			app.Put("/api/v1/muc/:room/affiliations", server.handleSetAffiliation)
+
+			resp := doJSON(t, app, "PUT", tt.path, tt.req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			manager.AssertNotCalled(t, "SetAffiliation")
+		})
+	}
+}
+
+func TestHandleGetRoles_DefaultsToModerator(t *testing.T) {
+	manager := &MockXMPPManager{}
+	manager.On("GetRoles", "", "room@conference.example.com", "moderator").Return([]models.MUCItem{}, nil)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Get("/api/v1/muc/:room/roles", server.handleGetRoles)
+
+	resp := doJSON(t, app, "GET", "/api/v1/muc/room@conference.example.com/roles", nil)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	manager.AssertExpectations(t)
+}
+
+func TestHandleSetRole(t *testing.T) {
+	manager := &MockXMPPManager{}
+	manager.On("SetRole", "", "room@conference.example.com", "alice", "visitor", "").Return(nil)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Put("/api/v1/muc/:room/roles", server.handleSetRole)
+
+	resp := doJSON(t, app, "PUT", "/api/v1/muc/room@conference.example.com/roles", models.MUCRoleRequest{
+		Nick: "alice",
+		Role: "visitor",
+	})
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	manager.AssertExpectations(t)
+}
+
+func TestHandleKickOccupant(t *testing.T) {
+	manager := &MockXMPPManager{}
+	manager.On("KickOccupant", "", "room@conference.example.com", "noisy", "Bye").Return(assert.AnError)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Post("/api/v1/muc/:room/kick", server.handleKickOccupant)
+
+	resp := doJSON(t, app, "POST", "/api/v1/muc/room@conference.example.com/kick", models.MUCKickRequest{
+		Nick:   "noisy",
+		Reason: "Bye",
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	manager.AssertExpectations(t)
+}
+
+func TestHandleKickOccupant_IQError(t *testing.T) {
+	tests := []struct {
+		condition string
+		status    int
+	}{
+		{"forbidden", http.StatusForbidden},
+		{"not-allowed", http.StatusForbidden},
+		{"item-not-found", http.StatusNotFound},
+		{"conflict", http.StatusConflict},
+		{"bad-request", http.StatusBadRequest},
+		{"internal-server-error", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			manager := &MockXMPPManager{}
+			manager.On("KickOccupant", "", "room@conference.example.com", "noisy", "").
+				Return(fmt.Errorf("failed to kick noisy: %w", &xmpp.IQError{Condition: tt.condition}))
+
+			app, server := newTestServer(t, &config.Config{}, manager)
+			app.Post("/api/v1/muc/:room/kick", server.handleKickOccupant)
+
+			resp := doJSON(t, app, "POST", "/api/v1/muc/room@conference.example.com/kick", models.MUCKickRequest{Nick: "noisy"})
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestHandleSetRoomSubject(t *testing.T) {
+	manager := &MockXMPPManager{}
+	manager.On("SetRoomSubject", "", "room@conference.example.com", "Incident resolved").Return(nil)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Put("/api/v1/muc/:room/subject", server.handleSetRoomSubject)
+
+	resp := doJSON(t, app, "PUT", "/api/v1/muc/room@conference.example.com/subject", models.MUCSubjectRequest{
+		Subject: "Incident resolved",
+	})
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	manager.AssertExpectations(t)
+}
+
+func TestHandleCreateRoom(t *testing.T) {
+	name := "INC-1234"
+	membersOnly := true
+	settings := models.MUCRoomSettings{Name: &name, MembersOnly: &membersOnly, Whois: "moderators"}
+
+	manager := &MockXMPPManager{}
+	manager.On("CreateRoom", "", "inc-1234@conference.example.com", "", settings).Return(nil)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Post("/api/v1/muc/rooms", server.handleCreateRoom)
+
+	resp := doJSON(t, app, "POST", "/api/v1/muc/rooms", models.MUCCreateRoomRequest{
+		Room:            "inc-1234@conference.example.com",
+		MUCRoomSettings: settings,
+	})
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	manager.AssertExpectations(t)
+}
+
+func TestHandleCreateRoom_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.MUCCreateRoomRequest
+	}{
+		{"missing room", models.MUCCreateRoomRequest{}},
+		{"invalid room", models.MUCCreateRoomRequest{Room: "room"}},
+		{"invalid whois", models.MUCCreateRoomRequest{Room: "room@conference.example.com", MUCRoomSettings: models.MUCRoomSettings{Whois: "everyone"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := &MockXMPPManager{}
+			app, server := newTestServer(t, &config.Config{}, manager)
+			app.Post("/api/v1/muc/rooms", server.handleCreateRoom)
+
+			resp := doJSON(t, app, "POST", "/api/v1/muc/rooms", tt.req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			manager.AssertNotCalled(t, "CreateRoom")
+		})
+	}
+}
+
+func TestHandleConfigureRoom_XMPPError(t *testing.T) {
+	persistent := false
+	settings := models.MUCRoomSettings{Persistent: &persistent}
+
+	manager := &MockXMPPManager{}
+	manager.On("ConfigureRoom", "", "room@conference.example.com", settings).Return(assert.AnError)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Put("/api/v1/muc/:room/config", server.handleConfigureRoom)
+
+	resp := doJSON(t, app, "PUT", "/api/v1/muc/room@conference.example.com/config", settings)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	manager.AssertExpectations(t)
+}
+
+func TestHandleDestroyRoom(t *testing.T) {
+	manager := &MockXMPPManager{}
+	manager.On("DestroyRoom", "", "inc-1234@conference.example.com", "postmortem@conference.example.com", "Resolved").Return(nil)
+	manager.On("DestroyRoom", "", "old@conference.example.com", "", "").Return(nil)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Delete("/api/v1/muc/:room", server.handleDestroyRoom)
+
+	resp := doJSON(t, app, "DELETE", "/api/v1/muc/inc-1234@conference.example.com", models.MUCDestroyRoomRequest{
+		AlternateVenue: "postmortem@conference.example.com",
+		Reason:         "Resolved",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doJSON(t, app, "DELETE", "/api/v1/muc/old@conference.example.com", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doJSON(t, app, "DELETE", "/api/v1/muc/old@conference.example.com", models.MUCDestroyRoomRequest{AlternateVenue: "elsewhere"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	manager.AssertExpectations(t)
+}
+
+func TestHandleGetOccupants(t *testing.T) {
+	manager := &MockXMPPManager{}
+	manager.On("GetRoomState", "", "room@conference.example.com").Return(&models.MUCRoomState{
+		Room:      "room@conference.example.com",
+		Nick:      "bot",
+		Occupants: []models.Occupant{{Room: "room@conference.example.com", Nick: "alice", Role: "moderator"}},
+	}, nil)
+	manager.On("GetRoomState", "", "other@conference.example.com").Return(nil, xmpp.ErrRoomNotJoined)
+	manager.On("GetRoomState", "support", "help@conference.example.com").Return(&models.MUCRoomState{
+		Room: "help@conference.example.com",
+		Nick: "Helpdesk",
+	}, nil)
+
+	cfg := &config.Config{
+		Accounts: []config.AccountConfig{{Name: "support", JID: "support@example.com"}},
+	}
+	app, server := newTestServer(t, cfg, manager)
+	app.Get("/api/v1/muc/:room/occupants", server.handleGetOccupants)
+
+	resp := doJSON(t, app, "GET", "/api/v1/muc/room@conference.example.com/occupants", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data models.MUCRoomState `json:"data"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "alice", body.Data.Occupants[0].Nick)
+
+	resp = doJSON(t, app, "GET", "/api/v1/muc/other@conference.example.com/occupants", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = doJSON(t, app, "GET", "/api/v1/muc/help@conference.example.com/occupants?account=support", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	manager.AssertExpectations(t)
+}
+
+func TestHandleSendMUCPrivateMessage(t *testing.T) {
+	manager := &MockXMPPManager{}
+	manager.On("SendMUCPrivateMessage", "", "room@conference.example.com", "alice", "psst").Return(nil)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	app.Post("/api/v1/muc/:room/private", server.handleSendMUCPrivateMessage)
+
+	resp := doJSON(t, app, "POST", "/api/v1/muc/room@conference.example.com/private", models.MUCPrivateMessageRequest{
+		Nick: "alice",
+		Body: "psst",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doJSON(t, app, "POST", "/api/v1/muc/room@conference.example.com/private", models.MUCPrivateMessageRequest{
+		Body: "psst",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	manager.AssertExpectations(t)
+}
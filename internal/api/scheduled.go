@@ -0,0 +1,151 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/scheduler"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// maxScheduleAhead is how far in the future a message can be scheduled
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+// SchedulerInterface defines the interface for scheduled message and schedule operations
+type SchedulerInterface interface {
+	Schedule(msg models.ScheduledMessage) (models.ScheduledMessage, error)
+	List() []models.ScheduledMessage
+	Cancel(id string) error
+	AddSchedule(req models.ScheduleRequest) (models.Schedule, error)
+	Schedules() []models.Schedule
+	GetSchedule(name string) (models.Schedule, error)
+	DeleteSchedule(name string) error
+}
+
+// SetScheduler enables send_at and delay on send requests and the /scheduled and /schedules
+// endpoints
+func (s *Server) SetScheduler(scheduler SchedulerInterface) {
+	s.scheduler = scheduler
+}
+
+// scheduleMessage stores a send request for later delivery and answers it with 202
+func (s *Server) scheduleMessage(c *fiber.Ctx, msg models.ScheduledMessage, sendAt, delay string) error {
+	logger := c.Locals("logger").(*zap.Logger)
+
+	if s.scheduler == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "scheduled messages are not available")
+	}
+
+	at, err := parseSendTime(sendAt, delay, time.Now())
+	if err != nil {
+		return err
+	}
+	msg.SendAt = at
+
+	scheduled, err := s.scheduler.Schedule(msg)
+	if errors.Is(err, scheduler.ErrFull) {
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	}
+	if err != nil {
+		logger.Error("Failed to schedule message",
+			zap.Error(err),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return err
+	}
+
+	logger.Info("Message scheduled",
+		zap.String("id", scheduled.ID),
+		zap.String("account", scheduled.Account),
+		zap.String("to", scheduled.To),
+		zap.String("room", scheduled.Room),
+		zap.Time("send_at", scheduled.SendAt),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	return c.Status(fiber.StatusAccepted).JSON(models.APIResponse{
+		Success: true,
+		Message: "Message scheduled",
+		Data: map[string]interface{}{
+			"id":          scheduled.ID,
+			"to":          scheduled.To,
+			"room":        scheduled.Room,
+			"body_length": len(scheduled.Body),
+			"send_at":     scheduled.SendAt.UTC().Format(time.RFC3339),
+			"request_id":  c.GetRespHeader("X-Request-ID"),
+		},
+	})
+}
+
+// parseSendTime returns the delivery time of a send request with send_at or delay
+func parseSendTime(sendAt, delay string, now time.Time) (time.Time, error) {
+	if sendAt != "" && delay != "" {
+		return time.Time{}, fiber.NewError(fiber.StatusBadRequest, "send_at and delay fields are mutually exclusive")
+	}
+
+	var at time.Time
+	if delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil || d <= 0 {
+			return time.Time{}, fiber.NewError(fiber.StatusBadRequest, "delay must be a positive duration, e.g. 15m")
+		}
+		at = now.Add(d)
+	} else {
+		var err error
+		at, err = time.Parse(time.RFC3339, sendAt)
+		if err != nil {
+			return time.Time{}, fiber.NewError(fiber.StatusBadRequest, "send_at must be an RFC 3339 time, e.g. 2026-01-02T15:04:05Z")
+		}
+		if !at.After(now) {
+			return time.Time{}, fiber.NewError(fiber.StatusBadRequest, "send_at must be in the future")
+		}
+	}
+
+	if at.Sub(now) > maxScheduleAhead {
+		return time.Time{}, fiber.NewError(fiber.StatusBadRequest, "messages can be scheduled at most one year ahead")
+	}
+	return at, nil
+}
+
+// handleListScheduled handles GET /api/v1/scheduled
+func (s *Server) handleListScheduled(c *fiber.Ctx) error {
+	if s.scheduler == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "scheduled messages are not available")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    s.scheduler.List(),
+	})
+}
+
+// handleCancelScheduled handles DELETE /api/v1/scheduled/:id
+func (s *Server) handleCancelScheduled(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+
+	if s.scheduler == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "scheduled messages are not available")
+	}
+
+	id := c.Params("id")
+	err := s.scheduler.Cancel(id)
+	if errors.Is(err, scheduler.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "scheduled message "+id+" not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Scheduled message cancelled",
+		zap.String("id", id),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Scheduled message cancelled",
+	})
+}
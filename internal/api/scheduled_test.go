@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/scheduler"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestScheduledEndpoints(t *testing.T) {
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{
+		Path:       filepath.Join(t.TempDir(), "scheduled.json"),
+		MaxPending: 10,
+	}}
+	manager := &MockXMPPManager{}
+
+	app, server := newTestServer(t, cfg, manager)
+	// Not started, so nothing is delivered during the test
+	server.SetScheduler(scheduler.NewManager(cfg, zap.NewNop(), manager))
+	app.Post("/api/v1/send", server.handleSendMessage)
+	app.Post("/api/v1/send-muc", server.handleSendMUCMessage)
+	app.Get("/api/v1/scheduled", server.handleListScheduled)
+	app.Delete("/api/v1/scheduled/:id", server.handleCancelScheduled)
+
+	resp := doJSON(t, app, "POST", "/api/v1/send", models.SendMessageRequest{
+		To:    "bob@example.com",
+		Body:  "Standup in 5 minutes",
+		Delay: "25m",
+	})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var scheduled models.APIResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&scheduled))
+	id := scheduled.Data.(map[string]interface{})["id"].(string)
+
+	sendAt := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	resp = doJSON(t, app, "POST", "/api/v1/send-muc", models.SendMUCMessageRequest{
+		Room:   "standup@conference.example.com",
+		Body:   "Release freeze starts now",
+		SendAt: sendAt,
+	})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resp = doJSON(t, app, "GET", "/api/v1/scheduled", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list struct {
+		Data []models.ScheduledMessage `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.Data, 2)
+	assert.Equal(t, id, list.Data[0].ID)
+	assert.Equal(t, "bob@example.com", list.Data[0].To)
+	assert.Equal(t, "standup@conference.example.com", list.Data[1].Room)
+	assert.Equal(t, sendAt, list.Data[1].SendAt.UTC().Format(time.RFC3339))
+
+	resp = doJSON(t, app, "DELETE", "/api/v1/scheduled/"+id, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doJSON(t, app, "DELETE", "/api/v1/scheduled/"+id, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Nothing was sent right away
+	manager.AssertNotCalled(t, "SendMessage")
+	manager.AssertNotCalled(t, "SendMUCMessage")
+}
+
+func TestParseSendTime(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	at, err := parseSendTime("", "90m", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(90*time.Minute), at)
+
+	at, err = parseSendTime("2026-03-01T12:00:00+02:00", "", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), at.UTC())
+
+	for _, tc := range []struct{ sendAt, delay string }{
+		{"2026-03-01T10:00:00Z", "1h"},
+		{"", "-5m"},
+		{"", "soon"},
+		{"tomorrow", ""},
+		{"2026-03-01T08:00:00Z", ""},
+		{"2027-03-02T09:00:00Z", ""},
+	} {
+		_, err := parseSendTime(tc.sendAt, tc.delay, now)
+		assert.Error(t, err, "send_at=%q delay=%q", tc.sendAt, tc.delay)
+	}
+}
+
+func TestScheduleEndpoints(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{
+		Path:          filepath.Join(dir, "scheduled.json"),
+		SchedulesPath: filepath.Join(dir, "schedules.json"),
+	}}
+
+	app, server := newTestServer(t, cfg, &MockXMPPManager{})
+	server.SetScheduler(scheduler.NewManager(cfg, zap.NewNop(), &MockXMPPManager{}))
+	app.Get("/api/v1/schedules", server.handleListSchedules)
+	app.Post("/api/v1/schedules", server.handleCreateSchedule)
+	app.Get("/api/v1/schedules/:name", server.handleGetSchedule)
+	app.Delete("/api/v1/schedules/:name", server.handleDeleteSchedule)
+
+	resp := doJSON(t, app, "POST", "/api/v1/schedules", models.ScheduleRequest{
+		Name:     "standup",
+		Cron:     "45 9 * * mon-fri",
+		Timezone: "Europe/Berlin",
+		Rooms:    []string{"team@conference.example.com"},
+		Body:     "Standup in 15 minutes",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = doJSON(t, app, "GET", "/api/v1/schedules/standup", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got struct {
+		Data models.Schedule `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, "api", got.Data.Source)
+	assert.Equal(t, 9, got.Data.NextRun.In(mustLoadLocation(t, "Europe/Berlin")).Hour())
+	assert.Nil(t, got.Data.LastRun)
+
+	resp = doJSON(t, app, "POST", "/api/v1/schedules", models.ScheduleRequest{
+		Name: "broken",
+		Cron: "every morning",
+		JIDs: []string{"bob@example.com"},
+		Body: "Hi",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = doJSON(t, app, "DELETE", "/api/v1/schedules/standup", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doJSON(t, app, "GET", "/api/v1/schedules/standup", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/scheduler"
+	"jabber-bot/internal/templates"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// handleListSchedules handles GET /api/v1/schedules
+func (s *Server) handleListSchedules(c *fiber.Ctx) error {
+	if s.scheduler == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "schedules are not available")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    s.scheduler.Schedules(),
+	})
+}
+
+// handleGetSchedule handles GET /api/v1/schedules/:name
+func (s *Server) handleGetSchedule(c *fiber.Ctx) error {
+	if s.scheduler == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "schedules are not available")
+	}
+
+	schedule, err := s.scheduler.GetSchedule(c.Params("name"))
+	if errors.Is(err, scheduler.ErrScheduleNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "schedule "+c.Params("name")+" not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    schedule,
+	})
+}
+
+// handleCreateSchedule handles POST /api/v1/schedules
+func (s *Server) handleCreateSchedule(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+
+	if s.scheduler == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "schedules are not available")
+	}
+
+	var req models.ScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := s.validateAccount(req.Account); err != nil {
+		return err
+	}
+	// Catch a misspelled template now rather than at the first run
+	if req.Template != "" && s.templates != nil {
+		if _, err := s.templates.Get(req.Template); errors.Is(err, templates.ErrNotFound) {
+			return fiber.NewError(fiber.StatusBadRequest, "template "+req.Template+" not found")
+		}
+	}
+
+	schedule, err := s.scheduler.AddSchedule(req)
+	if errors.Is(err, scheduler.ErrInvalidSchedule) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, scheduler.ErrConfigSchedule) {
+		return fiber.NewError(fiber.StatusConflict, "schedule "+req.Name+" is defined in the configuration file")
+	}
+	if err != nil {
+		logger.Error("Failed to save schedule",
+			zap.Error(err),
+			zap.String("schedule", req.Name),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return err
+	}
+
+	logger.Info("Schedule saved",
+		zap.String("schedule", schedule.Name),
+		zap.String("cron", schedule.Cron),
+		zap.Time("next_run", schedule.NextRun),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Success: true,
+		Message: "Schedule saved successfully",
+		Data:    schedule,
+	})
+}
+
+// handleDeleteSchedule handles DELETE /api/v1/schedules/:name
+func (s *Server) handleDeleteSchedule(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+
+	if s.scheduler == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "schedules are not available")
+	}
+
+	name := c.Params("name")
+	err := s.scheduler.DeleteSchedule(name)
+	if errors.Is(err, scheduler.ErrScheduleNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "schedule "+name+" not found")
+	}
+	if errors.Is(err, scheduler.ErrConfigSchedule) {
+		return fiber.NewError(fiber.StatusConflict, "schedule "+name+" is defined in the configuration file")
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Schedule deleted",
+		zap.String("schedule", name),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Schedule deleted successfully",
+	})
+}
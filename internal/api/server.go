@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"jabber-bot/internal/config"
 	"jabber-bot/internal/models"
+	"jabber-bot/internal/templates"
 	"jabber-bot/internal/xmpp"
 	"net"
 	"os"
@@ -24,11 +25,25 @@ import (
 
 // XMPPManagerInterface defines the interface for XMPP manager operations
 type XMPPManagerInterface interface {
-	SendMessage(to, body, messageType string) error
-	SendMUCMessage(room, body, subject string) error
-	SendChatState(to string, state xmpp.ChatState) error
-	SendFile(to, fileURL, fileName, fileType string) error
-	SendFileXEP0363(to, filePath, fileName, fileType string) error
+	SendMessage(account, from, to, body, messageType string) (models.SendResult, error)
+	SendMUCMessage(account, room, body, subject string, mentions []string) (models.SendResult, error)
+	Broadcast(account string, messages []models.BroadcastMessage) []models.BroadcastResult
+	InviteToRoom(account, room string, jids []string, reason string, mediated bool) error
+	GetAffiliations(account, room, affiliation string) ([]models.MUCItem, error)
+	SetAffiliation(account, room, jid, affiliation, reason string) error
+	GetRoles(account, room, role string) ([]models.MUCItem, error)
+	SetRole(account, room, nick, role, reason string) error
+	KickOccupant(account, room, nick, reason string) error
+	SetRoomSubject(account, room, subject string) error
+	CreateRoom(account, room, nick string, settings models.MUCRoomSettings) error
+	ConfigureRoom(account, room string, settings models.MUCRoomSettings) error
+	DestroyRoom(account, room, alternateVenue, reason string) error
+	GetRoomState(account, room string) (*models.MUCRoomState, error)
+	SendMUCPrivateMessage(account, room, nick, body string) error
+	GetAccountsStatus() []models.AccountStatus
+	SendChatState(account, to string, state xmpp.ChatState) error
+	SendFile(account, to, fileURL, fileName, fileType string) error
+	SendFileXEP0363(account, to, filePath, fileName, fileType string) error
 	IsConnected() bool
 	GetDefaultClient() *xmpp.Client
 	GetWebhookChannel() <-chan models.Message
@@ -40,6 +55,9 @@ type Server struct {
 	config     *config.Config
 	logger     *zap.Logger
 	manager    XMPPManagerInterface
+	templates  *templates.Store
+	scheduler  SchedulerInterface
+	webhooks   WebhookManagerInterface
 	actualPort int
 }
 
@@ -49,11 +67,17 @@ func NewServer(cfg *config.Config, logger *zap.Logger, manager XMPPManagerInterf
 		ErrorHandler: errorHandler,
 	})
 
+	store, err := templates.NewStore(cfg.Templates.Dir)
+	if err != nil {
+		logger.Error("Failed to load some message templates", zap.Error(err))
+	}
+
 	server := &Server{
-		app:     app,
-		config:  cfg,
-		logger:  logger,
-		manager: manager,
+		app:       app,
+		config:    cfg,
+		logger:    logger,
+		manager:   manager,
+		templates: store,
 	}
 
 	server.setupMiddleware()
@@ -62,6 +86,11 @@ func NewServer(cfg *config.Config, logger *zap.Logger, manager XMPPManagerInterf
 	return server
 }
 
+// Templates returns the message templates of the server
+func (s *Server) Templates() *templates.Store {
+	return s.templates
+}
+
 // setupMiddleware configures Fiber middleware
 func (s *Server) setupMiddleware() {
 	// Add Request ID
@@ -126,13 +155,54 @@ func (s *Server) setupRoutes() {
 	// Message endpoints (protected)
 	api.Post("/send", s.handleSendMessage)
 	api.Post("/send-muc", s.handleSendMUCMessage)
+	api.Post("/broadcast", s.handleBroadcast)
 	api.Post("/chat-state", s.handleSendChatState)
 	api.Post("/send-file", s.handleSendFile)
 
+	// Template endpoints (protected)
+	api.Get("/templates", s.handleListTemplates)
+	api.Get("/templates/:name", s.handleGetTemplate)
+	api.Put("/templates/:name", s.handlePutTemplate)
+	api.Delete("/templates/:name", s.handleDeleteTemplate)
+
+	// Scheduled message endpoints (protected)
+	api.Get("/scheduled", s.handleListScheduled)
+	api.Delete("/scheduled/:id", s.handleCancelScheduled)
+	api.Get("/schedules", s.handleListSchedules)
+	api.Post("/schedules", s.handleCreateSchedule)
+	api.Get("/schedules/:name", s.handleGetSchedule)
+	api.Delete("/schedules/:name", s.handleDeleteSchedule)
+
+	// MUC endpoints (protected)
+	api.Post("/muc/invite", s.handleMUCInvite)
+	api.Post("/muc/rooms", s.handleCreateRoom)
+	api.Put("/muc/:room/config", s.handleConfigureRoom)
+	api.Delete("/muc/:room", s.handleDestroyRoom)
+	api.Get("/muc/:room/occupants", s.handleGetOccupants)
+	api.Post("/muc/:room/private", s.handleSendMUCPrivateMessage)
+	api.Get("/muc/:room/affiliations", s.handleGetAffiliations)
+	api.Put("/muc/:room/affiliations", s.handleSetAffiliation)
+	api.Get("/muc/:room/roles", s.handleGetRoles)
+	api.Put("/muc/:room/roles", s.handleSetRole)
+	api.Post("/muc/:room/kick", s.handleKickOccupant)
+	api.Put("/muc/:room/subject", s.handleSetRoomSubject)
+
 	// Status endpoints (protected)
 	api.Get("/status", s.handleStatus)
 	api.Get("/webhook/status", s.handleWebhookStatus)
 
+	// Webhook subscription endpoints (protected)
+	api.Get("/webhooks/subscriptions", s.handleListSubscriptions)
+	api.Post("/webhooks/subscriptions", s.handleCreateSubscription)
+	api.Delete("/webhooks/subscriptions/:id", s.handleDeleteSubscription)
+
+	// Webhook dead letter endpoints (protected)
+	api.Get("/webhook/dead-letters", s.handleListDeadLetters)
+	api.Post("/webhook/dead-letters/replay", s.handleReplayDeadLetters)
+	api.Post("/webhook/dead-letters/:id/replay", s.handleReplayDeadLetters)
+	api.Delete("/webhook/dead-letters", s.handleDeleteDeadLetters)
+	api.Delete("/webhook/dead-letters/:id", s.handleDeleteDeadLetters)
+
 	// File serving endpoint (public) - for XEP-0363 HTTP File Upload
 	s.app.Get("/files/:filename", s.handleServeFile)
 
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/templates"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// handleListTemplates handles GET /api/v1/templates
+func (s *Server) handleListTemplates(c *fiber.Ctx) error {
+	if s.templates == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "templates are not configured")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    s.templates.List(),
+	})
+}
+
+// handleGetTemplate handles GET /api/v1/templates/:name
+func (s *Server) handleGetTemplate(c *fiber.Ctx) error {
+	if s.templates == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "templates are not configured")
+	}
+
+	tmpl, err := s.templates.Get(c.Params("name"))
+	if errors.Is(err, templates.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "template "+c.Params("name")+" not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    tmpl,
+	})
+}
+
+// handlePutTemplate handles PUT /api/v1/templates/:name
+func (s *Server) handlePutTemplate(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+
+	if s.templates == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "templates are not configured")
+	}
+
+	var req models.TemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	if strings.TrimSpace(req.Source) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "source field is required")
+	}
+
+	name := c.Params("name")
+	if err := s.templates.Put(name, req.Source); err != nil {
+		if _, ok := errors.AsType[*templates.Error](err); ok {
+			return templateErrorResponse(c, err)
+		}
+		if errors.Is(err, templates.ErrInvalidName) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		logger.Error("Failed to save template",
+			zap.Error(err),
+			zap.String("template", name),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return err
+	}
+
+	logger.Info("Template saved",
+		zap.String("template", name),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Template saved successfully",
+		Data:    templates.Template{Name: name, Source: req.Source},
+	})
+}
+
+// handleDeleteTemplate handles DELETE /api/v1/templates/:name
+func (s *Server) handleDeleteTemplate(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+
+	if s.templates == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "templates are not configured")
+	}
+
+	name := c.Params("name")
+	err := s.templates.Delete(name)
+	if errors.Is(err, templates.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "template "+name+" not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Template deleted",
+		zap.String("template", name),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Template deleted successfully",
+	})
+}
+
+// renderBody renders the template of a send request. The result replaces the body, so a
+// request sets either body or template.
+func (s *Server) renderBody(name string, data map[string]interface{}, body string) (string, error) {
+	if body != "" {
+		return "", fiber.NewError(fiber.StatusBadRequest, "body and template fields are mutually exclusive")
+	}
+	if s.templates == nil {
+		return "", fiber.NewError(fiber.StatusBadRequest, "templates are not configured")
+	}
+
+	rendered, err := s.templates.Render(name, data)
+	if errors.Is(err, templates.ErrNotFound) {
+		return "", fiber.NewError(fiber.StatusBadRequest, "template "+name+" not found")
+	}
+	return rendered, err
+}
+
+// templateErrorResponse answers a template that failed to parse or render with its position
+func templateErrorResponse(c *fiber.Ctx, err error) error {
+	tmplErr, ok := errors.AsType[*templates.Error](err)
+	if !ok {
+		return err
+	}
+
+	return c.Status(fiber.StatusBadRequest).JSON(models.TemplateErrorResponse{
+		ErrorResponse: models.ErrorResponse{
+			Success: false,
+			Error:   tmplErr.Error(),
+			Code:    fiber.StatusBadRequest,
+		},
+		Template: tmplErr.Template,
+		Line:     tmplErr.Line,
+		Column:   tmplErr.Column,
+	})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/templates"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateEndpoints(t *testing.T) {
+	store, err := templates.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	manager := &MockXMPPManager{}
+	manager.On("SendMessage", "", "", "oncall@example.com", "[CRITICAL] db1: disk full", "chat").Return(models.SendResult{}, nil)
+
+	app, server := newTestServer(t, &config.Config{}, manager)
+	server.templates = store
+	app.Get("/api/v1/templates", server.handleListTemplates)
+	app.Get("/api/v1/templates/:name", server.handleGetTemplate)
+	app.Put("/api/v1/templates/:name", server.handlePutTemplate)
+	app.Delete("/api/v1/templates/:name", server.handleDeleteTemplate)
+	app.Post("/api/v1/send", server.handleSendMessage)
+
+	resp := doJSON(t, app, "PUT", "/api/v1/templates/alert", models.TemplateRequest{Source: "[{{.severity}}] {{.host}}: {{.summary}}"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doJSON(t, app, "GET", "/api/v1/templates/alert", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doJSON(t, app, "POST", "/api/v1/send", models.SendMessageRequest{
+		To:       "oncall@example.com",
+		Template: "alert",
+		Data:     map[string]interface{}{"severity": "CRITICAL", "host": "db1", "summary": "disk full"},
+		Type:     "chat",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// A missing variable is reported with its position
+	resp = doJSON(t, app, "POST", "/api/v1/send", models.SendMessageRequest{
+		To:       "oncall@example.com",
+		Template: "alert",
+		Data:     map[string]interface{}{"severity": "CRITICAL"},
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	var tmplErr models.TemplateErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tmplErr))
+	assert.Equal(t, "alert", tmplErr.Template)
+	assert.Equal(t, 1, tmplErr.Line)
+	assert.Equal(t, 18, tmplErr.Column)
+
+	resp = doJSON(t, app, "POST", "/api/v1/send", models.SendMessageRequest{
+		To:       "oncall@example.com",
+		Body:     "Disk full",
+		Template: "alert",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = doJSON(t, app, "PUT", "/api/v1/templates/broken", models.TemplateRequest{Source: "ok\n{{.x"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tmplErr))
+	assert.Equal(t, 2, tmplErr.Line)
+
+	resp = doJSON(t, app, "DELETE", "/api/v1/templates/alert", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doJSON(t, app, "GET", "/api/v1/templates/alert", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = doJSON(t, app, "POST", "/api/v1/send", models.SendMessageRequest{To: "oncall@example.com", Template: "alert"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	manager.AssertExpectations(t)
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/webhook"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// WebhookManagerInterface defines the interface for webhook status, subscription and dead letter
+// operations
+type WebhookManagerInterface interface {
+	GetStatus() map[string]interface{}
+	Subscribe(sub models.WebhookSubscription) (models.WebhookSubscription, error)
+	Subscriptions() []models.WebhookSubscription
+	Unsubscribe(id string) error
+	DeadLetters() []models.DeadLetter
+	ReplayDeadLetters(ids []string) (int, error)
+	DeleteDeadLetters(ids []string) (int, error)
+}
+
+// SetWebhookManager enables the webhook status, the /webhooks/subscriptions and the
+// /webhook/dead-letters endpoints
+func (s *Server) SetWebhookManager(webhooks WebhookManagerInterface) {
+	s.webhooks = webhooks
+}
+
+// handleListSubscriptions handles GET /api/v1/webhooks/subscriptions
+func (s *Server) handleListSubscriptions(c *fiber.Ctx) error {
+	if s.webhooks == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "webhook subscriptions are not available")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    s.webhooks.Subscriptions(),
+	})
+}
+
+// handleCreateSubscription handles POST /api/v1/webhooks/subscriptions
+func (s *Server) handleCreateSubscription(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+
+	if s.webhooks == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "webhook subscriptions are not available")
+	}
+
+	var req models.WebhookSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.URL == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "url field is required")
+	}
+
+	expiresAt, err := parseExpiry(req.ExpiresAt, req.TTL, time.Now())
+	if err != nil {
+		return err
+	}
+
+	subscription, err := s.webhooks.Subscribe(models.WebhookSubscription{
+		URL:       req.URL,
+		Filters:   req.Filters,
+		Secret:    req.Secret,
+		ExpiresAt: expiresAt,
+	})
+	if errors.Is(err, webhook.ErrInvalidSubscription) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		logger.Error("Failed to save webhook subscription",
+			zap.Error(err),
+			zap.String("url", req.URL),
+			zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+		)
+		return err
+	}
+
+	logger.Info("Webhook subscription saved",
+		zap.String("id", subscription.ID),
+		zap.String("url", subscription.URL),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Success: true,
+		Message: "Webhook subscription saved successfully",
+		Data:    subscription,
+	})
+}
+
+// parseExpiry returns the expiry of a subscription request with expires_at or ttl, or nil
+// when it does not expire
+func parseExpiry(expiresAt, ttl string, now time.Time) (*time.Time, error) {
+	if expiresAt != "" && ttl != "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "expires_at and ttl fields are mutually exclusive")
+	}
+
+	var at time.Time
+	switch {
+	case ttl != "":
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "ttl must be a positive duration, e.g. 24h")
+		}
+		at = now.Add(d).UTC()
+	case expiresAt != "":
+		var err error
+		at, err = time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "expires_at must be an RFC 3339 time, e.g. 2026-01-02T15:04:05Z")
+		}
+		if !at.After(now) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "expires_at must be in the future")
+		}
+	default:
+		return nil, nil
+	}
+	return &at, nil
+}
+
+// handleDeleteSubscription handles DELETE /api/v1/webhooks/subscriptions/:id
+func (s *Server) handleDeleteSubscription(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+
+	if s.webhooks == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "webhook subscriptions are not available")
+	}
+
+	id := c.Params("id")
+	err := s.webhooks.Unsubscribe(id)
+	if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "webhook subscription "+id+" not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Webhook subscription deleted",
+		zap.String("id", id),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Webhook subscription deleted successfully",
+	})
+}
+
+// handleListDeadLetters handles GET /api/v1/webhook/dead-letters
+func (s *Server) handleListDeadLetters(c *fiber.Ctx) error {
+	if s.webhooks == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "webhook dead letters are not available")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    s.webhooks.DeadLetters(),
+	})
+}
+
+// handleReplayDeadLetters handles POST /api/v1/webhook/dead-letters/replay and
+// POST /api/v1/webhook/dead-letters/:id/replay
+func (s *Server) handleReplayDeadLetters(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+
+	if s.webhooks == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "webhook dead letters are not available")
+	}
+
+	ids, err := deadLetterIDs(c)
+	if err != nil {
+		return err
+	}
+
+	replayed, err := s.webhooks.ReplayDeadLetters(ids)
+	if errors.Is(err, webhook.ErrDeadLetterNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if errors.Is(err, webhook.ErrQueueFull) {
+		return fiber.NewError(fiber.StatusServiceUnavailable, fmt.Sprintf("webhook queue is full, %d dead letters replayed", replayed))
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Webhook dead letters replayed",
+		zap.Int("count", replayed),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Dead letters queued for delivery",
+		Data:    fiber.Map{"replayed": replayed},
+	})
+}
+
+// handleDeleteDeadLetters handles DELETE /api/v1/webhook/dead-letters and
+// DELETE /api/v1/webhook/dead-letters/:id
+func (s *Server) handleDeleteDeadLetters(c *fiber.Ctx) error {
+	logger := c.Locals("logger").(*zap.Logger)
+
+	if s.webhooks == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "webhook dead letters are not available")
+	}
+
+	ids, err := deadLetterIDs(c)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.webhooks.DeleteDeadLetters(ids)
+	if errors.Is(err, webhook.ErrDeadLetterNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Webhook dead letters deleted",
+		zap.Int("count", deleted),
+		zap.String("request_id", c.GetRespHeader("X-Request-ID")),
+	)
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Dead letters deleted successfully",
+		Data:    fiber.Map{"deleted": deleted},
+	})
+}
+
+// deadLetterIDs returns the dead letters selected by the :id parameter or the ids of the
+// request body; none selects all of them
+func deadLetterIDs(c *fiber.Ctx) ([]string, error) {
+	if id := c.Params("id"); id != "" {
+		return []string{id}, nil
+	}
+	if len(c.Body()) == 0 {
+		return nil, nil
+	}
+
+	var req models.DeadLetterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	return req.IDs, nil
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWebhookSubscriptionEndpoints(t *testing.T) {
+	cfg := &config.Config{Webhook: config.WebhookConfig{
+		RetryAttempts:     1,
+		SubscriptionsPath: filepath.Join(t.TempDir(), "subscriptions.json"),
+	}}
+
+	app, server := newTestServer(t, cfg, &MockXMPPManager{})
+	// Not started, so nothing is delivered during the test
+	server.SetWebhookManager(webhook.NewManager(cfg, zap.NewNop(), nil))
+	app.Get("/api/v1/webhooks/subscriptions", server.handleListSubscriptions)
+	app.Post("/api/v1/webhooks/subscriptions", server.handleCreateSubscription)
+	app.Delete("/api/v1/webhooks/subscriptions/:id", server.handleDeleteSubscription)
+	app.Get("/api/v1/webhook/status", server.handleWebhookStatus)
+
+	resp := doJSON(t, app, "POST", "/api/v1/webhooks/subscriptions", models.WebhookSubscriptionRequest{
+		URL:     "https://n8n.example.com/webhook/1234/webhook",
+		Filters: models.WebhookFilter{Types: []string{"chat"}},
+		TTL:     "24h",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		Data models.WebhookSubscription `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.NotEmpty(t, created.Data.Secret)
+	require.NotNil(t, created.Data.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), *created.Data.ExpiresAt, time.Minute)
+
+	for _, req := range []models.WebhookSubscriptionRequest{
+		{},
+		{URL: "https://example.com/hook", TTL: "1h", ExpiresAt: "2030-01-01T00:00:00Z"},
+		{URL: "https://example.com/hook", ExpiresAt: "2001-01-01T00:00:00Z"},
+		{URL: "https://example.com/hook", Filters: models.WebhookFilter{Types: []string{"error"}}},
+	} {
+		resp = doJSON(t, app, "POST", "/api/v1/webhooks/subscriptions", req)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+
+	resp = doJSON(t, app, "GET", "/api/v1/webhooks/subscriptions", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list struct {
+		Data []models.WebhookSubscription `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, created.Data.ID, list.Data[0].ID)
+	assert.Empty(t, list.Data[0].Secret)
+
+	resp = doJSON(t, app, "GET", "/api/v1/webhook/status", nil)
+	var status map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	assert.Equal(t, float64(1), status["subscriptions"])
+
+	resp = doJSON(t, app, "DELETE", "/api/v1/webhooks/subscriptions/"+created.Data.ID, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doJSON(t, app, "DELETE", "/api/v1/webhooks/subscriptions/"+created.Data.ID, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestWebhookDeadLetterEndpoints(t *testing.T) {
+	cfg := &config.Config{Webhook: config.WebhookConfig{
+		URL:           "http://127.0.0.1:1/unreachable",
+		Timeout:       time.Second,
+		RetryAttempts: 1,
+	}}
+
+	manager := webhook.NewManager(cfg, zap.NewNop(), nil)
+	service := manager.GetService()
+	require.NoError(t, service.Start())
+	defer service.Stop()
+
+	app, server := newTestServer(t, cfg, &MockXMPPManager{})
+	server.SetWebhookManager(manager)
+	app.Get("/api/v1/webhook/dead-letters", server.handleListDeadLetters)
+	app.Post("/api/v1/webhook/dead-letters/replay", server.handleReplayDeadLetters)
+	app.Post("/api/v1/webhook/dead-letters/:id/replay", server.handleReplayDeadLetters)
+	app.Delete("/api/v1/webhook/dead-letters", server.handleDeleteDeadLetters)
+	app.Delete("/api/v1/webhook/dead-letters/:id", server.handleDeleteDeadLetters)
+
+	require.NoError(t, service.SendMessage(models.Message{From: "alice@example.com/phone", Body: "hello"}))
+	require.Eventually(t, func() bool { return len(service.DeadLetters()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	resp := doJSON(t, app, "GET", "/api/v1/webhook/dead-letters", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list struct {
+		Data []models.DeadLetter `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, "default", list.Data[0].Target)
+	assert.Equal(t, "hello", list.Data[0].Message.Body)
+
+	resp = doJSON(t, app, "POST", "/api/v1/webhook/dead-letters/dl-unknown/replay", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = doJSON(t, app, "POST", "/api/v1/webhook/dead-letters/replay", models.DeadLetterRequest{IDs: []string{list.Data[0].ID}})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The endpoint is still down, so the replay fails again
+	require.Eventually(t, func() bool {
+		letters := service.DeadLetters()
+		return len(letters) == 1 && letters[0].ID != list.Data[0].ID
+	}, 5*time.Second, 10*time.Millisecond)
+
+	resp = doJSON(t, app, "DELETE", "/api/v1/webhook/dead-letters/"+list.Data[0].ID, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = doJSON(t, app, "DELETE", "/api/v1/webhook/dead-letters", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, service.DeadLetters())
+}
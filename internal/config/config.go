@@ -2,9 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"jabber-bot/internal/cron"
+
 	"github.com/spf13/viper"
 )
 
@@ -15,14 +20,108 @@ type Config struct {
 	Logging      LoggingConfig      `mapstructure:"logging"`
 	Reconnection ReconnectionConfig `mapstructure:"reconnection"`
 	FileTransfer FileTransferConfig `mapstructure:"file_transfer"`
+	MUC          MUCConfig          `mapstructure:"muc"`
+	Outbox       OutboxConfig       `mapstructure:"outbox"`
+	RateLimit    RateLimitConfig    `mapstructure:"rate_limit"`
+	Templates    TemplatesConfig    `mapstructure:"templates"`
+	Scheduler    SchedulerConfig    `mapstructure:"scheduler"`
+	Accounts     []AccountConfig    `mapstructure:"accounts"`
 }
 
 type XMPPConfig struct {
-	JID       string `mapstructure:"jid"`
-	Password  string `mapstructure:"password"`
-	Server    string `mapstructure:"server"`
-	Resource  string `mapstructure:"resource"`
-	Reconnect bool   `mapstructure:"reconnect"`
+	Mode      string        `mapstructure:"mode"` // client (default) or component (XEP-0114)
+	JID       string        `mapstructure:"jid"`  // in component mode the default sender (defaults to domain)
+	Password  string        `mapstructure:"password"`
+	Server    string        `mapstructure:"server"`    // host:port or ws(s):// URL; empty resolves it from DNS
+	Transport string        `mapstructure:"transport"` // tcp (default) or websocket (RFC 7395)
+	Proxy     string        `mapstructure:"proxy"`     // outbound proxy, http://host:port or socks5://host:port
+	Resource  string        `mapstructure:"resource"`
+	Reconnect bool          `mapstructure:"reconnect"`
+	Domain    string        `mapstructure:"domain"`  // component domain, e.g. bot.example.org
+	Secret    string        `mapstructure:"secret"`  // component shared secret
+	Carbons   bool          `mapstructure:"carbons"` // enable message carbons (XEP-0280)
+	TLS       XMPPTLSConfig `mapstructure:"tls"`
+	SASL      SASLConfig    `mapstructure:"sasl"`
+
+	MaxBodySize  int    `mapstructure:"max_body_size"` // longest message body in bytes; 0 sends bodies of any size
+	LongMessages string `mapstructure:"long_messages"` // split (default) or upload (XEP-0363) bodies over max_body_size
+}
+
+type XMPPTLSConfig struct {
+	Mode               string `mapstructure:"mode"`                 // starttls (default) or direct
+	CAFile             string `mapstructure:"ca_file"`              // PEM bundle of trusted CAs (default: system roots)
+	ServerName         string `mapstructure:"server_name"`          // name verified in the server certificate (default: JID domain)
+	MinVersion         string `mapstructure:"min_version"`          // minimum TLS version: 1.0, 1.1, 1.2 (default) or 1.3
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"` // do not verify the server certificate (labs only)
+	CertFile           string `mapstructure:"cert_file"`            // client certificate presented during the handshake
+	KeyFile            string `mapstructure:"key_file"`             // private key of the client certificate
+}
+
+type SASLConfig struct {
+	Mechanism string `mapstructure:"mechanism"` // PLAIN (default), X-OAUTH2 (password is the token), SCRAM-SHA-1, SCRAM-SHA-256 or EXTERNAL (client certificate)
+}
+
+// TLS modes
+const (
+	TLSModeStartTLS = "starttls"
+	TLSModeDirect   = "direct"
+)
+
+// SASL mechanisms
+const (
+	SASLPlain       = "PLAIN"
+	SASLOAuth2      = "X-OAUTH2"
+	SASLScramSHA1   = "SCRAM-SHA-1"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLExternal    = "EXTERNAL"
+)
+
+// XMPP connection modes
+const (
+	ModeClient    = "client"
+	ModeComponent = "component"
+)
+
+// Handling of bodies over xmpp.max_body_size
+const (
+	LongMessagesSplit  = "split"
+	LongMessagesUpload = "upload"
+)
+
+// Handling of schedule runs missed while the bot was stopped
+const (
+	MissedRunsSkip = "skip" // wait for the next run
+	MissedRunsOnce = "once" // run once at start, however many runs were missed
+)
+
+// minBodySize leaves room for the part markers and code fences of a split body
+const minBodySize = 64
+
+// XMPP transports
+const (
+	TransportTCP       = "tcp"
+	TransportWebSocket = "websocket"
+)
+
+// DefaultAccount is the name of the account configured by the top-level xmpp section
+const DefaultAccount = "default"
+
+// AccountConfig is an additional bot identity connected alongside the default account
+type AccountConfig struct {
+	Name     string               `mapstructure:"name"` // selects the account in API requests
+	JID      string               `mapstructure:"jid"`
+	Password string               `mapstructure:"password"`
+	Server   string               `mapstructure:"server"`   // defaults to xmpp.server
+	Resource string               `mapstructure:"resource"` // defaults to xmpp.resource
+	Nick     string               `mapstructure:"nick"`     // room nickname (defaults to JID localpart)
+	Rooms    []MUCRoomConfig      `mapstructure:"rooms"`    // rooms joined on connect
+	Webhook  AccountWebhookConfig `mapstructure:"webhook"`
+}
+
+// AccountWebhookConfig overrides the webhook target for messages received by an account
+type AccountWebhookConfig struct {
+	URL    string `mapstructure:"url"`     // defaults to webhook.url
+	APIKey string `mapstructure:"api_key"` // defaults to webhook.api_key
 }
 
 type APIConfig struct {
@@ -38,6 +137,63 @@ type WebhookConfig struct {
 	RetryAttempts  int           `mapstructure:"retry_attempts"`
 	TestModeSuffix string        `mapstructure:"test_mode_suffix"`
 	APIKey         string        `mapstructure:"api_key"`
+	ReplyActions   bool          `mapstructure:"reply_actions"` // execute the actions returned in webhook responses
+	// Targets receive the messages matching their rules, in addition to url
+	Targets []WebhookTargetConfig `mapstructure:"targets"`
+	// SubscriptionsPath is the file the subscriptions created through the API are persisted to
+	SubscriptionsPath string `mapstructure:"subscriptions_path"`
+	// SigningSecrets sign deliveries with HMAC-SHA256: the current secret, followed by the
+	// previous one while receivers switch over
+	SigningSecrets []string `mapstructure:"signing_secrets"`
+	// QueuePath is the journal messages waiting for delivery are persisted to (default ./data/webhook_queue.json)
+	QueuePath string `mapstructure:"queue_path"`
+	QueueSize int    `mapstructure:"queue_size"` // maximum number of deliveries waiting, one per message and target
+	// DeadLetterPath is the file deliveries that failed after all retries are kept in
+	// (default ./data/webhook_dead_letters.json)
+	DeadLetterPath string `mapstructure:"dead_letter_path"`
+	DeadLetterMax  int    `mapstructure:"dead_letter_max"` // dead letters kept; the oldest are dropped
+	// Workers deliver messages in parallel; the messages of a conversation to a target go to the
+	// same worker and are delivered in order
+	Workers int `mapstructure:"workers"`
+}
+
+// WebhookTargetConfig is an additional webhook endpoint
+type WebhookTargetConfig struct {
+	Name           string             `mapstructure:"name"`
+	URL            string             `mapstructure:"url"`
+	APIKey         string             `mapstructure:"api_key"`
+	Timeout        time.Duration      `mapstructure:"timeout"`         // defaults to webhook.timeout
+	RetryAttempts  int                `mapstructure:"retry_attempts"`  // defaults to webhook.retry_attempts
+	Headers        map[string]string  `mapstructure:"headers"`         // sent with every request
+	SigningSecrets []string           `mapstructure:"signing_secrets"` // defaults to webhook.signing_secrets
+	Match          WebhookMatchConfig `mapstructure:"match"`
+}
+
+// WebhookMatchConfig selects the messages delivered to a webhook target. Every rule that is set
+// must match; a rule with several values matches any of them. No rules match every message.
+type WebhookMatchConfig struct {
+	From   []string `mapstructure:"from"`   // sender bare JIDs or domains
+	Rooms  []string `mapstructure:"rooms"`  // room JIDs, for groupchat, MUC private messages and room events
+	Types  []string `mapstructure:"types"`  // message types: chat, groupchat, normal, headline
+	Events []string `mapstructure:"events"` // message, invite, occupant_joined, occupant_left
+	Body   string   `mapstructure:"body"`   // regular expression the body must match
+}
+
+// Validate checks the rules of a webhook target
+func (m WebhookMatchConfig) Validate() error {
+	for _, messageType := range m.Types {
+		switch messageType {
+		case "chat", "groupchat", "normal", "headline":
+		default:
+			return fmt.Errorf("invalid message type %q: must be one of chat, groupchat, normal, headline", messageType)
+		}
+	}
+	if m.Body != "" {
+		if _, err := regexp.Compile(m.Body); err != nil {
+			return fmt.Errorf("invalid body pattern: %w", err)
+		}
+	}
+	return nil
 }
 
 type LoggingConfig struct {
@@ -48,8 +204,99 @@ type LoggingConfig struct {
 
 type ReconnectionConfig struct {
 	Enabled     bool          `mapstructure:"enabled"`
-	MaxAttempts int           `mapstructure:"max_attempts"`
-	Backoff     time.Duration `mapstructure:"backoff"`
+	MaxAttempts int           `mapstructure:"max_attempts"` // attempts before pausing for MaxBackoff; -1 never pauses
+	Backoff     time.Duration `mapstructure:"backoff"`      // delay before the first attempt, doubled on every failure
+	MaxBackoff  time.Duration `mapstructure:"max_backoff"`  // upper bound of the delay
+}
+
+// OutboxConfig queues outbound messages while the XMPP connection is down
+type OutboxConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Path    string        `mapstructure:"path"`     // file the queue is persisted to; empty keeps it in memory
+	TTL     time.Duration `mapstructure:"ttl"`      // queued messages not sent within this time are dropped
+	MaxSize int           `mapstructure:"max_size"` // maximum number of queued messages
+}
+
+// RateLimitConfig paces outbound messages so the server's traffic shaper does not disconnect the bot
+type RateLimitConfig struct {
+	Enabled      bool      `mapstructure:"enabled"`
+	Global       RateLimit `mapstructure:"global"`        // all outbound messages
+	PerRecipient RateLimit `mapstructure:"per_recipient"` // messages to one JID
+	PerRoom      RateLimit `mapstructure:"per_room"`      // messages to one MUC room
+	MaxQueue     int       `mapstructure:"max_queue"`     // sends waiting for their turn before new ones are rejected
+}
+
+// RateLimit is a token bucket refilled with Rate messages per second and holding up to Burst messages
+type RateLimit struct {
+	Rate  float64 `mapstructure:"rate"` // 0 disables the limit
+	Burst int     `mapstructure:"burst"`
+}
+
+// TemplatesConfig locates the message templates, one <name>.tmpl file per template
+type TemplatesConfig struct {
+	Dir string `mapstructure:"dir"`
+}
+
+// SchedulerConfig stores messages scheduled for later delivery and recurring schedules
+type SchedulerConfig struct {
+	Path          string           `mapstructure:"path"`           // file the scheduled messages are persisted to
+	MaxPending    int              `mapstructure:"max_pending"`    // maximum number of scheduled messages
+	SchedulesPath string           `mapstructure:"schedules_path"` // file the schedules created through the API and run state are persisted to
+	Schedules     []ScheduleConfig `mapstructure:"schedules"`
+}
+
+// ScheduleConfig is a message sent on a cron schedule
+type ScheduleConfig struct {
+	Name       string                 `mapstructure:"name"`
+	Cron       string                 `mapstructure:"cron"`     // five-field cron expression or @daily, @hourly, ...
+	Timezone   string                 `mapstructure:"timezone"` // IANA time zone of the expression, UTC by default
+	JIDs       []string               `mapstructure:"jids"`
+	Rooms      []string               `mapstructure:"rooms"`
+	Body       string                 `mapstructure:"body"`
+	Template   string                 `mapstructure:"template"`
+	Data       map[string]interface{} `mapstructure:"data"`
+	URL        string                 `mapstructure:"url"`         // content fetched at every run
+	Account    string                 `mapstructure:"account"`     // sending account, empty = default account
+	MissedRuns string                 `mapstructure:"missed_runs"` // skip (default) or once
+}
+
+// Schedule names are used in URLs
+var validScheduleName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Validate checks a schedule, except its account which depends on the rest of the configuration
+func (s ScheduleConfig) Validate() error {
+	if !validScheduleName.MatchString(s.Name) {
+		return fmt.Errorf("invalid schedule name %q: use letters, digits, - and _", s.Name)
+	}
+	if _, err := cron.Parse(s.Cron); err != nil {
+		return fmt.Errorf("schedule %s: %w", s.Name, err)
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("schedule %s: invalid timezone %q", s.Name, s.Timezone)
+	}
+	if len(s.JIDs)+len(s.Rooms) == 0 {
+		return fmt.Errorf("schedule %s: jids or rooms are required", s.Name)
+	}
+	for _, jid := range append(append([]string(nil), s.JIDs...), s.Rooms...) {
+		if !strings.Contains(jid, "@") {
+			return fmt.Errorf("schedule %s: invalid JID %q", s.Name, jid)
+		}
+	}
+	if s.Body != "" && (s.Template != "" || s.URL != "") {
+		return fmt.Errorf("schedule %s: body cannot be combined with template or url", s.Name)
+	}
+	if s.Body == "" && s.Template == "" && s.URL == "" {
+		return fmt.Errorf("schedule %s: body, template or url is required", s.Name)
+	}
+	if s.URL != "" && !strings.HasPrefix(s.URL, "http://") && !strings.HasPrefix(s.URL, "https://") {
+		return fmt.Errorf("schedule %s: url must be an http or https URL", s.Name)
+	}
+	switch s.MissedRuns {
+	case "", MissedRunsSkip, MissedRunsOnce:
+	default:
+		return fmt.Errorf("schedule %s: invalid missed_runs %q: must be one of skip, once", s.Name, s.MissedRuns)
+	}
+	return nil
 }
 
 type FileTransferConfig struct {
@@ -62,6 +309,36 @@ type FileTransferConfig struct {
 	Timeout     time.Duration `mapstructure:"upload_timeout"` // HTTP File Upload (XEP-0363) timeout
 }
 
+type MUCConfig struct {
+	Nick             string           `mapstructure:"nick"`               // default nickname in rooms (defaults to JID localpart)
+	Trigger          string           `mapstructure:"trigger"`            // default groupchat trigger: all, mention or command_prefix
+	CommandPrefix    string           `mapstructure:"command_prefix"`     // prefix for the command_prefix trigger (default "!")
+	Rooms            []MUCRoomConfig  `mapstructure:"rooms"`              // rooms joined on connect
+	Invitations      InvitationConfig `mapstructure:"invitations"`        // XEP-0249 / XEP-0045 invitation handling
+	SelfPingInterval time.Duration    `mapstructure:"self_ping_interval"` // XEP-0410 room self-ping interval (default 5m)
+}
+
+type MUCRoomConfig struct {
+	JID           string `mapstructure:"jid"`
+	Nick          string `mapstructure:"nick"` // overrides MUCConfig.Nick for this room
+	Password      string `mapstructure:"password"`
+	Trigger       string `mapstructure:"trigger"`        // overrides MUCConfig.Trigger for this room
+	CommandPrefix string `mapstructure:"command_prefix"` // overrides MUCConfig.CommandPrefix for this room
+}
+
+// Groupchat triggers deciding which room messages are forwarded to the webhook
+const (
+	TriggerAll           = "all"
+	TriggerMention       = "mention"
+	TriggerCommandPrefix = "command_prefix"
+)
+
+type InvitationConfig struct {
+	AutoAccept      bool     `mapstructure:"auto_accept"`      // join rooms the bot is invited to
+	AllowedDomains  []string `mapstructure:"allowed_domains"`  // inviter domains allowed (empty = any)
+	AllowedInviters []string `mapstructure:"allowed_inviters"` // inviter bare JIDs allowed (empty = any)
+}
+
 func Load(configPath string) (*Config, error) {
 	viper.SetConfigFile(configPath)
 	viper.SetConfigType("yaml")
@@ -104,6 +381,33 @@ func Load(configPath string) (*Config, error) {
 	if config.Webhook.TestModeSuffix == "" {
 		config.Webhook.TestModeSuffix = "-test"
 	}
+	if config.Webhook.SubscriptionsPath == "" {
+		config.Webhook.SubscriptionsPath = "./data/webhook_subscriptions.json"
+	}
+	if config.Webhook.QueuePath == "" {
+		config.Webhook.QueuePath = "./data/webhook_queue.json"
+	}
+	if config.Webhook.QueueSize == 0 {
+		config.Webhook.QueueSize = 10000
+	}
+	if config.Webhook.DeadLetterPath == "" {
+		config.Webhook.DeadLetterPath = "./data/webhook_dead_letters.json"
+	}
+	if config.Webhook.DeadLetterMax == 0 {
+		config.Webhook.DeadLetterMax = 1000
+	}
+	if config.Webhook.Workers == 0 {
+		config.Webhook.Workers = 4
+	}
+	for i := range config.Webhook.Targets {
+		target := &config.Webhook.Targets[i]
+		if target.Timeout == 0 {
+			target.Timeout = config.Webhook.Timeout
+		}
+		if target.RetryAttempts == 0 {
+			target.RetryAttempts = config.Webhook.RetryAttempts
+		}
+	}
 	if config.Logging.Level == "" {
 		config.Logging.Level = "info"
 	}
@@ -116,9 +420,38 @@ func Load(configPath string) (*Config, error) {
 	if config.Reconnection.Backoff == 0 {
 		config.Reconnection.Backoff = 5 * time.Second
 	}
+	if config.Reconnection.MaxBackoff == 0 {
+		config.Reconnection.MaxBackoff = max(5*time.Minute, config.Reconnection.Backoff)
+	}
 	if config.FileTransfer.MaxSize == 0 {
 		config.FileTransfer.MaxSize = 10 * 1024 * 1024 // 10 MB default
 	}
+	if config.Outbox.TTL == 0 {
+		config.Outbox.TTL = 10 * time.Minute
+	}
+	if config.Outbox.MaxSize == 0 {
+		config.Outbox.MaxSize = 1000
+	}
+	if config.RateLimit.MaxQueue == 0 {
+		config.RateLimit.MaxQueue = 100
+	}
+	for _, limit := range []*RateLimit{&config.RateLimit.Global, &config.RateLimit.PerRecipient, &config.RateLimit.PerRoom} {
+		if limit.Rate > 0 && limit.Burst == 0 {
+			limit.Burst = 1
+		}
+	}
+	if config.Templates.Dir == "" {
+		config.Templates.Dir = "./templates"
+	}
+	if config.Scheduler.Path == "" {
+		config.Scheduler.Path = "./data/scheduled.json"
+	}
+	if config.Scheduler.MaxPending == 0 {
+		config.Scheduler.MaxPending = 10000
+	}
+	if config.Scheduler.SchedulesPath == "" {
+		config.Scheduler.SchedulesPath = "./data/schedules.json"
+	}
 	if config.FileTransfer.StoragePath == "" {
 		config.FileTransfer.StoragePath = "./uploads"
 	}
@@ -133,6 +466,383 @@ func Load(configPath string) (*Config, error) {
 	if config.FileTransfer.Timeout == 0 {
 		config.FileTransfer.Timeout = 60 * time.Second
 	}
+	if config.XMPP.Mode == "" {
+		config.XMPP.Mode = ModeClient
+	}
+	if config.XMPP.Transport == "" {
+		config.XMPP.Transport = TransportTCP
+		if strings.HasPrefix(config.XMPP.Server, "ws://") || strings.HasPrefix(config.XMPP.Server, "wss://") {
+			config.XMPP.Transport = TransportWebSocket
+		}
+	}
+	if config.XMPP.LongMessages == "" {
+		config.XMPP.LongMessages = LongMessagesSplit
+	}
+	if config.XMPP.TLS.Mode == "" {
+		config.XMPP.TLS.Mode = TLSModeStartTLS
+	}
+	if config.XMPP.TLS.MinVersion == "" {
+		config.XMPP.TLS.MinVersion = "1.2"
+	}
+	if config.XMPP.SASL.Mechanism == "" {
+		config.XMPP.SASL.Mechanism = SASLPlain
+	}
+	config.XMPP.SASL.Mechanism = strings.ToUpper(config.XMPP.SASL.Mechanism)
+	if config.XMPP.Mode == ModeComponent && config.XMPP.JID == "" {
+		config.XMPP.JID = config.XMPP.Domain
+	}
+	if config.MUC.Nick == "" {
+		config.MUC.Nick = strings.Split(config.XMPP.JID, "@")[0]
+	}
+	if config.MUC.Trigger == "" {
+		config.MUC.Trigger = TriggerAll
+	}
+	if config.MUC.CommandPrefix == "" {
+		config.MUC.CommandPrefix = "!"
+	}
+	if config.MUC.SelfPingInterval == 0 {
+		config.MUC.SelfPingInterval = 5 * time.Minute
+	}
+	if err := validateMode(config.XMPP); err != nil {
+		return nil, err
+	}
+	if err := validateReconnection(config.Reconnection); err != nil {
+		return nil, err
+	}
+	if config.Outbox.TTL < 0 || config.Outbox.MaxSize < 0 {
+		return nil, fmt.Errorf("outbox.ttl and outbox.max_size must be positive")
+	}
+	if config.Webhook.QueueSize < 0 || config.Webhook.DeadLetterMax < 0 {
+		return nil, fmt.Errorf("webhook.queue_size and webhook.dead_letter_max must be positive")
+	}
+	if config.Webhook.Workers < 0 {
+		return nil, fmt.Errorf("invalid webhook.workers %d: must be positive", config.Webhook.Workers)
+	}
+	if config.Scheduler.MaxPending < 0 {
+		return nil, fmt.Errorf("invalid scheduler.max_pending %d: must be positive", config.Scheduler.MaxPending)
+	}
+	if err := validateBodySize(config.XMPP); err != nil {
+		return nil, err
+	}
+	if err := validateRateLimit(config.RateLimit); err != nil {
+		return nil, err
+	}
+	if err := validateTransport(config.XMPP); err != nil {
+		return nil, err
+	}
+	if err := validateTLS(config.XMPP.TLS); err != nil {
+		return nil, err
+	}
+	if err := validateSASL(config.XMPP); err != nil {
+		return nil, err
+	}
+	if !isValidTrigger(config.MUC.Trigger) {
+		return nil, fmt.Errorf("invalid muc.trigger %q: must be one of all, mention, command_prefix", config.MUC.Trigger)
+	}
+	for _, room := range config.MUC.Rooms {
+		if room.Trigger != "" && !isValidTrigger(room.Trigger) {
+			return nil, fmt.Errorf("invalid trigger %q for room %s: must be one of all, mention, command_prefix", room.Trigger, room.JID)
+		}
+	}
+	if err := validateAccounts(config.Accounts, config.XMPP); err != nil {
+		return nil, err
+	}
+	if err := validateSchedules(config.Scheduler.Schedules, config.Accounts); err != nil {
+		return nil, err
+	}
+	if err := validateSigningSecrets("webhook.signing_secrets", config.Webhook.SigningSecrets); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookTargets(config.Webhook.Targets); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
+
+func validateMode(xmppConfig XMPPConfig) error {
+	switch xmppConfig.Mode {
+	case ModeClient:
+		return nil
+	case ModeComponent:
+		if xmppConfig.Domain == "" {
+			return fmt.Errorf("xmpp.domain is required in component mode")
+		}
+		if xmppConfig.Secret == "" {
+			return fmt.Errorf("xmpp.secret is required in component mode")
+		}
+		if jidDomain := xmppConfig.JID[strings.Index(xmppConfig.JID, "@")+1:]; jidDomain != xmppConfig.Domain {
+			return fmt.Errorf("xmpp.jid %q must be an address under the component domain %s", xmppConfig.JID, xmppConfig.Domain)
+		}
+		// Components are not located through DNS SRV records
+		if xmppConfig.Server == "" {
+			return fmt.Errorf("xmpp.server is required in component mode")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid xmpp.mode %q: must be one of client, component", xmppConfig.Mode)
+	}
+}
+
+func validateReconnection(reconnection ReconnectionConfig) error {
+	if reconnection.MaxAttempts < -1 {
+		return fmt.Errorf("invalid reconnection.max_attempts %d: must be positive, or -1 to retry forever", reconnection.MaxAttempts)
+	}
+	if reconnection.MaxBackoff < reconnection.Backoff {
+		return fmt.Errorf("reconnection.max_backoff %s is shorter than reconnection.backoff %s", reconnection.MaxBackoff, reconnection.Backoff)
+	}
+	return nil
+}
+
+func validateBodySize(xmppConfig XMPPConfig) error {
+	if xmppConfig.MaxBodySize != 0 && xmppConfig.MaxBodySize < minBodySize {
+		return fmt.Errorf("invalid xmpp.max_body_size %d: must be 0 or at least %d", xmppConfig.MaxBodySize, minBodySize)
+	}
+	switch xmppConfig.LongMessages {
+	case LongMessagesSplit, LongMessagesUpload:
+		return nil
+	default:
+		return fmt.Errorf("invalid xmpp.long_messages %q: must be split or upload", xmppConfig.LongMessages)
+	}
+}
+
+func validateRateLimit(rateLimit RateLimitConfig) error {
+	limits := map[string]RateLimit{
+		"global":        rateLimit.Global,
+		"per_recipient": rateLimit.PerRecipient,
+		"per_room":      rateLimit.PerRoom,
+	}
+	for name, limit := range limits {
+		if limit.Rate < 0 || limit.Burst < 0 {
+			return fmt.Errorf("invalid rate_limit.%s: rate and burst must be positive", name)
+		}
+	}
+	if rateLimit.MaxQueue < 0 {
+		return fmt.Errorf("invalid rate_limit.max_queue %d: must be positive", rateLimit.MaxQueue)
+	}
+	return nil
+}
+
+func validateTransport(xmppConfig XMPPConfig) error {
+	isURL := strings.HasPrefix(xmppConfig.Server, "ws://") || strings.HasPrefix(xmppConfig.Server, "wss://")
+	switch xmppConfig.Transport {
+	case TransportTCP:
+		if isURL {
+			return fmt.Errorf("xmpp.server %q is a WebSocket URL but xmpp.transport is tcp", xmppConfig.Server)
+		}
+	case TransportWebSocket:
+		if xmppConfig.Mode == ModeComponent {
+			return fmt.Errorf("xmpp.transport websocket is not supported in component mode")
+		}
+		if xmppConfig.Server != "" && !isURL {
+			return fmt.Errorf("xmpp.server must be a ws:// or wss:// URL with the websocket transport")
+		}
+	default:
+		return fmt.Errorf("invalid xmpp.transport %q: must be one of tcp, websocket", xmppConfig.Transport)
+	}
+
+	if xmppConfig.Proxy != "" {
+		proxyURL, err := url.Parse(xmppConfig.Proxy)
+		if err != nil {
+			return fmt.Errorf("invalid xmpp.proxy: %w", err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("invalid xmpp.proxy scheme %q: must be one of http, socks5, socks5h", proxyURL.Scheme)
+		}
+		if proxyURL.Host == "" {
+			return fmt.Errorf("xmpp.proxy %q has no host", xmppConfig.Proxy)
+		}
+	}
+	return nil
+}
+
+func validateTLS(tlsConfig XMPPTLSConfig) error {
+	switch tlsConfig.Mode {
+	case TLSModeStartTLS, TLSModeDirect:
+	default:
+		return fmt.Errorf("invalid xmpp.tls.mode %q: must be one of starttls, direct", tlsConfig.Mode)
+	}
+
+	switch tlsConfig.MinVersion {
+	case "1.0", "1.1", "1.2", "1.3":
+	default:
+		return fmt.Errorf("invalid xmpp.tls.min_version %q: must be one of 1.0, 1.1, 1.2, 1.3", tlsConfig.MinVersion)
+	}
+
+	if (tlsConfig.CertFile == "") != (tlsConfig.KeyFile == "") {
+		return fmt.Errorf("xmpp.tls.cert_file and xmpp.tls.key_file must be set together")
+	}
+	return nil
+}
+
+func validateSASL(xmppConfig XMPPConfig) error {
+	switch xmppConfig.SASL.Mechanism {
+	case SASLPlain, SASLOAuth2, SASLScramSHA1, SASLScramSHA256:
+	case SASLExternal:
+		if xmppConfig.TLS.CertFile == "" {
+			return fmt.Errorf("xmpp.sasl.mechanism EXTERNAL needs a client certificate in xmpp.tls.cert_file and xmpp.tls.key_file")
+		}
+	default:
+		return fmt.Errorf("invalid xmpp.sasl.mechanism %q: must be one of PLAIN, X-OAUTH2, SCRAM-SHA-1, SCRAM-SHA-256, EXTERNAL", xmppConfig.SASL.Mechanism)
+	}
+
+	if NegotiatedByBot(xmppConfig) && xmppConfig.Mode == ModeClient && xmppConfig.Transport == TransportWebSocket {
+		return fmt.Errorf("xmpp.tls.mode %s with xmpp.sasl.mechanism %s is not supported with the websocket transport",
+			xmppConfig.TLS.Mode, xmppConfig.SASL.Mechanism)
+	}
+	return nil
+}
+
+// NegotiatedByBot reports whether the bot negotiates TLS and SASL itself because the XMPP
+// library only implements STARTTLS with PLAIN or X-OAUTH2. It does so on TCP connections only.
+func NegotiatedByBot(xmppConfig XMPPConfig) bool {
+	return xmppConfig.TLS.Mode == TLSModeDirect ||
+		(xmppConfig.SASL.Mechanism != SASLPlain && xmppConfig.SASL.Mechanism != SASLOAuth2)
+}
+
+func validateAccounts(accounts []AccountConfig, xmppConfig XMPPConfig) error {
+	names := make(map[string]bool, len(accounts))
+	for i, account := range accounts {
+		if account.Name == "" {
+			return fmt.Errorf("accounts[%d]: name is required", i)
+		}
+		if account.Name == DefaultAccount {
+			return fmt.Errorf("accounts[%d]: name %q is reserved for the xmpp section", i, DefaultAccount)
+		}
+		if names[account.Name] {
+			return fmt.Errorf("duplicate account name %q", account.Name)
+		}
+		names[account.Name] = true
+
+		if account.JID == "" {
+			return fmt.Errorf("account %s: jid is required", account.Name)
+		}
+		isURL := strings.HasPrefix(account.Server, "ws://") || strings.HasPrefix(account.Server, "wss://")
+		if isURL && NegotiatedByBot(xmppConfig) {
+			return fmt.Errorf("account %s: xmpp.tls.mode %s with xmpp.sasl.mechanism %s is not supported with the websocket transport",
+				account.Name, xmppConfig.TLS.Mode, xmppConfig.SASL.Mechanism)
+		}
+		for _, room := range account.Rooms {
+			if room.Trigger != "" && !isValidTrigger(room.Trigger) {
+				return fmt.Errorf("account %s: invalid trigger %q for room %s: must be one of all, mention, command_prefix", account.Name, room.Trigger, room.JID)
+			}
+		}
+	}
+	return nil
+}
+
+func validateSchedules(schedules []ScheduleConfig, accounts []AccountConfig) error {
+	names := make(map[string]bool, len(schedules))
+	for _, schedule := range schedules {
+		if err := schedule.Validate(); err != nil {
+			return err
+		}
+		if names[schedule.Name] {
+			return fmt.Errorf("duplicate schedule name %q", schedule.Name)
+		}
+		names[schedule.Name] = true
+
+		if schedule.Account != "" && schedule.Account != DefaultAccount && !slices.ContainsFunc(accounts, func(a AccountConfig) bool {
+			return a.Name == schedule.Account
+		}) {
+			return fmt.Errorf("schedule %s: unknown account %q", schedule.Name, schedule.Account)
+		}
+	}
+	return nil
+}
+
+func validateWebhookTargets(targets []WebhookTargetConfig) error {
+	names := make(map[string]bool, len(targets))
+	for i, target := range targets {
+		if target.Name == "" {
+			return fmt.Errorf("webhook.targets[%d]: name is required", i)
+		}
+		if target.Name == "default" {
+			return fmt.Errorf("webhook.targets[%d]: name default is reserved for webhook.url", i)
+		}
+		if names[target.Name] {
+			return fmt.Errorf("duplicate webhook target name %q", target.Name)
+		}
+		names[target.Name] = true
+
+		if !strings.HasPrefix(target.URL, "http://") && !strings.HasPrefix(target.URL, "https://") {
+			return fmt.Errorf("webhook target %s: url must be an http or https URL", target.Name)
+		}
+		if target.Timeout < 0 || target.RetryAttempts < 0 {
+			return fmt.Errorf("webhook target %s: timeout and retry_attempts must be positive", target.Name)
+		}
+		if err := target.Match.Validate(); err != nil {
+			return fmt.Errorf("webhook target %s: %w", target.Name, err)
+		}
+		if err := validateSigningSecrets("webhook target "+target.Name+" signing_secrets", target.SigningSecrets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateSigningSecrets(name string, secrets []string) error {
+	if len(secrets) > 2 {
+		return fmt.Errorf("%s: at most two secrets, the current and the previous one", name)
+	}
+	if slices.Contains(secrets, "") {
+		return fmt.Errorf("%s: secrets must not be empty", name)
+	}
+	return nil
+}
+
+// ForAccount returns a copy of the configuration with the XMPP identity, rooms and webhook
+// target of an additional account. Everything else is shared with the default account.
+func (c *Config) ForAccount(account AccountConfig) *Config {
+	cfg := *c
+
+	// Additional accounts always connect as regular clients
+	cfg.XMPP.Mode = ModeClient
+	cfg.XMPP.Domain = ""
+	cfg.XMPP.Secret = ""
+	cfg.XMPP.JID = account.JID
+	cfg.XMPP.Password = account.Password
+	if account.Server != "" {
+		cfg.XMPP.Server = account.Server
+		cfg.XMPP.Transport = TransportTCP
+		if strings.HasPrefix(account.Server, "ws://") || strings.HasPrefix(account.Server, "wss://") {
+			cfg.XMPP.Transport = TransportWebSocket
+		}
+	}
+	if account.Resource != "" {
+		cfg.XMPP.Resource = account.Resource
+	}
+
+	cfg.MUC.Nick = account.Nick
+	if cfg.MUC.Nick == "" {
+		cfg.MUC.Nick = strings.Split(account.JID, "@")[0]
+	}
+	cfg.MUC.Rooms = account.Rooms
+
+	if account.Webhook.URL != "" {
+		cfg.Webhook.URL = account.Webhook.URL
+	}
+	if account.Webhook.APIKey != "" {
+		cfg.Webhook.APIKey = account.Webhook.APIKey
+	}
+	cfg.Accounts = nil
+
+	return &cfg
+}
+
+// WebhookTarget returns the webhook URL and API key for messages received by an account
+func (c *Config) WebhookTarget(account string) (string, string) {
+	for _, acc := range c.Accounts {
+		if acc.Name == account {
+			cfg := c.ForAccount(acc)
+			return cfg.Webhook.URL, cfg.Webhook.APIKey
+		}
+	}
+	return c.Webhook.URL, c.Webhook.APIKey
+}
+
+func isValidTrigger(trigger string) bool {
+	return trigger == TriggerAll || trigger == TriggerMention || trigger == TriggerCommandPrefix
+}
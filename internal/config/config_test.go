@@ -108,6 +108,7 @@ xmpp:
 	assert.Equal(t, "stdout", cfg.Logging.Output)
 	assert.Equal(t, 5, cfg.Reconnection.MaxAttempts)
 	assert.Equal(t, 5*time.Second, cfg.Reconnection.Backoff)
+	assert.Equal(t, 5*time.Minute, cfg.Reconnection.MaxBackoff)
 }
 
 func TestLoad_FileNotFound(t *testing.T) {
@@ -407,3 +408,730 @@ xmpp:
 	assert.Equal(t, "stdout", cfg.Logging.Output)
 	assert.Empty(t, cfg.Logging.FilePath)
 }
+
+func TestLoad_MUCConfig(t *testing.T) {
+	configContent := `
+xmpp:
+  jid: "bot@example.com"
+  password: "secret123"
+
+muc:
+  rooms:
+    - jid: "ops@conference.example.com"
+    - jid: "secret@conference.example.com"
+      nick: "OpsBot"
+      password: "hunter2"
+  invitations:
+    auto_accept: true
+    allowed_domains: ["example.com"]
+    allowed_inviters: ["boss@partner.org"]
+`
+
+	tempFile := filepath.Join(t.TempDir(), "muc-config.yaml")
+	err := os.WriteFile(tempFile, []byte(configContent), 0644)
+	require.NoError(t, err)
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+
+	// Nick defaults to the JID localpart
+	assert.Equal(t, "bot", cfg.MUC.Nick)
+
+	require.Len(t, cfg.MUC.Rooms, 2)
+	assert.Equal(t, "ops@conference.example.com", cfg.MUC.Rooms[0].JID)
+	assert.Equal(t, "OpsBot", cfg.MUC.Rooms[1].Nick)
+	assert.Equal(t, "hunter2", cfg.MUC.Rooms[1].Password)
+
+	assert.True(t, cfg.MUC.Invitations.AutoAccept)
+	assert.Equal(t, []string{"example.com"}, cfg.MUC.Invitations.AllowedDomains)
+	assert.Equal(t, []string{"boss@partner.org"}, cfg.MUC.Invitations.AllowedInviters)
+}
+
+func TestLoad_MUCTrigger(t *testing.T) {
+	configContent := `
+xmpp:
+  jid: "bot@example.com"
+  password: "secret123"
+
+muc:
+  trigger: "mention"
+  rooms:
+    - jid: "ops@conference.example.com"
+      trigger: "command_prefix"
+      command_prefix: "/"
+`
+
+	tempFile := filepath.Join(t.TempDir(), "muc-trigger.yaml")
+	require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, TriggerMention, cfg.MUC.Trigger)
+	assert.Equal(t, "!", cfg.MUC.CommandPrefix)
+	require.Len(t, cfg.MUC.Rooms, 1)
+	assert.Equal(t, TriggerCommandPrefix, cfg.MUC.Rooms[0].Trigger)
+	assert.Equal(t, "/", cfg.MUC.Rooms[0].CommandPrefix)
+}
+
+func TestLoad_MUCTrigger_Invalid(t *testing.T) {
+	configContent := `
+xmpp:
+  jid: "bot@example.com"
+  password: "secret123"
+
+muc:
+  rooms:
+    - jid: "ops@conference.example.com"
+      trigger: "sometimes"
+`
+
+	tempFile := filepath.Join(t.TempDir(), "muc-trigger-invalid.yaml")
+	require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+	_, err := Load(tempFile)
+	assert.ErrorContains(t, err, "invalid trigger")
+}
+
+func TestLoad_Accounts(t *testing.T) {
+	configContent := `
+xmpp:
+  jid: "bot@example.com"
+  password: "secret123"
+  server: "xmpp.example.com:5222"
+  resource: "bot"
+
+webhook:
+  url: "https://hooks.example.com/default"
+  api_key: "default-key"
+
+muc:
+  rooms:
+    - jid: "general@conference.example.com"
+
+accounts:
+  - name: "alerts"
+    jid: "alerts@example.com"
+    password: "alerts-secret"
+    rooms:
+      - jid: "ops@conference.example.com"
+    webhook:
+      url: "https://hooks.example.com/alerts"
+  - name: "support"
+    jid: "support@example.com"
+    password: "support-secret"
+    server: "support.example.com:5222"
+    nick: "Helpdesk"
+`
+
+	tempFile := filepath.Join(t.TempDir(), "accounts.yaml")
+	require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+	require.Len(t, cfg.Accounts, 2)
+
+	alerts := cfg.ForAccount(cfg.Accounts[0])
+	assert.Equal(t, "alerts@example.com", alerts.XMPP.JID)
+	assert.Equal(t, "alerts-secret", alerts.XMPP.Password)
+	assert.Equal(t, "xmpp.example.com:5222", alerts.XMPP.Server)
+	assert.Equal(t, "bot", alerts.XMPP.Resource)
+	assert.Equal(t, "alerts", alerts.MUC.Nick)
+	require.Len(t, alerts.MUC.Rooms, 1)
+	assert.Equal(t, "ops@conference.example.com", alerts.MUC.Rooms[0].JID)
+	assert.Empty(t, alerts.Accounts)
+
+	support := cfg.ForAccount(cfg.Accounts[1])
+	assert.Equal(t, "support.example.com:5222", support.XMPP.Server)
+	assert.Equal(t, "Helpdesk", support.MUC.Nick)
+	assert.Empty(t, support.MUC.Rooms)
+
+	// The default account is left untouched
+	assert.Equal(t, "bot@example.com", cfg.XMPP.JID)
+	require.Len(t, cfg.MUC.Rooms, 1)
+
+	url, apiKey := cfg.WebhookTarget("alerts")
+	assert.Equal(t, "https://hooks.example.com/alerts", url)
+	assert.Equal(t, "default-key", apiKey)
+
+	url, _ = cfg.WebhookTarget("support")
+	assert.Equal(t, "https://hooks.example.com/default", url)
+	url, _ = cfg.WebhookTarget(DefaultAccount)
+	assert.Equal(t, "https://hooks.example.com/default", url)
+}
+
+func TestLoad_Accounts_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts string
+		errMsg   string
+	}{
+		{
+			name: "missing name",
+			accounts: `
+  - jid: "alerts@example.com"`,
+			errMsg: "name is required",
+		},
+		{
+			name: "reserved name",
+			accounts: `
+  - name: "default"
+    jid: "alerts@example.com"`,
+			errMsg: "reserved",
+		},
+		{
+			name: "duplicate name",
+			accounts: `
+  - name: "alerts"
+    jid: "alerts@example.com"
+  - name: "alerts"
+    jid: "alerts2@example.com"`,
+			errMsg: "duplicate account name",
+		},
+		{
+			name: "missing jid",
+			accounts: `
+  - name: "alerts"`,
+			errMsg: "jid is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configContent := `
+xmpp:
+  jid: "bot@example.com"
+  password: "secret123"
+
+accounts:` + tt.accounts + "\n"
+
+			tempFile := filepath.Join(t.TempDir(), "accounts-invalid.yaml")
+			require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+			_, err := Load(tempFile)
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestLoad_ComponentMode(t *testing.T) {
+	configContent := `
+xmpp:
+  mode: "component"
+  server: "localhost:5347"
+  domain: "bot.example.org"
+  secret: "component-secret"
+`
+
+	tempFile := filepath.Join(t.TempDir(), "component.yaml")
+	require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, ModeComponent, cfg.XMPP.Mode)
+	assert.Equal(t, "bot.example.org", cfg.XMPP.Domain)
+	assert.Equal(t, "component-secret", cfg.XMPP.Secret)
+	// The component domain is the default sender
+	assert.Equal(t, "bot.example.org", cfg.XMPP.JID)
+}
+
+func TestLoad_ComponentMode_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		xmpp   string
+		errMsg string
+	}{
+		{
+			name: "unknown mode",
+			xmpp: `
+  mode: "server"`,
+			errMsg: "invalid xmpp.mode",
+		},
+		{
+			name: "missing secret",
+			xmpp: `
+  mode: "component"
+  domain: "bot.example.org"`,
+			errMsg: "xmpp.secret is required",
+		},
+		{
+			name: "sender outside domain",
+			xmpp: `
+  mode: "component"
+  jid: "bot@example.org"
+  domain: "bot.example.org"
+  secret: "component-secret"`,
+			errMsg: "must be an address under the component domain",
+		},
+		{
+			name: "missing server",
+			xmpp: `
+  mode: "component"
+  domain: "bot.example.org"
+  secret: "component-secret"`,
+			errMsg: "xmpp.server is required in component mode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configContent := "xmpp:" + tt.xmpp + "\n"
+
+			tempFile := filepath.Join(t.TempDir(), "component-invalid.yaml")
+			require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+			_, err := Load(tempFile)
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestLoad_TLSAndSASL(t *testing.T) {
+	configContent := `
+xmpp:
+  jid: "bot@example.org"
+  password: "secret"
+  tls:
+    ca_file: "/etc/ssl/internal-ca.pem"
+    server_name: "xmpp.internal.example.org"
+    min_version: "1.3"
+  sasl:
+    mechanism: "x-oauth2"
+`
+
+	tempFile := filepath.Join(t.TempDir(), "tls.yaml")
+	require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, TLSModeStartTLS, cfg.XMPP.TLS.Mode)
+	assert.Equal(t, "/etc/ssl/internal-ca.pem", cfg.XMPP.TLS.CAFile)
+	assert.Equal(t, "xmpp.internal.example.org", cfg.XMPP.TLS.ServerName)
+	assert.Equal(t, "1.3", cfg.XMPP.TLS.MinVersion)
+	assert.Equal(t, SASLOAuth2, cfg.XMPP.SASL.Mechanism)
+}
+
+func TestLoad_DirectTLSAndExternal(t *testing.T) {
+	configContent := `
+xmpp:
+  jid: "bot@example.org"
+  server: "xmpp.example.org:5223"
+  tls:
+    mode: "direct"
+    cert_file: "/etc/ssl/bot.pem"
+    key_file: "/etc/ssl/bot.key"
+  sasl:
+    mechanism: "external"
+`
+
+	tempFile := filepath.Join(t.TempDir(), "external.yaml")
+	require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, TLSModeDirect, cfg.XMPP.TLS.Mode)
+	assert.Equal(t, SASLExternal, cfg.XMPP.SASL.Mechanism)
+	assert.True(t, NegotiatedByBot(cfg.XMPP))
+}
+
+func TestLoad_TLSAndSASL_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		xmpp   string
+		errMsg string
+	}{
+		{
+			name: "unknown TLS mode",
+			xmpp: `
+  tls:
+    mode: "plain"`,
+			errMsg: "invalid xmpp.tls.mode",
+		},
+		{
+			name: "unknown TLS version",
+			xmpp: `
+  tls:
+    min_version: "2.0"`,
+			errMsg: "invalid xmpp.tls.min_version",
+		},
+		{
+			name: "certificate without key",
+			xmpp: `
+  tls:
+    cert_file: "/etc/ssl/bot.pem"`,
+			errMsg: "must be set together",
+		},
+		{
+			name: "EXTERNAL without client certificate",
+			xmpp: `
+  sasl:
+    mechanism: "EXTERNAL"`,
+			errMsg: "EXTERNAL needs a client certificate",
+		},
+		{
+			name: "SCRAM over WebSocket",
+			xmpp: `
+  server: "wss://xmpp.example.org/ws"
+  sasl:
+    mechanism: "SCRAM-SHA-256"`,
+			errMsg: "not supported with the websocket transport",
+		},
+		{
+			name: "direct TLS over WebSocket",
+			xmpp: `
+  transport: "websocket"
+  tls:
+    mode: "direct"`,
+			errMsg: "not supported with the websocket transport",
+		},
+		{
+			name: "unknown mechanism",
+			xmpp: `
+  sasl:
+    mechanism: "DIGEST-MD5"`,
+			errMsg: "invalid xmpp.sasl.mechanism",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configContent := "xmpp:" + tt.xmpp + "\n"
+
+			tempFile := filepath.Join(t.TempDir(), "tls-invalid.yaml")
+			require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+			_, err := Load(tempFile)
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestLoad_Transport(t *testing.T) {
+	configContent := `
+xmpp:
+  jid: "bot@example.org"
+  password: "secret"
+  server: "wss://example.org/xmpp-websocket"
+  proxy: "socks5://proxy.internal:1080"
+accounts:
+  - name: "support"
+    jid: "support@example.org"
+    server: "xmpp.example.org:5222"
+`
+
+	tempFile := filepath.Join(t.TempDir(), "transport.yaml")
+	require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+
+	// A WebSocket URL selects the WebSocket transport
+	assert.Equal(t, TransportWebSocket, cfg.XMPP.Transport)
+	assert.Equal(t, "socks5://proxy.internal:1080", cfg.XMPP.Proxy)
+
+	// An account with a host:port server connects over TCP through the same proxy
+	account := cfg.ForAccount(cfg.Accounts[0])
+	assert.Equal(t, TransportTCP, account.XMPP.Transport)
+	assert.Equal(t, "socks5://proxy.internal:1080", account.XMPP.Proxy)
+}
+
+func TestLoad_Transport_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		xmpp   string
+		errMsg string
+	}{
+		{
+			name: "unknown transport",
+			xmpp: `
+  transport: "bosh"`,
+			errMsg: "invalid xmpp.transport",
+		},
+		{
+			name: "websocket with host:port",
+			xmpp: `
+  transport: "websocket"
+  server: "example.org:5222"`,
+			errMsg: "must be a ws:// or wss:// URL",
+		},
+		{
+			name: "tcp with URL",
+			xmpp: `
+  transport: "tcp"
+  server: "wss://example.org/ws"`,
+			errMsg: "is a WebSocket URL",
+		},
+		{
+			name: "unsupported proxy scheme",
+			xmpp: `
+  proxy: "ftp://proxy.internal:21"`,
+			errMsg: "invalid xmpp.proxy scheme",
+		},
+		{
+			name: "proxy without host",
+			xmpp: `
+  proxy: "http://"`,
+			errMsg: "has no host",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configContent := "xmpp:" + tt.xmpp + "\n"
+
+			tempFile := filepath.Join(t.TempDir(), "transport-invalid.yaml")
+			require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+			_, err := Load(tempFile)
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestLoad_Reconnection_Invalid(t *testing.T) {
+	tests := []struct {
+		name         string
+		reconnection string
+		errMsg       string
+	}{
+		{
+			name: "negative attempts",
+			reconnection: `
+  max_attempts: -2`,
+			errMsg: "invalid reconnection.max_attempts",
+		},
+		{
+			name: "max backoff below backoff",
+			reconnection: `
+  backoff: "1m"
+  max_backoff: "10s"`,
+			errMsg: "shorter than reconnection.backoff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configContent := "reconnection:" + tt.reconnection + "\n"
+
+			tempFile := filepath.Join(t.TempDir(), "reconnection-invalid.yaml")
+			require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+			_, err := Load(tempFile)
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestLoad_Outbox(t *testing.T) {
+	configContent := `
+outbox:
+  enabled: true
+  path: "/var/lib/jabber-bot/outbox.json"
+`
+	tempFile := filepath.Join(t.TempDir(), "outbox.yaml")
+	require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Outbox.Enabled)
+	assert.Equal(t, "/var/lib/jabber-bot/outbox.json", cfg.Outbox.Path)
+	assert.Equal(t, 10*time.Minute, cfg.Outbox.TTL)
+	assert.Equal(t, 1000, cfg.Outbox.MaxSize)
+
+	// Negative limits are rejected
+	require.NoError(t, os.WriteFile(tempFile, []byte("outbox:\n  max_size: -1\n"), 0644))
+	_, err = Load(tempFile)
+	assert.ErrorContains(t, err, "outbox.max_size")
+}
+
+func TestLoad_RateLimit(t *testing.T) {
+	configContent := `
+rate_limit:
+  enabled: true
+  global:
+    rate: 20
+    burst: 40
+  per_recipient:
+    rate: 1
+`
+	tempFile := filepath.Join(t.TempDir(), "rate-limit.yaml")
+	require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+
+	assert.True(t, cfg.RateLimit.Enabled)
+	assert.Equal(t, RateLimit{Rate: 20, Burst: 40}, cfg.RateLimit.Global)
+	assert.Equal(t, RateLimit{Rate: 1, Burst: 1}, cfg.RateLimit.PerRecipient)
+	assert.Equal(t, RateLimit{}, cfg.RateLimit.PerRoom)
+	assert.Equal(t, 100, cfg.RateLimit.MaxQueue)
+
+	require.NoError(t, os.WriteFile(tempFile, []byte("rate_limit:\n  per_room:\n    rate: -1\n"), 0644))
+	_, err = Load(tempFile)
+	assert.ErrorContains(t, err, "invalid rate_limit.per_room")
+}
+
+func TestLoad_MaxBodySize(t *testing.T) {
+	tests := []struct {
+		name   string
+		xmpp   string
+		errMsg string
+	}{
+		{name: "defaults", xmpp: `
+  jid: "bot@example.org"`},
+		{name: "upload", xmpp: `
+  max_body_size: 4096
+  long_messages: "upload"`},
+		{name: "too small", xmpp: `
+  max_body_size: 10`, errMsg: "invalid xmpp.max_body_size"},
+		{name: "unknown mode", xmpp: `
+  long_messages: "truncate"`, errMsg: "invalid xmpp.long_messages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configContent := "xmpp:" + tt.xmpp + "\n"
+
+			tempFile := filepath.Join(t.TempDir(), "body-size.yaml")
+			require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+			cfg, err := Load(tempFile)
+			if tt.errMsg != "" {
+				assert.ErrorContains(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, []string{LongMessagesSplit, LongMessagesUpload}, cfg.XMPP.LongMessages)
+		})
+	}
+}
+
+func TestLoad_TemplatesDefault(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "templates.yaml")
+	require.NoError(t, os.WriteFile(tempFile, []byte("xmpp:\n  jid: \"bot@example.org\"\n"), 0644))
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+	assert.Equal(t, "./templates", cfg.Templates.Dir)
+}
+
+func TestLoad_Scheduler(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "scheduler.yaml")
+	require.NoError(t, os.WriteFile(tempFile, []byte("xmpp:\n  jid: \"bot@example.org\"\n"), 0644))
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+	assert.Equal(t, "./data/scheduled.json", cfg.Scheduler.Path)
+	assert.Equal(t, 10000, cfg.Scheduler.MaxPending)
+
+	require.NoError(t, os.WriteFile(tempFile, []byte("xmpp:\n  jid: \"bot@example.org\"\nscheduler:\n  max_pending: -1\n"), 0644))
+	_, err = Load(tempFile)
+	assert.ErrorContains(t, err, "scheduler.max_pending")
+}
+
+func TestLoad_Schedules(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "schedules.yaml")
+	configContent := `
+xmpp:
+  jid: "bot@example.org"
+scheduler:
+  schedules:
+    - name: "standup"
+      cron: "45 9 * * mon-fri"
+      timezone: "Europe/Berlin"
+      rooms: ["team@conference.example.org"]
+      body: "Standup in 15 minutes"
+      missed_runs: "once"
+`
+	require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+	assert.Equal(t, "./data/schedules.json", cfg.Scheduler.SchedulesPath)
+	require.Len(t, cfg.Scheduler.Schedules, 1)
+	assert.Equal(t, MissedRunsOnce, cfg.Scheduler.Schedules[0].MissedRuns)
+	assert.Equal(t, []string{"team@conference.example.org"}, cfg.Scheduler.Schedules[0].Rooms)
+
+	for _, schedule := range []string{
+		`{name: "a", cron: "61 * * * *", jids: ["a@example.org"], body: "x"}`,
+		`{name: "a", cron: "@daily", timezone: "Mars/Olympus", jids: ["a@example.org"], body: "x"}`,
+		`{name: "a", cron: "@daily", body: "x"}`,
+		`{name: "a", cron: "@daily", jids: ["a@example.org"]}`,
+		`{name: "a", cron: "@daily", jids: ["a@example.org"], body: "x", account: "missing"}`,
+		`{name: "a b", cron: "@daily", jids: ["a@example.org"], body: "x"}`,
+	} {
+		content := "xmpp:\n  jid: \"bot@example.org\"\nscheduler:\n  schedules:\n    - " + schedule + "\n"
+		require.NoError(t, os.WriteFile(tempFile, []byte(content), 0644))
+		_, err := Load(tempFile)
+		assert.Error(t, err, schedule)
+	}
+}
+
+func TestLoad_WebhookTargets(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "targets.yaml")
+	configContent := `
+xmpp:
+  jid: "bot@example.org"
+webhook:
+  timeout: 20s
+  targets:
+    - name: "ops"
+      url: "https://n8n.example.org/webhook/ops"
+      retry_attempts: 5
+      headers:
+        X-Workflow: "ops"
+      match:
+        rooms: ["ops@conference.example.org"]
+        body: "(?i)alert"
+`
+	require.NoError(t, os.WriteFile(tempFile, []byte(configContent), 0644))
+
+	cfg, err := Load(tempFile)
+	require.NoError(t, err)
+	assert.Equal(t, "./data/webhook_subscriptions.json", cfg.Webhook.SubscriptionsPath)
+	assert.Equal(t, "./data/webhook_queue.json", cfg.Webhook.QueuePath)
+	assert.Equal(t, 10000, cfg.Webhook.QueueSize)
+	assert.Equal(t, "./data/webhook_dead_letters.json", cfg.Webhook.DeadLetterPath)
+	assert.Equal(t, 1000, cfg.Webhook.DeadLetterMax)
+	assert.Equal(t, 4, cfg.Webhook.Workers)
+	require.Len(t, cfg.Webhook.Targets, 1)
+	target := cfg.Webhook.Targets[0]
+	assert.Equal(t, 20*time.Second, target.Timeout)
+	assert.Equal(t, 5, target.RetryAttempts)
+	assert.Equal(t, "ops", target.Headers["x-workflow"])
+	assert.Equal(t, []string{"ops@conference.example.org"}, target.Match.Rooms)
+
+	for _, target := range []string{
+		`{url: "https://example.org/hook"}`,
+		`{name: "a", url: "ftp://example.org/hook"}`,
+		`{name: "a", url: "https://example.org/hook", match: {body: "(unclosed"}}`,
+		`{name: "a", url: "https://example.org/hook", match: {types: ["error"]}}`,
+		`{name: "a", url: "https://example.org/hook", signing_secrets: ["new", "old", "older"]}`,
+		`{name: "default", url: "https://example.org/hook"}`,
+	} {
+		content := "xmpp:\n  jid: \"bot@example.org\"\nwebhook:\n  targets:\n    - " + target + "\n"
+		require.NoError(t, os.WriteFile(tempFile, []byte(content), 0644))
+		_, err := Load(tempFile)
+		assert.Error(t, err, target)
+	}
+
+	content := "xmpp:\n  jid: \"bot@example.org\"\nwebhook:\n  signing_secrets: [\"\"]\n"
+	require.NoError(t, os.WriteFile(tempFile, []byte(content), 0644))
+	_, err = Load(tempFile)
+	assert.Error(t, err)
+
+	content = "xmpp:\n  jid: \"bot@example.org\"\nwebhook:\n  workers: -1\n"
+	require.NoError(t, os.WriteFile(tempFile, []byte(content), 0644))
+	_, err = Load(tempFile)
+	assert.Error(t, err)
+
+	content = "xmpp:\n  jid: \"bot@example.org\"\nwebhook:\n  targets:\n" +
+		"    - {name: \"a\", url: \"https://example.org/a\"}\n    - {name: \"a\", url: \"https://example.org/b\"}\n"
+	require.NoError(t, os.WriteFile(tempFile, []byte(content), 0644))
+	_, err = Load(tempFile)
+	assert.Error(t, err)
+}
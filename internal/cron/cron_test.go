@@ -0,0 +1,83 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2026, 3, 4, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 4, 9, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 4, 9, 45, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2026, 3, 5, 9, 30, 0, 0, time.UTC)},
+		{"0 17 * * 7", time.Date(2026, 3, 8, 17, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 jun *", time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2026, 3, 4, 10, 5, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches
+		{"0 8 13 * fri", time.Date(2026, 3, 6, 8, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			schedule, err := Parse(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, schedule.Next(from))
+		})
+	}
+}
+
+func TestNext_TimeZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	schedule, err := Parse("0 9 * * *")
+	require.NoError(t, err)
+
+	next := schedule.Next(time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC).In(berlin))
+	assert.Equal(t, time.Date(2026, 1, 11, 8, 0, 0, 0, time.UTC), next.UTC())
+
+	// 02:30 does not exist on the day clocks move forward
+	schedule, err = Parse("30 2 * * *")
+	require.NoError(t, err)
+	next = schedule.Next(time.Date(2026, 3, 28, 12, 0, 0, 0, berlin))
+	assert.Equal(t, time.Date(2026, 3, 30, 2, 30, 0, 0, berlin), next)
+}
+
+func TestNext_NeverMatches(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every 5m",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
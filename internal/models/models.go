@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Message represents an XMPP message
 type Message struct {
 	ID               string `json:"id"`
@@ -10,36 +12,338 @@ type Message struct {
 	Subject          string `json:"subject"`
 	Thread           string `json:"thread"`
 	Stamp            string `json:"stamp"`
+	Account          string `json:"account,omitempty"` // bot account that received the message
 	ReceiptRequested bool   `json:"receipt_requested,omitempty"`
+	Mentioned        bool   `json:"mentioned,omitempty"`   // groupchat message addresses the bot
+	MUCPrivate       bool   `json:"muc_private,omitempty"` // private message from a room occupant
+	// Event is set for non-chat events (e.g. "invite"); empty means a regular message
+	Event    string    `json:"event,omitempty"`
+	Invite   *Invite   `json:"invite,omitempty"`
+	Occupant *Occupant `json:"occupant,omitempty"`
+}
+
+// Invite describes a MUC invitation received by the bot (XEP-0249 or XEP-0045 mediated)
+type Invite struct {
+	Room     string `json:"room"`
+	Inviter  string `json:"inviter"`
+	Reason   string `json:"reason,omitempty"`
+	Mediated bool   `json:"mediated"`
+	Accepted bool   `json:"accepted"`
+}
+
+// Occupant describes a user present in a MUC room
+type Occupant struct {
+	Room        string `json:"room"`
+	Nick        string `json:"nick"`
+	JID         string `json:"jid,omitempty"` // real JID, only visible in non-anonymous rooms or to moderators
+	Role        string `json:"role,omitempty"`
+	Affiliation string `json:"affiliation,omitempty"`
+	Show        string `json:"show,omitempty"`
+	Status      string `json:"status,omitempty"`
+}
+
+// SendResult describes how an outbound message was handled
+type SendResult struct {
+	Queued    bool          // the message was queued while disconnected and is sent after reconnect
+	MessageID string        // ID of the queued message
+	ExpiresAt time.Time     // the queued message is dropped if not sent by then
+	Delay     time.Duration // time the message waited for the outbound rate limit
+}
+
+// MUCRoomHealth describes the health of a joined room as seen by the self-ping check (XEP-0410)
+type MUCRoomHealth struct {
+	Room      string `json:"room"`
+	Nick      string `json:"nick"`
+	Joined    bool   `json:"joined"`  // the room confirmed our presence
+	Healthy   bool   `json:"healthy"` // the last self-ping succeeded
+	LastPing  string `json:"last_ping,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+	Rejoins   int    `json:"rejoins"`
+}
+
+// MUCRoomState describes a joined room and its current occupants
+type MUCRoomState struct {
+	Room        string     `json:"room"`
+	Nick        string     `json:"nick"`
+	Role        string     `json:"role,omitempty"`
+	Affiliation string     `json:"affiliation,omitempty"`
+	Occupants   []Occupant `json:"occupants"`
 }
 
 // SendMessageRequest represents API request to send a message
 type SendMessageRequest struct {
-	To   string `json:"to" validate:"required"`
-	Body string `json:"body" validate:"required"`
-	Type string `json:"type,omitempty"`
+	To       string                 `json:"to" validate:"required"`
+	Body     string                 `json:"body"`
+	Template string                 `json:"template,omitempty"` // template rendered into the body instead of body
+	Data     map[string]interface{} `json:"data,omitempty"`     // template variables
+	Type     string                 `json:"type,omitempty"`
+	Account  string                 `json:"account,omitempty"` // sending bot account (empty = default account)
+	From     string                 `json:"from,omitempty"`    // sender localpart, component mode only
+	SendAt   string                 `json:"send_at,omitempty"` // RFC 3339 time to deliver the message at
+	Delay    string                 `json:"delay,omitempty"`   // delivers the message after this duration, e.g. 15m
 }
 
 // SendMUCMessageRequest represents API request to send a message to MUC
 type SendMUCMessageRequest struct {
-	Room    string `json:"room" validate:"required"`
+	Room     string                 `json:"room" validate:"required"`
+	Body     string                 `json:"body"`
+	Template string                 `json:"template,omitempty"` // template rendered into the body instead of body
+	Data     map[string]interface{} `json:"data,omitempty"`     // template variables
+	Subject  string                 `json:"subject,omitempty"`
+	Mentions []string               `json:"mentions,omitempty"` // occupant nicks to highlight
+	Account  string                 `json:"account,omitempty"`
+	SendAt   string                 `json:"send_at,omitempty"` // RFC 3339 time to deliver the message at
+	Delay    string                 `json:"delay,omitempty"`   // delivers the message after this duration, e.g. 15m
+}
+
+// ScheduledMessage is a message waiting for its delivery time. Messages to rooms set Room,
+// messages to users set To.
+type ScheduledMessage struct {
+	ID        string    `json:"id"`
+	Account   string    `json:"account,omitempty"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	Room      string    `json:"room,omitempty"`
+	Body      string    `json:"body"`
+	Type      string    `json:"type,omitempty"`
+	Subject   string    `json:"subject,omitempty"`
+	Mentions  []string  `json:"mentions,omitempty"`
+	SendAt    time.Time `json:"send_at"`
+	CreatedAt time.Time `json:"created_at"`
+	Attempts  int       `json:"attempts,omitempty"`   // failed delivery attempts
+	LastError string    `json:"last_error,omitempty"` // error of the last failed attempt
+}
+
+// ScheduleRequest defines a message sent on a cron schedule
+type ScheduleRequest struct {
+	Name       string                 `json:"name"`
+	Cron       string                 `json:"cron"`               // five-field cron expression or @daily, @hourly, ...
+	Timezone   string                 `json:"timezone,omitempty"` // IANA time zone of the expression, UTC by default
+	JIDs       []string               `json:"jids,omitempty"`
+	Rooms      []string               `json:"rooms,omitempty"`
+	Body       string                 `json:"body,omitempty"`
+	Template   string                 `json:"template,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	URL        string                 `json:"url,omitempty"`         // content fetched at every run
+	Account    string                 `json:"account,omitempty"`     // sending account (empty = default account)
+	MissedRuns string                 `json:"missed_runs,omitempty"` // skip (default) or once
+}
+
+// Schedule is a recurring message with the state of its runs
+type Schedule struct {
+	ScheduleRequest
+	Source     string     `json:"source"` // config or api
+	NextRun    time.Time  `json:"next_run"`
+	LastRun    *time.Time `json:"last_run,omitempty"`
+	LastStatus string     `json:"last_status,omitempty"` // sent, failed or skipped
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// BroadcastRequest represents API request to send the same message to several users and rooms
+type BroadcastRequest struct {
+	JIDs      []string                          `json:"jids,omitempty"`
+	Rooms     []string                          `json:"rooms,omitempty"`
+	Body      string                            `json:"body"`
+	Template  string                            `json:"template,omitempty"`  // template rendered into the body instead of body
+	Data      map[string]interface{}            `json:"data,omitempty"`      // template variables of every recipient
+	Variables map[string]map[string]interface{} `json:"variables,omitempty"` // template variables per JID or room, merged over data
+	Type      string                            `json:"type,omitempty"`      // message type of the messages to JIDs
+	Account   string                            `json:"account,omitempty"`
+}
+
+// BroadcastMessage is one message of a broadcast
+type BroadcastMessage struct {
+	To   string
+	Room bool
+	Body string
+	Type string
+}
+
+// BroadcastResult is the outcome of one message of a broadcast
+type BroadcastResult struct {
+	To        string `json:"to"`
+	Room      bool   `json:"room,omitempty"`
+	Success   bool   `json:"success"`
+	Queued    bool   `json:"queued,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
+	DelayMs   int64  `json:"delay_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// MUCInviteRequest represents API request to invite users into a MUC room
+type MUCInviteRequest struct {
+	Room     string   `json:"room" validate:"required"`
+	JIDs     []string `json:"jids" validate:"required"`
+	Reason   string   `json:"reason,omitempty"`
+	Mediated bool     `json:"mediated,omitempty"` // send via the room (XEP-0045) instead of directly (XEP-0249)
+	Account  string   `json:"account,omitempty"`
+}
+
+// MUCItem represents an occupant or affiliated user of a MUC room
+type MUCItem struct {
+	JID         string `json:"jid,omitempty"`
+	Nick        string `json:"nick,omitempty"`
+	Affiliation string `json:"affiliation,omitempty"`
+	Role        string `json:"role,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// MUCPrivateMessageRequest represents API request to send a private message to a room occupant
+type MUCPrivateMessageRequest struct {
+	Nick    string `json:"nick" validate:"required"`
 	Body    string `json:"body" validate:"required"`
-	Subject string `json:"subject,omitempty"`
+	Account string `json:"account,omitempty"`
+}
+
+// MUCAffiliationRequest represents API request to change a user's affiliation in a room
+type MUCAffiliationRequest struct {
+	JID         string `json:"jid" validate:"required"`
+	Affiliation string `json:"affiliation" validate:"required"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// MUCRoleRequest represents API request to change an occupant's role in a room
+type MUCRoleRequest struct {
+	Nick   string `json:"nick" validate:"required"`
+	Role   string `json:"role" validate:"required"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// MUCKickRequest represents API request to kick an occupant from a room
+type MUCKickRequest struct {
+	Nick   string `json:"nick" validate:"required"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// MUCSubjectRequest represents API request to change a room subject
+type MUCSubjectRequest struct {
+	Subject string `json:"subject"`
+}
+
+// MUCRoomSettings holds room configuration options (muc#roomconfig). Nil fields keep the server default.
+type MUCRoomSettings struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	MembersOnly *bool   `json:"members_only,omitempty"`
+	Persistent  *bool   `json:"persistent,omitempty"`
+	Password    *string `json:"password,omitempty"` // empty string removes the password
+	Whois       string  `json:"whois,omitempty"`    // "moderators" or "anyone"
+	Archiving   *bool   `json:"archiving,omitempty"`
+}
+
+// MUCCreateRoomRequest represents API request to create and configure a room
+type MUCCreateRoomRequest struct {
+	Room    string `json:"room" validate:"required"`
+	Nick    string `json:"nick,omitempty"`
+	Account string `json:"account,omitempty"`
+	MUCRoomSettings
+}
+
+// MUCDestroyRoomRequest represents API request to destroy a room
+type MUCDestroyRoomRequest struct {
+	AlternateVenue string `json:"alternate_venue,omitempty"`
+	Reason         string `json:"reason,omitempty"`
 }
 
 // WebhookPayload represents payload sent to webhook endpoint
 type WebhookPayload struct {
+	Event     string  `json:"event"`
 	Message   Message `json:"message"`
 	Timestamp string  `json:"timestamp"`
 	Source    string  `json:"source"`
 }
 
+// WebhookResponse is the body a webhook may answer with when webhook.reply_actions is enabled
+type WebhookResponse struct {
+	Actions []WebhookAction `json:"actions"`
+}
+
+// WebhookAction is an action the bot executes in the conversation of the message that was
+// sent to the webhook
+type WebhookAction struct {
+	Type     string `json:"type"`                // reply, react, chat_state, send_file, send_to or set_presence
+	Body     string `json:"body,omitempty"`      // reply, send_to
+	Emoji    string `json:"emoji,omitempty"`     // react
+	State    string `json:"state,omitempty"`     // chat_state: active, composing, paused, inactive or gone
+	URL      string `json:"url,omitempty"`       // send_file
+	FileName string `json:"file_name,omitempty"` // send_file, defaults to the last element of the URL
+	FileType string `json:"file_type,omitempty"` // send_file
+	To       string `json:"to,omitempty"`        // send_to: recipient JID
+	Room     string `json:"room,omitempty"`      // send_to: room JID
+	Show     string `json:"show,omitempty"`      // set_presence: empty (available), away, chat, dnd or xa
+	Status   string `json:"status,omitempty"`    // set_presence
+}
+
+// WebhookFilter selects the messages delivered to a webhook subscription. Every filter that is set
+// must match; a filter with several values matches any of them.
+type WebhookFilter struct {
+	From   []string `json:"from,omitempty"`   // sender bare JIDs or domains
+	Rooms  []string `json:"rooms,omitempty"`  // room JIDs
+	Types  []string `json:"types,omitempty"`  // chat, groupchat, normal, headline
+	Events []string `json:"events,omitempty"` // message, invite, occupant_joined, occupant_left
+	Body   string   `json:"body,omitempty"`   // regular expression
+}
+
+// WebhookSubscriptionRequest represents request to register a webhook callback URL
+type WebhookSubscriptionRequest struct {
+	URL       string        `json:"url"`
+	Filters   WebhookFilter `json:"filters"`
+	Secret    string        `json:"secret"`     // generated when empty
+	ExpiresAt string        `json:"expires_at"` // RFC 3339 time; mutually exclusive with ttl
+	TTL       string        `json:"ttl"`        // duration such as 24h; neither set never expires
+}
+
+// WebhookSubscription is a webhook callback URL registered through the API
+type WebhookSubscription struct {
+	ID        string        `json:"id"`
+	URL       string        `json:"url"`
+	Filters   WebhookFilter `json:"filters"`
+	Secret    string        `json:"secret,omitempty"` // only returned when the subscription is created
+	CreatedAt time.Time     `json:"created_at"`
+	ExpiresAt *time.Time    `json:"expires_at,omitempty"`
+}
+
+// DeadLetter is a webhook delivery that failed after all retry attempts, or a message that
+// could not be queued for delivery
+type DeadLetter struct {
+	ID       string    `json:"id"`
+	Target   string    `json:"target,omitempty"` // empty when the message was never queued
+	URL      string    `json:"url,omitempty"`
+	Message  Message   `json:"message"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// DeadLetterRequest selects dead letters to replay or delete; no IDs selects all of them
+type DeadLetterRequest struct {
+	IDs []string `json:"ids"`
+}
+
 // StatusResponse represents API response with status information
 type StatusResponse struct {
-	XMPPConnected bool   `json:"xmpp_connected"`
-	APIRunning    bool   `json:"api_running"`
-	WebhookConfig string `json:"webhook_url"`
-	Version       string `json:"version"`
+	XMPPConnected bool            `json:"xmpp_connected"`
+	APIRunning    bool            `json:"api_running"`
+	WebhookConfig string          `json:"webhook_url"`
+	Version       string          `json:"version"`
+	Accounts      []AccountStatus `json:"accounts,omitempty"`
+}
+
+// AccountStatus reports the connection state of a bot account
+type AccountStatus struct {
+	Name      string `json:"name"`
+	JID       string `json:"jid"`
+	Connected bool   `json:"connected"`
+	ConnectionStats
+	Rooms []MUCRoomHealth `json:"rooms,omitempty"`
+}
+
+// ConnectionStats reports the reconnection history of an XMPP connection
+type ConnectionStats struct {
+	Reconnects     int    `json:"reconnects"`
+	LastError      string `json:"last_error,omitempty"`
+	LastErrorAt    string `json:"last_error_at,omitempty"`
+	ConnectedSince string `json:"connected_since,omitempty"`
 }
 
 // APIResponse represents standard API response
@@ -57,16 +361,31 @@ type ErrorResponse struct {
 	Code    int    `json:"code"`
 }
 
+// TemplateErrorResponse is returned for a template that fails to parse or render
+type TemplateErrorResponse struct {
+	ErrorResponse
+	Template string `json:"template"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// TemplateRequest represents API request to create or replace a message template
+type TemplateRequest struct {
+	Source string `json:"source" validate:"required"`
+}
+
 // SendChatStateRequest represents API request to send chat state (XEP-0085)
 type SendChatStateRequest struct {
-	To    string `json:"to" validate:"required"`
-	State string `json:"state" validate:"required"`
+	To      string `json:"to" validate:"required"`
+	State   string `json:"state" validate:"required"`
+	Account string `json:"account,omitempty"`
 }
 
 // SendFileRequest represents API request to send a file via XMPP
 type SendFileRequest struct {
 	To          string `json:"to" validate:"required"`
 	Description string `json:"description,omitempty"`
+	Account     string `json:"account,omitempty"`
 	// File is not in JSON, it's multipart/form-data field
 }
 
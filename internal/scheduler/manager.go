@@ -0,0 +1,337 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/fileutil"
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxAttempts is the number of times delivery of a message is tried before it is dropped
+	maxAttempts = 3
+
+	// retryDelay is the time between delivery attempts of a message
+	retryDelay = 30 * time.Second
+)
+
+// ErrNotFound is returned for a scheduled message that does not exist or was already sent
+var ErrNotFound = errors.New("scheduled message not found")
+
+// ErrFull is returned when scheduler.max_pending messages are already scheduled
+var ErrFull = errors.New("too many scheduled messages")
+
+// XMPPManagerInterface defines the XMPP manager operations used to deliver scheduled messages
+type XMPPManagerInterface interface {
+	SendMessage(account, from, to, body, messageType string) (models.SendResult, error)
+	SendMUCMessage(account, room, body, subject string, mentions []string) (models.SendResult, error)
+}
+
+// Manager delivers messages at their scheduled time and runs recurring schedules. Pending
+// messages are persisted to a file so they survive a restart; messages that became due while
+// the bot was down are sent right after the start.
+type Manager struct {
+	config      *config.Config
+	logger      *zap.Logger
+	xmppManager XMPPManagerInterface
+	clock       Clock
+	renderer    Renderer
+	httpClient  *http.Client
+
+	mu       sync.Mutex
+	messages []models.ScheduledMessage // sorted by SendAt
+	seq      uint64
+
+	schedulesMu   sync.Mutex
+	schedules     map[string]*models.Schedule
+	schedulesWake chan struct{}
+
+	wake       chan struct{}
+	cancelFunc context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// NewManager creates new scheduler
+func NewManager(cfg *config.Config, logger *zap.Logger, xmppManager XMPPManagerInterface) *Manager {
+	return &Manager{
+		config:      cfg,
+		logger:      logger,
+		xmppManager: xmppManager,
+		clock:       realClock{},
+		httpClient:  &http.Client{Timeout: fetchTimeout},
+
+		schedules:     make(map[string]*models.Schedule),
+		schedulesWake: make(chan struct{}, 1),
+
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Start loads the persisted messages and schedules and starts delivering them
+func (m *Manager) Start(ctx context.Context) error {
+	if err := m.load(); err != nil {
+		return err
+	}
+	if err := m.loadSchedules(); err != nil {
+		return err
+	}
+
+	m.logger.Info("Starting scheduler",
+		zap.String("path", m.config.Scheduler.Path),
+		zap.Int("pending", len(m.List())),
+		zap.Int("schedules", len(m.Schedules())),
+	)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	m.cancelFunc = cancel
+
+	m.wg.Add(2)
+	go m.run(runCtx)
+	go m.runSchedules(runCtx)
+
+	return nil
+}
+
+// Stop stops the scheduler. Pending messages stay in the file.
+func (m *Manager) Stop() error {
+	m.logger.Info("Stopping scheduler")
+
+	if m.cancelFunc != nil {
+		m.cancelFunc()
+	}
+	m.wg.Wait()
+
+	m.logger.Info("Scheduler stopped")
+	return nil
+}
+
+// Schedule stores a message for delivery at msg.SendAt and returns it with its ID
+func (m *Manager) Schedule(msg models.ScheduledMessage) (models.ScheduledMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.messages) >= m.config.Scheduler.MaxPending {
+		return models.ScheduledMessage{}, ErrFull
+	}
+
+	now := m.clock.Now()
+	msg.ID = fmt.Sprintf("sched-%d-%d", now.UnixNano(), atomic.AddUint64(&m.seq, 1))
+	msg.CreatedAt = now
+	msg.Attempts = 0
+	msg.LastError = ""
+
+	previous := m.messages
+	m.insert(msg)
+	if err := m.save(); err != nil {
+		m.messages = previous
+		return models.ScheduledMessage{}, err
+	}
+
+	m.notify()
+	return msg, nil
+}
+
+// List returns the pending messages ordered by delivery time
+func (m *Manager) List() []models.ScheduledMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]models.ScheduledMessage, len(m.messages))
+	copy(list, m.messages)
+	return list
+}
+
+// Cancel removes a pending message
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	msg := m.messages[i]
+	m.messages = append(m.messages[:i:i], m.messages[i+1:]...)
+	if err := m.save(); err != nil {
+		m.insert(msg)
+		return err
+	}
+	return nil
+}
+
+// run sends due messages and sleeps until the next one, or until a message is scheduled
+func (m *Manager) run(ctx context.Context) {
+	defer m.wg.Done()
+
+	for {
+		m.sendDue(ctx)
+
+		var timer <-chan time.Time
+		if next, ok := m.nextSendAt(); ok {
+			timer = m.clock.After(next.Sub(m.clock.Now()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer:
+		case <-m.wake:
+		}
+	}
+}
+
+// sendDue delivers the messages whose time has come, oldest first
+func (m *Manager) sendDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		m.mu.Lock()
+		if len(m.messages) == 0 || m.messages[0].SendAt.After(m.clock.Now()) {
+			m.mu.Unlock()
+			return
+		}
+		msg := m.messages[0]
+		m.mu.Unlock()
+
+		// The message is only removed once sent, so a crash during delivery sends it again
+		err := m.deliver(msg)
+		m.finish(msg, err)
+	}
+}
+
+func (m *Manager) deliver(msg models.ScheduledMessage) error {
+	if msg.Room != "" {
+		_, err := m.xmppManager.SendMUCMessage(msg.Account, msg.Room, msg.Body, msg.Subject, msg.Mentions)
+		return err
+	}
+
+	_, err := m.xmppManager.SendMessage(msg.Account, msg.From, msg.To, msg.Body, msg.Type)
+	return err
+}
+
+// finish removes a delivered message, or reschedules a failed one until it runs out of attempts
+func (m *Manager) finish(msg models.ScheduledMessage, sendErr error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.index(msg.ID)
+	if i < 0 {
+		// Cancelled during delivery
+		return
+	}
+	m.messages = append(m.messages[:i:i], m.messages[i+1:]...)
+
+	switch {
+	case sendErr == nil:
+		m.logger.Info("Scheduled message sent",
+			zap.String("id", msg.ID),
+			zap.String("account", msg.Account),
+			zap.String("to", msg.To),
+			zap.String("room", msg.Room),
+		)
+	case msg.Attempts+1 >= maxAttempts:
+		m.logger.Error("Dropping scheduled message after failed attempts",
+			zap.String("id", msg.ID),
+			zap.Int("attempts", msg.Attempts+1),
+			zap.Error(sendErr),
+		)
+	default:
+		msg.Attempts++
+		msg.LastError = sendErr.Error()
+		msg.SendAt = m.clock.Now().Add(retryDelay)
+		m.insert(msg)
+		m.logger.Warn("Failed to send scheduled message, will retry",
+			zap.String("id", msg.ID),
+			zap.Int("attempts", msg.Attempts),
+			zap.Time("retry_at", msg.SendAt),
+			zap.Error(sendErr),
+		)
+	}
+
+	if err := m.save(); err != nil {
+		m.logger.Error("Failed to update scheduled messages", zap.Error(err))
+	}
+}
+
+func (m *Manager) nextSendAt() (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.messages) == 0 {
+		return time.Time{}, false
+	}
+	return m.messages[0].SendAt, true
+}
+
+// notify wakes the run loop to recompute the time of the next message
+func (m *Manager) notify() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// insert adds a message keeping the list sorted by SendAt. The caller must hold the lock.
+func (m *Manager) insert(msg models.ScheduledMessage) {
+	i := sort.Search(len(m.messages), func(i int) bool {
+		return m.messages[i].SendAt.After(msg.SendAt)
+	})
+	messages := make([]models.ScheduledMessage, 0, len(m.messages)+1)
+	messages = append(messages, m.messages[:i]...)
+	messages = append(messages, msg)
+	m.messages = append(messages, m.messages[i:]...)
+}
+
+// index returns the position of a message, or -1. The caller must hold the lock.
+func (m *Manager) index(id string) int {
+	for i, msg := range m.messages {
+		if msg.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// load reads the messages persisted by a previous run
+func (m *Manager) load() error {
+	data, err := os.ReadFile(m.config.Scheduler.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read scheduled messages: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	var messages []models.ScheduledMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("failed to parse scheduled messages %s: %w", m.config.Scheduler.Path, err)
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].SendAt.Before(messages[j].SendAt) })
+
+	m.mu.Lock()
+	m.messages = messages
+	m.mu.Unlock()
+	return nil
+}
+
+// save writes the messages to disk. The caller must hold the lock.
+func (m *Manager) save() error {
+	if err := fileutil.WriteJSONAtomic(m.config.Scheduler.Path, m.messages); err != nil {
+		return fmt.Errorf("failed to write scheduled messages: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeClock only moves when advanced
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	kept := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			kept = append(kept, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = kept
+}
+
+// fakeSender records delivered messages and fails while err is set
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []string
+	err  error
+}
+
+func (s *fakeSender) SendMessage(_, _, to, body, _ string) (models.SendResult, error) {
+	return s.record(to, body)
+}
+
+func (s *fakeSender) SendMUCMessage(_, room, body, _ string, _ []string) (models.SendResult, error) {
+	return s.record(room, body)
+}
+
+func (s *fakeSender) record(to, body string) (models.SendResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return models.SendResult{}, s.err
+	}
+	s.sent = append(s.sent, to+": "+body)
+	return models.SendResult{}, nil
+}
+
+func (s *fakeSender) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.sent...)
+}
+
+func newTestManager(t *testing.T, dir string, schedules ...config.ScheduleConfig) (*Manager, *fakeClock, *fakeSender) {
+	t.Helper()
+	return startTestManager(t, dir, newFakeClock(), schedules...)
+}
+
+func startTestManager(t *testing.T, dir string, clock *fakeClock, schedules ...config.ScheduleConfig) (*Manager, *fakeClock, *fakeSender) {
+	t.Helper()
+
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{
+		Path:          filepath.Join(dir, "scheduled.json"),
+		MaxPending:    10,
+		SchedulesPath: filepath.Join(dir, "schedules.json"),
+		Schedules:     schedules,
+	}}
+	sender := &fakeSender{}
+
+	m := NewManager(cfg, zap.NewNop(), sender)
+	m.clock = clock
+	require.NoError(t, m.Start(context.Background()))
+	t.Cleanup(func() { m.Stop() })
+
+	return m, clock, sender
+}
+
+func TestManager_SendsAtScheduledTime(t *testing.T) {
+	m, clock, sender := newTestManager(t, t.TempDir())
+
+	_, err := m.Schedule(models.ScheduledMessage{To: "bob@example.org", Body: "second", SendAt: clock.Now().Add(2 * time.Hour)})
+	require.NoError(t, err)
+	_, err = m.Schedule(models.ScheduledMessage{Room: "ops@conference.example.org", Body: "first", SendAt: clock.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	list := m.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, "first", list[0].Body)
+
+	// Let the run loop pick up the new messages before time moves
+	require.Eventually(t, func() bool { return pendingWaiters(clock) > 0 }, time.Second, time.Millisecond)
+	assert.Empty(t, sender.messages())
+
+	clock.Advance(time.Hour)
+	require.Eventually(t, func() bool { return len(sender.messages()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"ops@conference.example.org: first"}, sender.messages())
+
+	clock.Advance(time.Hour)
+	require.Eventually(t, func() bool { return len(sender.messages()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, "bob@example.org: second", sender.messages()[1])
+	assert.Empty(t, m.List())
+}
+
+func TestManager_Cancel(t *testing.T) {
+	m, clock, sender := newTestManager(t, t.TempDir())
+
+	msg, err := m.Schedule(models.ScheduledMessage{To: "bob@example.org", Body: "hi", SendAt: clock.Now().Add(time.Minute)})
+	require.NoError(t, err)
+
+	require.NoError(t, m.Cancel(msg.ID))
+	assert.ErrorIs(t, m.Cancel(msg.ID), ErrNotFound)
+	assert.Empty(t, m.List())
+
+	clock.Advance(time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, sender.messages())
+}
+
+func TestManager_Retry(t *testing.T) {
+	m, clock, sender := newTestManager(t, t.TempDir())
+
+	sender.mu.Lock()
+	sender.err = errors.New("not connected")
+	sender.mu.Unlock()
+
+	_, err := m.Schedule(models.ScheduledMessage{To: "bob@example.org", Body: "hi", SendAt: clock.Now()})
+	require.NoError(t, err)
+
+	// The failed attempt is recorded and the message moved back by the retry delay
+	require.Eventually(t, func() bool {
+		list := m.List()
+		return len(list) == 1 && list[0].Attempts == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, "not connected", m.List()[0].LastError)
+	assert.Equal(t, clock.Now().Add(retryDelay), m.List()[0].SendAt)
+
+	sender.mu.Lock()
+	sender.err = nil
+	sender.mu.Unlock()
+
+	clock.Advance(retryDelay)
+	require.Eventually(t, func() bool { return len(sender.messages()) == 1 }, time.Second, time.Millisecond)
+	assert.Empty(t, m.List())
+}
+
+func TestManager_Persistence(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+
+	m, clock, _ := newTestManager(t, dir)
+	msg, err := m.Schedule(models.ScheduledMessage{To: "bob@example.org", Body: "later", SendAt: clock.Now().Add(24 * time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, m.Stop())
+
+	restarted, _, _ := newTestManager(t, dir)
+	list := restarted.List()
+	require.Len(t, list, 1)
+	assert.Equal(t, msg.ID, list[0].ID)
+	assert.Equal(t, "later", list[0].Body)
+	assert.True(t, msg.SendAt.Equal(list[0].SendAt))
+}
+
+func TestManager_MaxPending(t *testing.T) {
+	m, clock, _ := newTestManager(t, t.TempDir())
+
+	for i := 0; i < 10; i++ {
+		_, err := m.Schedule(models.ScheduledMessage{To: "bob@example.org", Body: "hi", SendAt: clock.Now().Add(time.Hour)})
+		require.NoError(t, err)
+	}
+	_, err := m.Schedule(models.ScheduledMessage{To: "bob@example.org", Body: "hi", SendAt: clock.Now().Add(time.Hour)})
+	assert.ErrorIs(t, err, ErrFull)
+}
+
+func pendingWaiters(c *fakeClock) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
@@ -0,0 +1,452 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/cron"
+	"jabber-bot/internal/fileutil"
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// fetchTimeout bounds the request for the content of a schedule with a URL
+	fetchTimeout = 10 * time.Second
+
+	// maxFetchSize is the largest response accepted from a schedule URL
+	maxFetchSize = 1 << 20
+
+	// Sources of schedules
+	sourceConfig = "config"
+	sourceAPI    = "api"
+
+	// Results of schedule runs
+	statusSent    = "sent"
+	statusFailed  = "failed"
+	statusSkipped = "skipped"
+)
+
+// ErrScheduleNotFound is returned for a schedule that does not exist
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// ErrInvalidSchedule is returned for a schedule with an invalid definition
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ErrConfigSchedule is returned when changing a schedule defined in the configuration file
+var ErrConfigSchedule = errors.New("schedule is defined in the configuration file")
+
+// Renderer renders message templates
+type Renderer interface {
+	Render(name string, data map[string]interface{}) (string, error)
+}
+
+// SetRenderer sets the templates used by schedules with a template
+func (m *Manager) SetRenderer(renderer Renderer) {
+	m.renderer = renderer
+}
+
+// AddSchedule creates a schedule or replaces one created through the API
+func (m *Manager) AddSchedule(req models.ScheduleRequest) (models.Schedule, error) {
+	if err := scheduleConfig(req).Validate(); err != nil {
+		return models.Schedule{}, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	m.schedulesMu.Lock()
+	defer m.schedulesMu.Unlock()
+
+	schedule := &models.Schedule{ScheduleRequest: req, Source: sourceAPI}
+	if existing, ok := m.schedules[req.Name]; ok {
+		if existing.Source == sourceConfig {
+			return models.Schedule{}, ErrConfigSchedule
+		}
+		schedule.LastRun = existing.LastRun
+		schedule.LastStatus = existing.LastStatus
+		schedule.LastError = existing.LastError
+	}
+	schedule.NextRun = nextRun(req, m.clock.Now())
+
+	previous := m.schedules[req.Name]
+	m.schedules[req.Name] = schedule
+	if err := m.saveSchedules(); err != nil {
+		if previous != nil {
+			m.schedules[req.Name] = previous
+		} else {
+			delete(m.schedules, req.Name)
+		}
+		return models.Schedule{}, err
+	}
+
+	select {
+	case m.schedulesWake <- struct{}{}:
+	default:
+	}
+	return *schedule, nil
+}
+
+// Schedules returns all schedules sorted by name
+func (m *Manager) Schedules() []models.Schedule {
+	m.schedulesMu.Lock()
+	defer m.schedulesMu.Unlock()
+
+	list := make([]models.Schedule, 0, len(m.schedules))
+	for _, schedule := range m.schedules {
+		list = append(list, *schedule)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// GetSchedule returns a schedule by name
+func (m *Manager) GetSchedule(name string) (models.Schedule, error) {
+	m.schedulesMu.Lock()
+	defer m.schedulesMu.Unlock()
+
+	schedule, ok := m.schedules[name]
+	if !ok {
+		return models.Schedule{}, ErrScheduleNotFound
+	}
+	return *schedule, nil
+}
+
+// DeleteSchedule removes a schedule created through the API
+func (m *Manager) DeleteSchedule(name string) error {
+	m.schedulesMu.Lock()
+	defer m.schedulesMu.Unlock()
+
+	schedule, ok := m.schedules[name]
+	if !ok {
+		return ErrScheduleNotFound
+	}
+	if schedule.Source == sourceConfig {
+		return ErrConfigSchedule
+	}
+
+	delete(m.schedules, name)
+	if err := m.saveSchedules(); err != nil {
+		m.schedules[name] = schedule
+		return err
+	}
+	return nil
+}
+
+// runSchedules runs schedules at their next run time
+func (m *Manager) runSchedules(ctx context.Context) {
+	defer m.wg.Done()
+
+	for {
+		m.runDue(ctx)
+
+		var timer <-chan time.Time
+		if next, ok := m.nextScheduleRun(); ok {
+			timer = m.clock.After(next.Sub(m.clock.Now()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer:
+		case <-m.schedulesWake:
+		}
+	}
+}
+
+// runDue runs the schedules whose next run has come
+func (m *Manager) runDue(ctx context.Context) {
+	now := m.clock.Now()
+
+	m.schedulesMu.Lock()
+	var due []*models.Schedule
+	for _, schedule := range m.schedules {
+		if !schedule.NextRun.IsZero() && !schedule.NextRun.After(now) {
+			due = append(due, schedule)
+		}
+	}
+	m.schedulesMu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].NextRun.Before(due[j].NextRun) })
+	for _, schedule := range due {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := m.runSchedule(ctx, schedule.ScheduleRequest)
+		m.finishRun(schedule, now, err)
+	}
+}
+
+// runSchedule builds the message of a schedule and sends it to every target
+func (m *Manager) runSchedule(ctx context.Context, req models.ScheduleRequest) error {
+	body, err := m.scheduleBody(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, jid := range req.JIDs {
+		if _, err := m.xmppManager.SendMessage(req.Account, "", jid, body, "chat"); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", jid, err))
+		}
+	}
+	for _, room := range req.Rooms {
+		if _, err := m.xmppManager.SendMUCMessage(req.Account, room, body, "", nil); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", room, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// scheduleBody returns the static body, the fetched content or the rendered template. Templates
+// get the fetched content as {{.content}} and, for a JSON object, its fields as variables.
+func (m *Manager) scheduleBody(ctx context.Context, req models.ScheduleRequest) (string, error) {
+	if req.Body != "" {
+		return req.Body, nil
+	}
+
+	var content string
+	if req.URL != "" {
+		var err error
+		if content, err = m.fetch(ctx, req.URL); err != nil {
+			return "", err
+		}
+		if req.Template == "" {
+			if strings.TrimSpace(content) == "" {
+				return "", fmt.Errorf("%s returned no content", req.URL)
+			}
+			return content, nil
+		}
+	}
+
+	if m.renderer == nil {
+		return "", fmt.Errorf("templates are not configured")
+	}
+
+	data := make(map[string]interface{}, len(req.Data)+1)
+	for key, value := range req.Data {
+		data[key] = value
+	}
+	if req.URL != "" {
+		var fields map[string]interface{}
+		if json.Unmarshal([]byte(content), &fields) == nil {
+			for key, value := range fields {
+				data[key] = value
+			}
+		}
+		data["content"] = content
+	}
+
+	return m.renderer.Render(req.Template, data)
+}
+
+// fetch returns the body of a GET request to url
+func (m *Manager) fetch(ctx context.Context, url string) (string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+
+	resp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to fetch %s: status %d", url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	return string(data), nil
+}
+
+// finishRun records the result of a run and computes the next one
+func (m *Manager) finishRun(schedule *models.Schedule, ranAt time.Time, runErr error) {
+	m.schedulesMu.Lock()
+	defer m.schedulesMu.Unlock()
+
+	// Deleted or replaced during the run
+	if m.schedules[schedule.Name] != schedule {
+		return
+	}
+
+	schedule.LastRun = &ranAt
+	schedule.LastStatus = statusSent
+	schedule.LastError = ""
+	if runErr != nil {
+		schedule.LastStatus = statusFailed
+		schedule.LastError = runErr.Error()
+	}
+	schedule.NextRun = nextRun(schedule.ScheduleRequest, m.clock.Now())
+
+	if runErr != nil {
+		m.logger.Error("Schedule run failed",
+			zap.String("schedule", schedule.Name),
+			zap.Time("next_run", schedule.NextRun),
+			zap.Error(runErr),
+		)
+	} else {
+		m.logger.Info("Schedule run",
+			zap.String("schedule", schedule.Name),
+			zap.Time("next_run", schedule.NextRun),
+		)
+	}
+
+	if err := m.saveSchedules(); err != nil {
+		m.logger.Error("Failed to update schedules", zap.Error(err))
+	}
+}
+
+func (m *Manager) nextScheduleRun() (time.Time, bool) {
+	m.schedulesMu.Lock()
+	defer m.schedulesMu.Unlock()
+
+	var next time.Time
+	for _, schedule := range m.schedules {
+		if !schedule.NextRun.IsZero() && (next.IsZero() || schedule.NextRun.Before(next)) {
+			next = schedule.NextRun
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// loadSchedules combines the schedules of the configuration with the schedules created through
+// the API and the run state persisted by a previous run, then handles runs missed while the
+// bot was stopped
+func (m *Manager) loadSchedules() error {
+	var persisted []models.Schedule
+	data, err := os.ReadFile(m.config.Scheduler.SchedulesPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read schedules: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &persisted); err != nil {
+			return fmt.Errorf("failed to parse schedules %s: %w", m.config.Scheduler.SchedulesPath, err)
+		}
+	}
+
+	previous := make(map[string]models.Schedule, len(persisted))
+	for _, schedule := range persisted {
+		previous[schedule.Name] = schedule
+	}
+
+	m.schedulesMu.Lock()
+	defer m.schedulesMu.Unlock()
+
+	for _, cfg := range m.config.Scheduler.Schedules {
+		schedule := &models.Schedule{ScheduleRequest: scheduleRequest(cfg), Source: sourceConfig}
+		// The state is kept unless the timing of the schedule changed
+		if old, ok := previous[cfg.Name]; ok && old.Cron == cfg.Cron && old.Timezone == cfg.Timezone {
+			schedule.NextRun = old.NextRun
+			schedule.LastRun = old.LastRun
+			schedule.LastStatus = old.LastStatus
+			schedule.LastError = old.LastError
+		}
+		m.schedules[cfg.Name] = schedule
+	}
+	for _, old := range persisted {
+		if _, ok := m.schedules[old.Name]; ok || old.Source != sourceAPI {
+			// A schedule removed from the configuration, or shadowed by it
+			continue
+		}
+		schedule := old
+		m.schedules[old.Name] = &schedule
+	}
+
+	now := m.clock.Now()
+	for _, schedule := range m.schedules {
+		switch {
+		case schedule.NextRun.IsZero():
+			schedule.NextRun = nextRun(schedule.ScheduleRequest, now)
+		case !schedule.NextRun.After(now) && schedule.MissedRuns == config.MissedRunsOnce:
+			m.logger.Info("Running schedule missed while stopped",
+				zap.String("schedule", schedule.Name),
+				zap.Time("missed_run", schedule.NextRun),
+			)
+			schedule.NextRun = now
+		case !schedule.NextRun.After(now):
+			m.logger.Warn("Skipping schedule runs missed while stopped",
+				zap.String("schedule", schedule.Name),
+				zap.Time("missed_run", schedule.NextRun),
+			)
+			schedule.LastStatus = statusSkipped
+			schedule.LastError = ""
+			schedule.NextRun = nextRun(schedule.ScheduleRequest, now)
+		}
+	}
+
+	return m.saveSchedules()
+}
+
+// saveSchedules persists the schedules and their state. The caller must hold schedulesMu.
+func (m *Manager) saveSchedules() error {
+	list := make([]models.Schedule, 0, len(m.schedules))
+	for _, schedule := range m.schedules {
+		list = append(list, *schedule)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	if err := fileutil.WriteJSONAtomic(m.config.Scheduler.SchedulesPath, list); err != nil {
+		return fmt.Errorf("failed to write schedules: %w", err)
+	}
+	return nil
+}
+
+// nextRun returns the first run of a schedule after t, or the zero time if it never runs
+func nextRun(req models.ScheduleRequest, t time.Time) time.Time {
+	// Both were checked by Validate
+	schedule, err := cron.Parse(req.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+	loc, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		return time.Time{}
+	}
+	return schedule.Next(t.In(loc))
+}
+
+func scheduleConfig(req models.ScheduleRequest) config.ScheduleConfig {
+	return config.ScheduleConfig{
+		Name:       req.Name,
+		Cron:       req.Cron,
+		Timezone:   req.Timezone,
+		JIDs:       req.JIDs,
+		Rooms:      req.Rooms,
+		Body:       req.Body,
+		Template:   req.Template,
+		Data:       req.Data,
+		URL:        req.URL,
+		Account:    req.Account,
+		MissedRuns: req.MissedRuns,
+	}
+}
+
+func scheduleRequest(cfg config.ScheduleConfig) models.ScheduleRequest {
+	return models.ScheduleRequest{
+		Name:       cfg.Name,
+		Cron:       cfg.Cron,
+		Timezone:   cfg.Timezone,
+		JIDs:       cfg.JIDs,
+		Rooms:      cfg.Rooms,
+		Body:       cfg.Body,
+		Template:   cfg.Template,
+		Data:       cfg.Data,
+		URL:        cfg.URL,
+		Account:    cfg.Account,
+		MissedRuns: cfg.MissedRuns,
+	}
+}
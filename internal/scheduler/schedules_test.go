@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/templates"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedules_RunOnCron(t *testing.T) {
+	m, clock, sender := newTestManager(t, t.TempDir(), config.ScheduleConfig{
+		Name:  "standup",
+		Cron:  "*/5 * * * *",
+		JIDs:  []string{"bob@example.org"},
+		Rooms: []string{"team@conference.example.org"},
+		Body:  "Standup!",
+	})
+
+	schedule, err := m.GetSchedule("standup")
+	require.NoError(t, err)
+	assert.Equal(t, "config", schedule.Source)
+	assert.Equal(t, clock.Now().Add(5*time.Minute), schedule.NextRun)
+	assert.Nil(t, schedule.LastRun)
+
+	require.Eventually(t, func() bool { return pendingWaiters(clock) > 0 }, time.Second, time.Millisecond)
+	clock.Advance(5 * time.Minute)
+	require.Eventually(t, func() bool { return len(sender.messages()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"bob@example.org: Standup!", "team@conference.example.org: Standup!"}, sender.messages())
+
+	require.Eventually(t, func() bool {
+		schedule, _ := m.GetSchedule("standup")
+		return schedule.LastRun != nil
+	}, time.Second, time.Millisecond)
+	schedule, err = m.GetSchedule("standup")
+	require.NoError(t, err)
+	assert.Equal(t, clock.Now(), *schedule.LastRun)
+	assert.Equal(t, "sent", schedule.LastStatus)
+	assert.Equal(t, clock.Now().Add(5*time.Minute), schedule.NextRun)
+}
+
+func TestSchedules_MissedRuns(t *testing.T) {
+	dir := t.TempDir()
+	schedules := []config.ScheduleConfig{
+		{Name: "summary", Cron: "0 9 * * *", JIDs: []string{"oncall@example.org"}, Body: "Daily summary", MissedRuns: config.MissedRunsOnce},
+		{Name: "standup", Cron: "0 9 * * *", JIDs: []string{"team@example.org"}, Body: "Standup!"},
+	}
+
+	m, _, _ := newTestManager(t, dir, schedules...)
+	require.NoError(t, m.Stop())
+
+	// Three runs are missed while the bot is stopped
+	clock := newFakeClock()
+	clock.Advance(3*24*time.Hour + time.Hour)
+	restarted, _, sender := startTestManager(t, dir, clock, schedules...)
+
+	require.Eventually(t, func() bool { return len(sender.messages()) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, []string{"oncall@example.org: Daily summary"}, sender.messages())
+
+	standup, err := restarted.GetSchedule("standup")
+	require.NoError(t, err)
+	assert.Equal(t, "skipped", standup.LastStatus)
+	assert.Equal(t, time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC), standup.NextRun)
+}
+
+func TestSchedules_URLAndTemplate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "Alice", "alerts": 3}`))
+	}))
+	defer server.Close()
+
+	store, err := templates.NewStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, store.Put("oncall", "On call today: {{.name}} ({{.alerts}} open alerts)"))
+
+	m, clock, sender := newTestManager(t, t.TempDir())
+	m.SetRenderer(store)
+
+	_, err = m.AddSchedule(models.ScheduleRequest{
+		Name:     "oncall",
+		Cron:     "@hourly",
+		JIDs:     []string{"team@example.org"},
+		Template: "oncall",
+		URL:      server.URL,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return pendingWaiters(clock) > 0 }, time.Second, time.Millisecond)
+	clock.Advance(time.Hour)
+	require.Eventually(t, func() bool { return len(sender.messages()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, "team@example.org: On call today: Alice (3 open alerts)", sender.messages()[0])
+}
+
+func TestSchedules_FetchFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	m, clock, sender := newTestManager(t, t.TempDir(), config.ScheduleConfig{
+		Name: "report",
+		Cron: "0 * * * *",
+		JIDs: []string{"team@example.org"},
+		URL:  server.URL,
+	})
+
+	require.Eventually(t, func() bool { return pendingWaiters(clock) > 0 }, time.Second, time.Millisecond)
+	clock.Advance(time.Hour)
+	require.Eventually(t, func() bool {
+		schedule, _ := m.GetSchedule("report")
+		return schedule.LastStatus == "failed"
+	}, time.Second, time.Millisecond)
+
+	schedule, err := m.GetSchedule("report")
+	require.NoError(t, err)
+	assert.Contains(t, schedule.LastError, "status 502")
+	assert.Empty(t, sender.messages())
+}
+
+func TestSchedules_AddAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	standup := config.ScheduleConfig{Name: "standup", Cron: "0 9 * * 1-5", Timezone: "Europe/Berlin", JIDs: []string{"team@example.org"}, Body: "Standup!"}
+
+	m, _, _ := newTestManager(t, dir, standup)
+
+	_, err := m.AddSchedule(models.ScheduleRequest{Name: "bad", Cron: "0 25 * * *", JIDs: []string{"team@example.org"}, Body: "x"})
+	assert.ErrorIs(t, err, ErrInvalidSchedule)
+	_, err = m.AddSchedule(models.ScheduleRequest{Name: "standup", Cron: "@daily", JIDs: []string{"team@example.org"}, Body: "x"})
+	assert.ErrorIs(t, err, ErrConfigSchedule)
+
+	created, err := m.AddSchedule(models.ScheduleRequest{Name: "retro", Cron: "0 15 * * fri", Timezone: "Europe/Berlin", Rooms: []string{"team@conference.example.org"}, Body: "Retro time"})
+	require.NoError(t, err)
+	assert.Equal(t, "api", created.Source)
+	// Friday 15:00 in Berlin is 14:00 UTC in March
+	assert.Equal(t, time.Date(2026, 3, 6, 14, 0, 0, 0, time.UTC), created.NextRun.UTC())
+	require.NoError(t, m.Stop())
+
+	// Schedules created through the API survive a restart
+	restarted, _, _ := newTestManager(t, dir, standup)
+	list := restarted.Schedules()
+	require.Len(t, list, 2)
+	assert.Equal(t, "retro", list[0].Name)
+	assert.Equal(t, "standup", list[1].Name)
+
+	assert.ErrorIs(t, restarted.DeleteSchedule("standup"), ErrConfigSchedule)
+	require.NoError(t, restarted.DeleteSchedule("retro"))
+	assert.ErrorIs(t, restarted.DeleteSchedule("retro"), ErrScheduleNotFound)
+	_, err = restarted.GetSchedule("retro")
+	assert.ErrorIs(t, err, ErrScheduleNotFound)
+}
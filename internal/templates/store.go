@@ -0,0 +1,211 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// fileExtension is the extension of template files in the templates directory
+const fileExtension = ".tmpl"
+
+// ErrNotFound is returned for a template that does not exist
+var ErrNotFound = errors.New("template not found")
+
+// ErrInvalidName is returned for a template name that cannot be used as a file name
+var ErrInvalidName = errors.New("invalid template name")
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// errorPosition matches the position text/template puts in its errors: "template: name:3:14: ..."
+// for execution errors and "template: name:3: ..." for parse errors
+var errorPosition = regexp.MustCompile(`^template: [^:]*:(\d+)(?::(\d+))?: (.*)$`)
+
+// Error is a template that failed to parse or render, with the position of the failure.
+// Column is 0 when unknown, which is always the case for parse errors.
+type Error struct {
+	Template string
+	Line     int
+	Column   int
+	Message  string
+}
+
+func (e *Error) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("template %s:%d:%d: %s", e.Template, e.Line, e.Column, e.Message)
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("template %s:%d: %s", e.Template, e.Line, e.Message)
+	}
+	return fmt.Sprintf("template %s: %s", e.Template, e.Message)
+}
+
+// newError converts a text/template error into an Error
+func newError(name string, err error) *Error {
+	match := errorPosition.FindStringSubmatch(err.Error())
+	if match == nil {
+		return &Error{Template: name, Message: err.Error()}
+	}
+
+	line, _ := strconv.Atoi(match[1])
+	column, _ := strconv.Atoi(match[2])
+	return &Error{Template: name, Line: line, Column: column, Message: match[3]}
+}
+
+// Template is a named message template
+type Template struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+// Store holds the message templates of a directory, one <name>.tmpl file per template
+type Store struct {
+	mu        sync.RWMutex
+	dir       string
+	sources   map[string]string
+	templates map[string]*template.Template
+}
+
+// NewStore loads the templates of a directory. A missing directory is created on the first
+// write. Templates that fail to parse are skipped and reported in the returned error, so the
+// store is usable even when the error is not nil.
+func NewStore(dir string) (*Store, error) {
+	s := &Store{
+		dir:       dir,
+		sources:   make(map[string]string),
+		templates: make(map[string]*template.Template),
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+fileExtension))
+	if err != nil {
+		return s, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	var errs []error
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), fileExtension)
+		if !validName.MatchString(name) {
+			continue
+		}
+
+		source, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read template %s: %w", name, err))
+			continue
+		}
+
+		tmpl, err := parse(name, string(source))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.sources[name] = string(source)
+		s.templates[name] = tmpl
+	}
+
+	return s, errors.Join(errs...)
+}
+
+func parse(name, source string) (*template.Template, error) {
+	// Missing variables are errors rather than "<no value>" in a sent message
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, newError(name, err)
+	}
+	return tmpl, nil
+}
+
+// List returns all templates sorted by name
+func (s *Store) List() []Template {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Template, 0, len(s.sources))
+	for name, source := range s.sources {
+		list = append(list, Template{Name: name, Source: source})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Get returns a template by name
+func (s *Store) Get(name string) (Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	source, ok := s.sources[name]
+	if !ok {
+		return Template{}, ErrNotFound
+	}
+	return Template{Name: name, Source: source}, nil
+}
+
+// Put creates or replaces a template and saves it to the templates directory
+func (s *Store) Put(name, source string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("%w %q: use letters, digits, - and _", ErrInvalidName, name)
+	}
+
+	tmpl, err := parse(name, source)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create templates directory: %w", err)
+	}
+	if err := os.WriteFile(s.path(name), []byte(source), 0644); err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+
+	s.sources[name] = source
+	s.templates[name] = tmpl
+	return nil
+}
+
+// Delete removes a template and its file
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sources[name]; !ok {
+		return ErrNotFound
+	}
+	if err := os.Remove(s.path(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	delete(s.sources, name)
+	delete(s.templates, name)
+	return nil
+}
+
+// Render executes a template with the given data
+func (s *Store) Render(name string, data map[string]interface{}) (string, error) {
+	s.mu.RLock()
+	tmpl, ok := s.templates[name]
+	s.mu.RUnlock()
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", newError(name, err)
+	}
+	return out.String(), nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+fileExtension)
+}
@@ -0,0 +1,74 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_PutRenderDelete(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "templates")
+	store, err := NewStore(dir)
+	require.NoError(t, err)
+	assert.Empty(t, store.List())
+
+	require.NoError(t, store.Put("alert", "[{{.severity}}] {{.host}}: {{.summary}}"))
+
+	body, err := store.Render("alert", map[string]interface{}{
+		"severity": "CRITICAL",
+		"host":     "db1",
+		"summary":  "disk full",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "[CRITICAL] db1: disk full", body)
+
+	// Templates are saved to the directory and loaded on start
+	store, err = NewStore(dir)
+	require.NoError(t, err)
+	tmpl, err := store.Get("alert")
+	require.NoError(t, err)
+	assert.Equal(t, "[{{.severity}}] {{.host}}: {{.summary}}", tmpl.Source)
+
+	require.NoError(t, store.Delete("alert"))
+	assert.ErrorIs(t, store.Delete("alert"), ErrNotFound)
+	_, err = store.Render("alert", nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = os.Stat(filepath.Join(dir, "alert.tmpl"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestStore_Errors(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	err = store.Put("broken", "line one\n{{if .x}}unterminated")
+	var tmplErr *Error
+	require.ErrorAs(t, err, &tmplErr)
+	assert.Equal(t, "broken", tmplErr.Template)
+	assert.Equal(t, 2, tmplErr.Line)
+	assert.Equal(t, 0, tmplErr.Column)
+
+	assert.ErrorContains(t, store.Put("../escape", "x"), "invalid template name")
+
+	// Missing variables fail with their position
+	require.NoError(t, store.Put("alert", "Host:\n  {{.host}}"))
+	_, err = store.Render("alert", map[string]interface{}{})
+	require.ErrorAs(t, err, &tmplErr)
+	assert.Equal(t, 2, tmplErr.Line)
+	assert.Equal(t, 4, tmplErr.Column)
+	assert.Contains(t, tmplErr.Message, `map has no entry for key "host"`)
+	assert.Equal(t, `template alert:2:4: `+tmplErr.Message, err.Error())
+}
+
+func TestNewStore_SkipsBrokenFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ok.tmpl"), []byte("{{.x}}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.tmpl"), []byte("{{.x"), 0644))
+
+	store, err := NewStore(dir)
+	assert.ErrorContains(t, err, "template bad:1")
+	assert.Equal(t, []Template{{Name: "ok", Source: "{{.x}}"}}, store.List())
+}
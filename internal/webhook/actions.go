@@ -0,0 +1,147 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/xmpp"
+
+	"go.uber.org/zap"
+)
+
+// maxResponseSize limits how much of a webhook response is read for reply actions
+const maxResponseSize = 1 << 20
+
+// ActionHandler executes an action returned by the webhook for a message
+type ActionHandler func(msg models.Message, action models.WebhookAction) error
+
+// SetActionHandler sets the handler for the actions returned by the webhook when
+// webhook.reply_actions is enabled
+func (s *Service) SetActionHandler(handler ActionHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onAction = handler
+}
+
+// runActions executes the actions in a webhook response, in order. A failed action is logged
+// and counted but does not stop the ones after it.
+func (s *Service) runActions(msg models.Message, body []byte) {
+	actions, err := parseActions(body)
+	if err != nil {
+		s.updateActionStats(err)
+		s.logger.Error("Invalid webhook reply actions",
+			zap.Error(err),
+			zap.String("from", msg.From),
+			zap.String("message_id", msg.ID),
+		)
+		return
+	}
+
+	s.mu.RLock()
+	handler := s.onAction
+	s.mu.RUnlock()
+	if handler == nil || len(actions) == 0 {
+		return
+	}
+
+	for i, action := range actions {
+		err := handler(msg, action)
+		s.updateActionStats(err)
+		if err != nil {
+			s.logger.Error("Webhook reply action failed",
+				zap.Int("index", i),
+				zap.String("action", action.Type),
+				zap.Error(err),
+				zap.String("from", msg.From),
+				zap.String("message_id", msg.ID),
+			)
+			continue
+		}
+
+		s.logger.Debug("Webhook reply action executed",
+			zap.Int("index", i),
+			zap.String("action", action.Type),
+			zap.String("from", msg.From),
+		)
+	}
+}
+
+// parseActions reads the actions from a webhook response. Webhooks that answer with an empty
+// body or anything other than a JSON object have no actions.
+func parseActions(body []byte) ([]models.WebhookAction, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '{' {
+		return nil, nil
+	}
+
+	var response models.WebhookResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook response: %w", err)
+	}
+
+	return response.Actions, nil
+}
+
+// executeAction executes a reply action in the conversation of the message it answers, with
+// the account that received the message
+func (m *Manager) executeAction(msg models.Message, action models.WebhookAction) error {
+	to, _ := xmpp.ReplyTarget(msg)
+
+	switch action.Type {
+	case "reply":
+		if strings.TrimSpace(action.Body) == "" {
+			return fmt.Errorf("reply action requires a body")
+		}
+		_, err := m.xmppManager.SendReply(msg, action.Body)
+		return err
+
+	case "react":
+		if action.Emoji == "" {
+			return fmt.Errorf("react action requires an emoji")
+		}
+		return m.xmppManager.SendReaction(msg, []string{action.Emoji})
+
+	case "chat_state":
+		switch xmpp.ChatState(action.State) {
+		case xmpp.ChatStateActive, xmpp.ChatStateComposing, xmpp.ChatStatePaused, xmpp.ChatStateInactive, xmpp.ChatStateGone:
+		default:
+			return fmt.Errorf("invalid chat state %q: must be one of active, composing, paused, inactive, gone", action.State)
+		}
+		return m.xmppManager.SendChatState(msg.Account, to, xmpp.ChatState(action.State))
+
+	case "send_file":
+		if action.URL == "" {
+			return fmt.Errorf("send_file action requires a url")
+		}
+		fileName := action.FileName
+		if fileName == "" {
+			fileName = path.Base(action.URL)
+		}
+		return m.xmppManager.SendFile(msg.Account, to, action.URL, fileName, action.FileType)
+
+	case "send_to":
+		if strings.TrimSpace(action.Body) == "" {
+			return fmt.Errorf("send_to action requires a body")
+		}
+		if (action.To == "") == (action.Room == "") {
+			return fmt.Errorf("send_to action requires exactly one of to and room")
+		}
+		var err error
+		if action.Room != "" {
+			_, err = m.xmppManager.SendMUCMessage(msg.Account, action.Room, action.Body, "", nil)
+		} else {
+			_, err = m.xmppManager.SendMessage(msg.Account, "", action.To, action.Body, "chat")
+		}
+		return err
+
+	case "set_presence":
+		return m.xmppManager.SetPresence(msg.Account, action.Show, action.Status)
+
+	default:
+		return fmt.Errorf("unknown action type %q", action.Type)
+	}
+}
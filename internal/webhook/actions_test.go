@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+	"jabber-bot/internal/xmpp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestManager_ReplyActions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"actions": [
+			{"type": "chat_state", "state": "composing"},
+			{"type": "react", "emoji": "👀"},
+			{"type": "reply", "body": "Deploying now"},
+			{"type": "send_to", "room": "ops@conference.example.com", "body": "Deploy requested by alice"},
+			{"type": "send_file", "url": "https://files.example.com/report.pdf"},
+			{"type": "set_presence", "show": "dnd", "status": "Deploying"},
+			{"type": "dance"}
+		]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			URL:           server.URL,
+			Timeout:       5 * time.Second,
+			RetryAttempts: 1,
+			ReplyActions:  true,
+		},
+	}
+
+	msg := models.Message{
+		ID:     "msg-1",
+		From:   "deploys@conference.example.com/alice",
+		To:     "bot@example.com/res",
+		Body:   "deploy api",
+		Type:   "groupchat",
+		Thread: "deploy-42",
+	}
+
+	xmppManager := &MockXMPPManager{}
+	xmppManager.On("SendChatState", "", "deploys@conference.example.com", xmpp.ChatStateComposing).Return(nil)
+	xmppManager.On("SendReaction", msg, []string{"👀"}).Return(nil)
+	xmppManager.On("SendReply", msg, "Deploying now").Return(nil)
+	xmppManager.On("SendMUCMessage", "", "ops@conference.example.com", "Deploy requested by alice", "", []string(nil)).Return(nil)
+	xmppManager.On("SendFile", "", "deploys@conference.example.com", "https://files.example.com/report.pdf", "report.pdf", "").
+		Return(errors.New("upload failed"))
+	xmppManager.On("SetPresence", "", "dnd", "Deploying").Return(nil)
+
+	manager := NewManager(cfg, zaptest.NewLogger(t), xmppManager)
+	manager.GetService().sendWebhook(context.Background(), msg)
+
+	xmppManager.AssertExpectations(t)
+
+	stats := manager.GetService().GetStats()
+	assert.Equal(t, int64(1), stats.TotalSent)
+	assert.Equal(t, int64(5), stats.ActionsExecuted)
+	assert.Equal(t, int64(2), stats.ActionsFailed)
+	assert.Equal(t, `unknown action type "dance"`, stats.LastActionError)
+}
+
+func TestManager_ReplyActionsDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"actions": [{"type": "reply", "body": "Hello"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			URL:           server.URL,
+			Timeout:       5 * time.Second,
+			RetryAttempts: 1,
+		},
+	}
+
+	xmppManager := &MockXMPPManager{}
+	manager := NewManager(cfg, zaptest.NewLogger(t), xmppManager)
+	manager.GetService().sendWebhook(context.Background(), models.Message{From: "bob@example.com/phone", Body: "Hi"})
+
+	xmppManager.AssertNotCalled(t, "SendReply")
+	assert.Equal(t, int64(0), manager.GetService().GetStats().ActionsExecuted)
+}
+
+func TestParseActions(t *testing.T) {
+	actions, err := parseActions([]byte(` {"actions": [{"type": "reply", "body": "Hi"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []models.WebhookAction{{Type: "reply", Body: "Hi"}}, actions)
+
+	for _, body := range []string{"", "OK", `["reply"]`, `{"status": "ok"}`} {
+		actions, err := parseActions([]byte(body))
+		assert.NoError(t, err, body)
+		assert.Empty(t, actions, body)
+	}
+
+	_, err = parseActions([]byte(`{"actions": "reply"}`))
+	assert.Error(t, err)
+}
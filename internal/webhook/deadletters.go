@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"jabber-bot/internal/fileutil"
+	"jabber-bot/internal/models"
+)
+
+// ErrDeadLetterNotFound is returned for unknown dead letter IDs
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// deadLetters keeps the deliveries that failed after all retries, oldest first, optionally
+// persisted to a file. When it is full the oldest dead letters are dropped.
+type deadLetters struct {
+	mu      sync.Mutex
+	letters []models.DeadLetter
+	path    string
+	maxSize int
+}
+
+// newDeadLetters creates a dead letter store persisted to path, or kept in memory when path is
+// empty
+func newDeadLetters(path string, maxSize int) *deadLetters {
+	return &deadLetters{path: path, maxSize: maxSize}
+}
+
+// load reads the dead letters persisted by a previous run
+func (d *deadLetters) load() error {
+	if d.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(d.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read webhook dead letters: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	var letters []models.DeadLetter
+	if err := json.Unmarshal(data, &letters); err != nil {
+		return fmt.Errorf("failed to parse webhook dead letters %s: %w", d.path, err)
+	}
+
+	d.mu.Lock()
+	d.letters = letters
+	d.mu.Unlock()
+	return nil
+}
+
+// add stores a dead letter and assigns its ID
+func (d *deadLetters) add(letter models.DeadLetter) (models.DeadLetter, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return models.DeadLetter{}, fmt.Errorf("failed to generate dead letter ID: %w", err)
+	}
+	letter.ID = "dl-" + id
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	previous := d.letters
+	d.letters = append(d.letters, letter)
+	if d.maxSize > 0 && len(d.letters) > d.maxSize {
+		d.letters = d.letters[len(d.letters)-d.maxSize:]
+	}
+
+	if err := d.save(); err != nil {
+		d.letters = previous
+		return models.DeadLetter{}, err
+	}
+	return letter, nil
+}
+
+// list returns the dead letters, oldest first
+func (d *deadLetters) list() []models.DeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	letters := make([]models.DeadLetter, len(d.letters))
+	copy(letters, d.letters)
+	return letters
+}
+
+// get returns the dead letters with the given IDs, or all of them when no IDs are given
+func (d *deadLetters) get(ids []string) ([]models.DeadLetter, error) {
+	if len(ids) == 0 {
+		return d.list(), nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	letters := make([]models.DeadLetter, 0, len(ids))
+	for _, id := range ids {
+		i := d.index(id)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+		}
+		letters = append(letters, d.letters[i])
+	}
+	return letters, nil
+}
+
+// remove deletes the dead letters with the given IDs, or all of them when no IDs are given,
+// and returns how many were deleted. Nothing is deleted when an ID is unknown.
+func (d *deadLetters) remove(ids []string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(ids) == 0 {
+		removed := len(d.letters)
+		previous := d.letters
+		d.letters = nil
+		if err := d.save(); err != nil {
+			d.letters = previous
+			return 0, err
+		}
+		return removed, nil
+	}
+
+	drop := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if d.index(id) < 0 {
+			return 0, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+		}
+		drop[id] = true
+	}
+
+	previous := d.letters
+	kept := make([]models.DeadLetter, 0, len(d.letters))
+	for _, letter := range d.letters {
+		if !drop[letter.ID] {
+			kept = append(kept, letter)
+		}
+	}
+	d.letters = kept
+
+	if err := d.save(); err != nil {
+		d.letters = previous
+		return 0, err
+	}
+	return len(previous) - len(kept), nil
+}
+
+// len returns the number of dead letters
+func (d *deadLetters) len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.letters)
+}
+
+// index returns the position of a dead letter, or -1. The caller must hold the lock.
+func (d *deadLetters) index(id string) int {
+	for i, letter := range d.letters {
+		if letter.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// save writes the dead letters to disk. The caller must hold the lock.
+func (d *deadLetters) save() error {
+	if d.path == "" {
+		return nil
+	}
+	letters := d.letters
+	if letters == nil {
+		letters = []models.DeadLetter{}
+	}
+	if err := fileutil.WriteJSONAtomic(d.path, letters); err != nil {
+		return fmt.Errorf("failed to write webhook dead letters: %w", err)
+	}
+	return nil
+}
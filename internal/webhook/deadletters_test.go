@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestService_DeadLetters(t *testing.T) {
+	var mu sync.Mutex
+	down := true
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/down" && down {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		received = append(received, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			Timeout:        5 * time.Second,
+			RetryAttempts:  1,
+			QueuePath:      filepath.Join(dir, "queue.json"),
+			QueueSize:      10,
+			DeadLetterPath: filepath.Join(dir, "dead_letters.json"),
+			DeadLetterMax:  10,
+			Targets: []config.WebhookTargetConfig{
+				{Name: "up", URL: server.URL + "/up", RetryAttempts: 1},
+				{Name: "down", URL: server.URL + "/down", RetryAttempts: 1},
+			},
+		},
+	}
+	service := NewService(cfg, zaptest.NewLogger(t))
+
+	service.sendWebhook(context.Background(), models.Message{From: "alice@example.com/phone", Body: "hello"})
+	assert.Equal(t, []string{"/up"}, received)
+
+	letters := service.DeadLetters()
+	require.Len(t, letters, 1)
+	assert.Equal(t, "down", letters[0].Target)
+	assert.Equal(t, "hello", letters[0].Message.Body)
+	assert.Equal(t, "webhook returned status 502", letters[0].Error)
+	assert.Equal(t, 1, letters[0].Attempts)
+
+	// Dead letters survive a restart
+	service = NewService(cfg, zaptest.NewLogger(t))
+	require.NoError(t, service.Start())
+	defer service.Stop()
+	require.Len(t, service.DeadLetters(), 1)
+
+	_, err := service.ReplayDeadLetters([]string{"dl-unknown"})
+	assert.ErrorIs(t, err, ErrDeadLetterNotFound)
+
+	mu.Lock()
+	down = false
+	mu.Unlock()
+
+	replayed, err := service.ReplayDeadLetters(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	assert.Empty(t, service.DeadLetters())
+
+	// The replay goes to the target it failed for only
+	assert.Eventually(t, func() bool { return service.GetQueueLength() == 0 }, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, []string{"/up", "/down"}, received)
+	mu.Unlock()
+
+	deleted, err := service.DeleteDeadLetters(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, deleted)
+}
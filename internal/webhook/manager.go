@@ -7,6 +7,7 @@ import (
 
 	"jabber-bot/internal/config"
 	"jabber-bot/internal/models"
+	"jabber-bot/internal/xmpp"
 
 	"go.uber.org/zap"
 )
@@ -14,6 +15,13 @@ import (
 // XMPPManagerInterface defines the interface for XMPP manager operations
 type XMPPManagerInterface interface {
 	GetWebhookChannel() <-chan models.Message
+	SendReply(msg models.Message, body string) (models.SendResult, error)
+	SendReaction(msg models.Message, emojis []string) error
+	SendChatState(account, to string, state xmpp.ChatState) error
+	SendFile(account, to, fileURL, fileName, fileType string) error
+	SendMessage(account, from, to, body, messageType string) (models.SendResult, error)
+	SendMUCMessage(account, room, body, subject string, mentions []string) (models.SendResult, error)
+	SetPresence(account, show, status string) error
 }
 
 // Manager manages webhook service integration with XMPP manager
@@ -28,12 +36,15 @@ type Manager struct {
 
 // NewManager creates new webhook manager
 func NewManager(cfg *config.Config, logger *zap.Logger, xmppManager XMPPManagerInterface) *Manager {
-	return &Manager{
+	m := &Manager{
 		config:         cfg,
 		logger:         logger,
 		webhookService: NewService(cfg, logger),
 		xmppManager:    xmppManager,
 	}
+	m.webhookService.SetActionHandler(m.executeAction)
+
+	return m
 }
 
 // Start starts webhook manager
@@ -84,6 +95,36 @@ func (m *Manager) GetService() *Service {
 	return m.webhookService
 }
 
+// Subscribe registers a webhook subscription
+func (m *Manager) Subscribe(sub models.WebhookSubscription) (models.WebhookSubscription, error) {
+	return m.webhookService.Subscribe(sub)
+}
+
+// Subscriptions returns the webhook subscriptions
+func (m *Manager) Subscriptions() []models.WebhookSubscription {
+	return m.webhookService.Subscriptions()
+}
+
+// Unsubscribe removes a webhook subscription
+func (m *Manager) Unsubscribe(id string) error {
+	return m.webhookService.Unsubscribe(id)
+}
+
+// DeadLetters returns the webhook messages that could not be delivered
+func (m *Manager) DeadLetters() []models.DeadLetter {
+	return m.webhookService.DeadLetters()
+}
+
+// ReplayDeadLetters queues webhook dead letters for delivery again
+func (m *Manager) ReplayDeadLetters(ids []string) (int, error) {
+	return m.webhookService.ReplayDeadLetters(ids)
+}
+
+// DeleteDeadLetters removes webhook dead letters
+func (m *Manager) DeleteDeadLetters(ids []string) (int, error) {
+	return m.webhookService.DeleteDeadLetters(ids)
+}
+
 // processXMPPMessages processes messages from XMPP manager
 func (m *Manager) processXMPPMessages(ctx context.Context) {
 	defer m.wg.Done()
@@ -127,16 +168,31 @@ func (m *Manager) handleIncomingMessage(msg models.Message) {
 // GetStatus returns webhook manager status
 func (m *Manager) GetStatus() map[string]interface{} {
 	stats := m.webhookService.GetStats()
+	workers := m.webhookService.WorkerStats()
+	inFlight := 0
+	for _, w := range workers {
+		inFlight += w.InFlight
+	}
 
 	return map[string]interface{}{
-		"running":      m.webhookService.isRunning(),
-		"healthy":      m.webhookService.IsHealthy(),
-		"queue_length": m.webhookService.GetQueueLength(),
-		"webhook_url":  m.config.Webhook.URL,
-		"total_sent":   stats.TotalSent,
-		"total_failed": stats.TotalFailed,
-		"last_sent":    stats.LastSent,
-		"last_failure": stats.LastFailure,
-		"last_error":   stats.LastError,
+		"running":       m.webhookService.isRunning(),
+		"healthy":       m.webhookService.IsHealthy(),
+		"queue_length":  m.webhookService.GetQueueLength(),
+		"webhook_url":   m.config.Webhook.URL,
+		"targets":       len(m.webhookService.targets),
+		"subscriptions": m.webhookService.subscriptionCount(),
+		"dead_letters":  m.webhookService.deadLetters.len(),
+		"in_flight":     inFlight,
+		"workers":       workers,
+		"total_sent":    stats.TotalSent,
+		"total_failed":  stats.TotalFailed,
+		"last_sent":     stats.LastSent,
+		"last_failure":  stats.LastFailure,
+		"last_error":    stats.LastError,
+
+		"reply_actions":     m.config.Webhook.ReplyActions,
+		"actions_executed":  stats.ActionsExecuted,
+		"actions_failed":    stats.ActionsFailed,
+		"last_action_error": stats.LastActionError,
 	}
 }
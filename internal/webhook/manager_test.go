@@ -7,6 +7,7 @@ import (
 
 	"jabber-bot/internal/config"
 	"jabber-bot/internal/models"
+	"jabber-bot/internal/xmpp"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -25,6 +26,41 @@ func (m *MockXMPPManager) GetWebhookChannel() <-chan models.Message {
 	return args.Get(0).(<-chan models.Message)
 }
 
+func (m *MockXMPPManager) SendReply(msg models.Message, body string) (models.SendResult, error) {
+	args := m.Called(msg, body)
+	return models.SendResult{}, args.Error(0)
+}
+
+func (m *MockXMPPManager) SendReaction(msg models.Message, emojis []string) error {
+	args := m.Called(msg, emojis)
+	return args.Error(0)
+}
+
+func (m *MockXMPPManager) SendChatState(account, to string, state xmpp.ChatState) error {
+	args := m.Called(account, to, state)
+	return args.Error(0)
+}
+
+func (m *MockXMPPManager) SendFile(account, to, fileURL, fileName, fileType string) error {
+	args := m.Called(account, to, fileURL, fileName, fileType)
+	return args.Error(0)
+}
+
+func (m *MockXMPPManager) SendMessage(account, from, to, body, messageType string) (models.SendResult, error) {
+	args := m.Called(account, from, to, body, messageType)
+	return models.SendResult{}, args.Error(0)
+}
+
+func (m *MockXMPPManager) SendMUCMessage(account, room, body, subject string, mentions []string) (models.SendResult, error) {
+	args := m.Called(account, room, body, subject, mentions)
+	return models.SendResult{}, args.Error(0)
+}
+
+func (m *MockXMPPManager) SetPresence(account, show, status string) error {
+	args := m.Called(account, show, status)
+	return args.Error(0)
+}
+
 func TestNewManager(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	cfg := &config.Config{
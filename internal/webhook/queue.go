@@ -0,0 +1,406 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"jabber-bot/internal/fileutil"
+	"jabber-bot/internal/models"
+)
+
+// ErrQueueFull is returned when a message cannot be queued because the webhook queue is full
+var ErrQueueFull = errors.New("webhook queue is full")
+
+// queueCompactRecords is the number of journal records after which the journal is rewritten
+// with the queued messages only, once most of its records are obsolete
+const queueCompactRecords = 1000
+
+// queuedMessage is a message waiting for webhook delivery
+type queuedMessage struct {
+	ID       string         `json:"id"`
+	Message  models.Message `json:"message"`
+	Target   string         `json:"target,omitempty"`
+	Group    string         `json:"group,omitempty"` // shared by the entries of a message, one per target
+	QueuedAt time.Time      `json:"queued_at"`
+}
+
+// journalRecord is a line of the queue file: a queued message, or the ID of a message whose
+// delivery finished
+type journalRecord struct {
+	Push *queuedMessage `json:"push,omitempty"`
+	Done string         `json:"done,omitempty"`
+}
+
+// queue holds the messages waiting for webhook delivery, optionally persisted to a file so they
+// survive a restart. Messages stay in the queue until their delivery finished, so a message
+// interrupted by shutdown is delivered again on the next start.
+//
+// The messages are split into shards, one per worker. The entries of a target and conversation
+// always go to the same shard in arrival order, so a worker only looks at its own shard and is
+// the only one woken up for it.
+//
+// The file is a journal: every push and done appends a record, so queueing a message does not
+// rewrite the whole queue. The journal is compacted once most of its records are obsolete.
+type queue struct {
+	mu      sync.Mutex
+	shards  []*queueShard
+	size    int            // entries queued over all shards
+	groups  map[string]int // entries queued per group
+	path    string
+	maxSize int
+	seq     uint64
+
+	journal *os.File
+	records int  // records appended since the last compaction
+	damaged bool // an append failed, the journal must be rewritten
+}
+
+// queueShard holds the entries delivered by a worker in arrival order. The worker delivers one
+// entry at a time, always the first one.
+type queueShard struct {
+	messages []queuedMessage
+	inFlight bool          // the delivery of the first entry has started
+	changed  chan struct{} // closed when messages are queued
+}
+
+// newQueue creates a queue of n shards persisted to path, or kept in memory when path is empty.
+// Fewer than one shard means one.
+func newQueue(path string, maxSize, n int) *queue {
+	if n < 1 {
+		n = 1
+	}
+	shards := make([]*queueShard, n)
+	for i := range shards {
+		shards[i] = &queueShard{changed: make(chan struct{})}
+	}
+	return &queue{
+		shards:  shards,
+		groups:  make(map[string]int),
+		path:    path,
+		maxSize: maxSize,
+	}
+}
+
+// shardFor returns the shard of the entries of a conversation to the target of an entry
+func (q *queue) shardFor(entry queuedMessage) int {
+	if len(q.shards) == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(entry.Target))
+	h.Write([]byte{0})
+	h.Write([]byte(conversationKey(entry.Message)))
+	return int(h.Sum32() % uint32(len(q.shards)))
+}
+
+// load reads the messages persisted by a previous run and compacts the journal
+func (q *queue) load() error {
+	if q.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(q.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read webhook queue: %w", err)
+	}
+	messages, err := readJournal(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse webhook queue %s: %w", q.path, err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, shard := range q.shards {
+		shard.messages = nil
+		shard.inFlight = false
+	}
+	q.size = 0
+	q.groups = make(map[string]int)
+	for _, msg := range messages {
+		q.add(msg)
+	}
+	if err := q.compact(); err != nil {
+		return err
+	}
+	for i := range q.shards {
+		q.wake(i)
+	}
+	return nil
+}
+
+// readJournal replays the records of a queue file. A record cut short by a crash while it was
+// appended is ignored. A file holding a JSON array is a queue written by an older version.
+func readJournal(data []byte) ([]queuedMessage, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if data[0] == '[' {
+		var messages []queuedMessage
+		err := json.Unmarshal(data, &messages)
+		return messages, err
+	}
+
+	var messages []queuedMessage
+	finished := make(map[string]bool)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var record journalRecord
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case record.Push != nil:
+			messages = append(messages, *record.Push)
+		case record.Done != "":
+			finished[record.Done] = true
+		}
+	}
+
+	kept := messages[:0]
+	for _, msg := range messages {
+		if !finished[msg.ID] {
+			kept = append(kept, msg)
+		}
+	}
+	return kept, nil
+}
+
+// push appends a message for delivery, one entry per target. The entries are queued together or
+// not at all. A queue without maximum size is unbounded.
+func (q *queue) push(msg models.Message, targets ...string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.maxSize > 0 && q.size+len(targets) > q.maxSize {
+		return ErrQueueFull
+	}
+
+	now := time.Now()
+	group := ""
+	added := make([]int, 0, len(targets))
+	for i, target := range targets {
+		id := fmt.Sprintf("wq-%d-%d", now.UnixNano(), atomic.AddUint64(&q.seq, 1))
+		if i == 0 && len(targets) > 1 {
+			group = id
+		}
+		entry := queuedMessage{
+			ID:       id,
+			Message:  msg,
+			Target:   target,
+			Group:    group,
+			QueuedAt: now.UTC(),
+		}
+		added = append(added, q.add(entry))
+
+		if err := q.persist(journalRecord{Push: &entry}); err != nil {
+			// The journal already holds the entries queued before, rewrite it on the next change
+			for j := len(added) - 1; j >= 0; j-- {
+				q.removeLast(added[j])
+			}
+			q.damaged = q.damaged || i > 0
+			return err
+		}
+	}
+	for _, shard := range added {
+		q.wake(shard)
+	}
+	return nil
+}
+
+// add appends an entry to its shard and returns the shard. The caller must hold the lock.
+func (q *queue) add(entry queuedMessage) int {
+	i := q.shardFor(entry)
+	q.shards[i].messages = append(q.shards[i].messages, entry)
+	q.size++
+	if entry.Group != "" {
+		q.groups[entry.Group]++
+	}
+	return i
+}
+
+// removeLast removes the entry added last to a shard. The caller must hold the lock.
+func (q *queue) removeLast(i int) {
+	shard := q.shards[i]
+	q.forget(shard.messages[len(shard.messages)-1])
+	shard.messages = shard.messages[:len(shard.messages)-1]
+}
+
+// forget updates the counters for an entry leaving the queue. The caller must hold the lock.
+func (q *queue) forget(entry queuedMessage) {
+	q.size--
+	if entry.Group == "" {
+		return
+	}
+	if q.groups[entry.Group]--; q.groups[entry.Group] <= 0 {
+		delete(q.groups, entry.Group)
+	}
+}
+
+// next returns the oldest message of a shard and marks it in flight. Nothing is returned while
+// the delivery of a message of the shard is in progress.
+func (q *queue) next(i int) (queuedMessage, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	shard := q.shards[i]
+	if shard.inFlight || len(shard.messages) == 0 {
+		return queuedMessage{}, false
+	}
+	shard.inFlight = true
+	return shard.messages[0], true
+}
+
+// done removes the message of a shard whose delivery finished, successfully or not
+func (q *queue) done(i int, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	shard := q.shards[i]
+	for j, msg := range shard.messages {
+		if msg.ID == id {
+			if j == 0 {
+				shard.inFlight = false
+			}
+			shard.messages = append(shard.messages[:j], shard.messages[j+1:]...)
+			q.forget(msg)
+			return q.persist(journalRecord{Done: id})
+		}
+	}
+	return nil
+}
+
+// release makes the message of a shard whose delivery was interrupted available again
+func (q *queue) release(i int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shards[i].inFlight = false
+}
+
+// hasGroup reports whether entries of a message are still queued for other targets
+func (q *queue) hasGroup(group string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.groups[group] > 0
+}
+
+// len returns the number of queued messages, including the ones in flight
+func (q *queue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// waiting returns the number of messages of a shard whose delivery has not started
+func (q *queue) waiting(i int) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	shard := q.shards[i]
+	if shard.inFlight {
+		return len(shard.messages) - 1
+	}
+	return len(shard.messages)
+}
+
+// wait returns a channel that is closed when messages are queued to a shard. Workers call it
+// before next, so they do not miss messages queued in between.
+func (q *queue) wait(i int) <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.shards[i].changed
+}
+
+// wake wakes the worker waiting for messages of a shard. The caller must hold the lock.
+func (q *queue) wake(i int) {
+	close(q.shards[i].changed)
+	q.shards[i].changed = make(chan struct{})
+}
+
+// close closes the journal; the next write opens it again
+func (q *queue) close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.journal == nil {
+		return nil
+	}
+	err := q.journal.Close()
+	q.journal = nil
+	return err
+}
+
+// persist appends a record to the journal, or compacts the journal when most of its records are
+// obsolete. The caller must hold the lock and has already applied the record to the messages.
+func (q *queue) persist(record journalRecord) error {
+	if q.path == "" {
+		return nil
+	}
+	if q.damaged || (q.records >= queueCompactRecords && q.records > 2*q.size) {
+		return q.compact()
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook queue record: %w", err)
+	}
+	if q.journal == nil {
+		if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+			return fmt.Errorf("failed to write webhook queue: %w", err)
+		}
+		if q.journal, err = os.OpenFile(q.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644); err != nil {
+			return fmt.Errorf("failed to write webhook queue: %w", err)
+		}
+	}
+	if _, err := q.journal.Write(append(data, '\n')); err != nil {
+		// A partly written record would corrupt the records appended after it
+		q.damaged = true
+		return fmt.Errorf("failed to write webhook queue: %w", err)
+	}
+	q.records++
+	return nil
+}
+
+// compact rewrites the journal with a record per queued message. The caller must hold the lock.
+func (q *queue) compact() error {
+	if q.path == "" {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, shard := range q.shards {
+		for i := range shard.messages {
+			if err := encoder.Encode(journalRecord{Push: &shard.messages[i]}); err != nil {
+				return fmt.Errorf("failed to encode webhook queue record: %w", err)
+			}
+		}
+	}
+
+	// The open journal refers to the file replaced by the rename
+	if q.journal != nil {
+		//goland:noinspection GoUnhandledErrorResult
+		q.journal.Close()
+		q.journal = nil
+	}
+	if err := fileutil.WriteFileAtomic(q.path, buf.Bytes()); err != nil {
+		q.damaged = true
+		return fmt.Errorf("failed to write webhook queue: %w", err)
+	}
+	q.records = 0
+	q.damaged = false
+	return nil
+}
@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"jabber-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue_Persisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+
+	q := newQueue(path, 3, 1)
+	require.NoError(t, q.push(models.Message{Body: "first"}, ""))
+	require.NoError(t, q.push(models.Message{Body: "second"}, "ops"))
+	require.NoError(t, q.push(models.Message{Body: "third"}, ""))
+	assert.ErrorIs(t, q.push(models.Message{Body: "fourth"}, ""), ErrQueueFull)
+
+	first, ok := q.next(0)
+	require.True(t, ok)
+	assert.Equal(t, "first", first.Message.Body)
+	require.NoError(t, q.done(0, first.ID))
+
+	// The second message is in flight when the bot stops
+	second, ok := q.next(0)
+	require.True(t, ok)
+	assert.Equal(t, "ops", second.Target)
+	assert.Equal(t, 2, q.len())
+
+	restarted := newQueue(path, 3, 1)
+	require.NoError(t, restarted.load())
+	assert.Equal(t, 2, restarted.len())
+
+	var bodies []string
+	for {
+		entry, ok := restarted.next(0)
+		if !ok {
+			break
+		}
+		bodies = append(bodies, entry.Message.Body)
+		require.NoError(t, restarted.done(0, entry.ID))
+	}
+	assert.Equal(t, []string{"second", "third"}, bodies, "messages interrupted by a restart are delivered again")
+}
+
+func TestQueue_Journal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "queue.json")
+
+	q := newQueue(path, 0, 1)
+	// Every message adds two records, so the last push compacts the journal
+	for i := 0; i < queueCompactRecords/2+1; i++ {
+		require.NoError(t, q.push(models.Message{Body: "delivered"}, ""))
+		entry, ok := q.next(0)
+		require.True(t, ok)
+		require.NoError(t, q.done(0, entry.ID))
+	}
+	require.NoError(t, q.push(models.Message{Body: "pending"}, ""))
+
+	// Pushes and deliveries are appended, and the journal is compacted once most records are obsolete
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(data), "\n"))
+	require.NoError(t, q.close())
+
+	// A record cut short by a crash is ignored
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"push":{"id":"wq-cut","mess`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	restarted := newQueue(path, 0, 1)
+	require.NoError(t, restarted.load())
+	entry, ok := restarted.next(0)
+	require.True(t, ok)
+	assert.Equal(t, "pending", entry.Message.Body)
+	assert.Equal(t, 1, restarted.len())
+
+	// Loading compacts the journal to the queued messages
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "\n"))
+}
+
+func TestQueue_LoadArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"id":"wq-1","message":{"body":"old"},"queued_at":"2026-01-02T03:04:05Z"}]`), 0644))
+
+	q := newQueue(path, 0, 1)
+	require.NoError(t, q.load())
+	entry, ok := q.next(0)
+	require.True(t, ok)
+	assert.Equal(t, "old", entry.Message.Body)
+}
+
+func TestQueue_Shards(t *testing.T) {
+	q := newQueue("", 0, 4)
+	msg := models.Message{From: "alice@example.com/phone", Body: "hi"}
+	shard := q.shardFor(queuedMessage{Message: msg, Target: "ops"})
+
+	waits := make([]<-chan struct{}, 4)
+	for i := range waits {
+		waits[i] = q.wait(i)
+	}
+	require.NoError(t, q.push(msg, "ops"))
+
+	// Only the worker of the shard is woken up and sees the message
+	for i, wait := range waits {
+		woken := false
+		select {
+		case <-wait:
+			woken = true
+		default:
+		}
+		assert.Equal(t, i == shard, woken, "shard %d", i)
+	}
+	assert.Equal(t, 1, q.waiting(shard))
+	assert.Equal(t, 1, q.len())
+
+	// A shard delivers one message at a time, in order
+	require.NoError(t, q.push(models.Message{From: "alice@example.com/laptop", Body: "again"}, "ops"))
+	first, ok := q.next(shard)
+	require.True(t, ok)
+	assert.Equal(t, "hi", first.Message.Body)
+	_, ok = q.next(shard)
+	assert.False(t, ok)
+
+	require.NoError(t, q.done(shard, first.ID))
+	second, ok := q.next(shard)
+	require.True(t, ok)
+	assert.Equal(t, "again", second.Message.Body)
+}
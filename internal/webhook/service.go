@@ -5,12 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"sync"
 	"time"
 
 	"jabber-bot/internal/config"
 	"jabber-bot/internal/models"
+	"jabber-bot/pkg/webhooksig"
 
 	"go.uber.org/zap"
 )
@@ -23,7 +25,8 @@ type Service struct {
 	config        *config.Config
 	logger        *zap.Logger
 	httpClient    *http.Client
-	messageQueue  chan models.Message
+	queue         *queue
+	deadLetters   *deadLetters
 	mu            sync.RWMutex
 	running       bool
 	cancelFunc    context.CancelFunc
@@ -31,6 +34,13 @@ type Service struct {
 	testMode      *TestModeUtils
 	wg            sync.WaitGroup
 	onMessageSent MessageCallback
+	onAction      ActionHandler
+	targets       []*target
+	workers       []*worker
+	notified      map[string]bool // message groups whose callback already ran
+
+	subscriptionsMu sync.RWMutex
+	subscriptions   map[string]*subscription
 }
 
 // Stats contains webhook statistics
@@ -40,21 +50,32 @@ type Stats struct {
 	LastSent    time.Time `json:"last_sent"`
 	LastFailure time.Time `json:"last_failure"`
 	LastError   string    `json:"last_error"`
-	mu          sync.RWMutex
+
+	ActionsExecuted int64  `json:"actions_executed"`
+	ActionsFailed   int64  `json:"actions_failed"`
+	LastActionError string `json:"last_action_error"`
+	mu              sync.RWMutex
 }
 
 // NewService creates new webhook service
 func NewService(cfg *config.Config, logger *zap.Logger) *Service {
-	return &Service{
+	workers := newWorkers(cfg.Webhook.Workers)
+	s := &Service{
 		config: cfg,
 		logger: logger,
 		httpClient: &http.Client{
 			Timeout: cfg.Webhook.Timeout,
 		},
-		messageQueue: make(chan models.Message, 1000),
-		stats:        &Stats{},
-		testMode:     NewTestModeUtils(cfg.Webhook.TestModeSuffix),
+		queue:         newQueue(cfg.Webhook.QueuePath, cfg.Webhook.QueueSize, len(workers)),
+		deadLetters:   newDeadLetters(cfg.Webhook.DeadLetterPath, cfg.Webhook.DeadLetterMax),
+		stats:         &Stats{},
+		testMode:      NewTestModeUtils(cfg.Webhook.TestModeSuffix),
+		subscriptions: make(map[string]*subscription),
+		workers:       workers,
 	}
+	s.targets = newTargets(cfg, logger)
+
+	return s
 }
 
 // Start starts webhook service
@@ -66,18 +87,34 @@ func (s *Service) Start() error {
 		return fmt.Errorf("webhook service is already running")
 	}
 
+	if err := s.loadSubscriptions(); err != nil {
+		return err
+	}
+	if err := s.queue.load(); err != nil {
+		return err
+	}
+	if err := s.deadLetters.load(); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	s.cancelFunc = cancel
 
-	// Start webhook processor
-	s.wg.Add(1)
-	go s.processWebhooks(ctx)
+	// Start webhook workers
+	for _, w := range s.workers {
+		s.wg.Add(1)
+		go s.runWorker(ctx, w)
+	}
 
 	s.running = true
 	s.logger.Info("Webhook service started",
 		zap.String("url", s.config.Webhook.URL),
+		zap.Int("targets", len(s.targets)),
 		zap.Duration("timeout", s.config.Webhook.Timeout),
 		zap.Int("retry_attempts", s.config.Webhook.RetryAttempts),
+		zap.Int("workers", len(s.workers)),
+		zap.Int("queued", s.queue.len()),
+		zap.Int("dead_letters", s.deadLetters.len()),
 	)
 
 	return nil
@@ -92,41 +129,63 @@ func (s *Service) Stop() error {
 		return nil
 	}
 
-	// Signal processor to stop
+	// Signal workers to stop
 	if s.cancelFunc != nil {
 		s.cancelFunc()
 	}
 
-	// Wait for processor to finish
+	// Wait for workers to finish, messages not delivered yet stay queued for the next start
 	s.wg.Wait()
 
-	close(s.messageQueue)
+	if err := s.queue.close(); err != nil {
+		s.logger.Warn("Failed to close webhook queue", zap.Error(err))
+	}
+
 	s.running = false
 
 	s.logger.Info("Webhook service stopped")
 	return nil
 }
 
-// SendMessage sends message to webhook endpoint
+// SendMessage queues a message for delivery to each webhook target it matches. A message that
+// cannot be queued is kept as a dead letter for each target.
 func (s *Service) SendMessage(msg models.Message) error {
 	if !s.isRunning() {
 		return fmt.Errorf("webhook service is not running")
 	}
 
-	select {
-	case s.messageQueue <- msg:
-		s.logger.Debug("Message queued for webhook",
+	targets := s.targetsFor(msg)
+	if len(targets) == 0 {
+		s.logger.Debug("No webhook target matches message",
 			zap.String("from", msg.From),
-			zap.String("to", msg.To),
+			zap.String("event", messageEvent(msg)),
 		)
 		return nil
-	default:
-		s.logger.Warn("Webhook queue full, dropping message",
+	}
+
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.name
+	}
+
+	if err := s.queue.push(msg, names...); err != nil {
+		s.logger.Warn("Failed to queue message for webhook, keeping it as dead letter",
+			zap.Error(err),
 			zap.String("from", msg.From),
-			zap.Int("queue_length", len(s.messageQueue)),
+			zap.Int("queue_length", s.queue.len()),
 		)
-		return fmt.Errorf("webhook queue is full")
+		for _, name := range names {
+			s.addDeadLetter(models.DeadLetter{Target: name, Message: msg, Error: err.Error()})
+		}
+		return err
 	}
+
+	s.logger.Debug("Message queued for webhook",
+		zap.String("from", msg.From),
+		zap.String("to", msg.To),
+		zap.Strings("targets", names),
+	)
+	return nil
 }
 
 // GetStats returns webhook statistics
@@ -140,6 +199,10 @@ func (s *Service) GetStats() Stats {
 		LastSent:    s.stats.LastSent,
 		LastFailure: s.stats.LastFailure,
 		LastError:   s.stats.LastError,
+
+		ActionsExecuted: s.stats.ActionsExecuted,
+		ActionsFailed:   s.stats.ActionsFailed,
+		LastActionError: s.stats.LastActionError,
 	}
 }
 
@@ -157,119 +220,282 @@ func (s *Service) isRunning() bool {
 	return s.running
 }
 
-// processWebhooks processes messages from queue and sends webhooks
-func (s *Service) processWebhooks(ctx context.Context) {
-	defer s.wg.Done()
-	s.logger.Info("Starting webhook processor")
-	defer s.logger.Info("Webhook processor stopped")
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case msg, ok := <-s.messageQueue:
-			if !ok {
-				return
-			}
-			s.sendWebhook(msg)
-		}
+// processQueued delivers a queued message to its target. Messages queued without a target by
+// an older version go to every target they match. It returns ctx.Err() when the delivery was
+// interrupted by shutdown.
+func (s *Service) processQueued(ctx context.Context, entry queuedMessage) error {
+	if entry.Target == "" {
+		s.sendWebhook(ctx, entry.Message)
+		return ctx.Err()
+	}
+
+	t := s.targetByName(entry.Target, entry.Message)
+	if t == nil {
+		s.logger.Warn("Webhook target of queued message no longer exists",
+			zap.String("target", entry.Target),
+			zap.String("from", entry.Message.From),
+		)
+		s.addDeadLetter(models.DeadLetter{
+			Target:  entry.Target,
+			Message: entry.Message,
+			Error:   fmt.Sprintf("webhook target %s no longer exists", entry.Target),
+		})
+		return nil
+	}
+
+	return s.deliverTo(ctx, entry, []*target{t})
+}
+
+// sendWebhook delivers a message to every webhook target it matches, one after the other
+func (s *Service) sendWebhook(ctx context.Context, msg models.Message) {
+	targets := s.targetsFor(msg)
+	if len(targets) == 0 {
+		s.logger.Debug("No webhook target matches message",
+			zap.String("from", msg.From),
+			zap.String("event", messageEvent(msg)),
+		)
+		return
 	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	s.deliverTo(ctx, queuedMessage{Message: msg}, targets)
 }
 
-// sendWebhook sends webhook notification with retry logic
-func (s *Service) sendWebhook(msg models.Message) {
+// deliverTo delivers a queued message to each of the targets. It returns ctx.Err() when the
+// delivery was interrupted by shutdown.
+func (s *Service) deliverTo(ctx context.Context, entry queuedMessage, targets []*target) error {
+	msg := entry.Message
+
 	// Create webhook payload
 	payload := models.WebhookPayload{
+		Event:     messageEvent(msg),
 		Message:   msg,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		Source:    "jabber-bot",
 	}
 
+	delivered := false
+	for _, t := range targets {
+		response, err := s.deliver(ctx, payload, t)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			continue
+		}
+
+		// Call the callback once, whichever target received the message first
+		if !delivered {
+			delivered = true
+			s.notifySent(entry)
+		}
+
+		if s.config.Webhook.ReplyActions {
+			s.runActions(msg, response)
+		}
+	}
+	return nil
+}
+
+// notifySent calls the callback for a delivered message, unless another entry of the message
+// was delivered to its target before
+func (s *Service) notifySent(entry queuedMessage) {
+	s.mu.Lock()
+	callback := s.onMessageSent
+	if entry.Group != "" {
+		if s.notified[entry.Group] {
+			callback = nil
+		} else {
+			if s.notified == nil {
+				s.notified = make(map[string]bool)
+			}
+			s.notified[entry.Group] = true
+		}
+	}
+	s.mu.Unlock()
+
+	if callback != nil {
+		callback(entry.Message)
+	}
+}
+
+// forgetGroup drops the callback state of a message once none of its entries is queued anymore
+func (s *Service) forgetGroup(group string) {
+	if group == "" || s.queue.hasGroup(group) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.notified, group)
+}
+
+// deliver sends a webhook payload to one target with retry logic and returns the response
+// body of the successful attempt. When ctx is cancelled it gives up without a dead letter, so
+// the message stays queued.
+func (s *Service) deliver(ctx context.Context, payload models.WebhookPayload, t *target) ([]byte, error) {
+	msg := payload.Message
+
+	webhookURL := t.url
+	// Check if test mode is detected and update URL
+	if _, testURL, isTestMode := s.testMode.ProcessTestMessage(msg.Body, t.url); isTestMode {
+		webhookURL = testURL
+	}
+
+	// Retries of a delivery keep its ID, so receivers can drop duplicates
+	deliveryID, err := randomHex(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate delivery ID: %w", err)
+	}
+
 	// Send with retries
 	var lastErr error
-	for attempt := 1; attempt <= s.config.Webhook.RetryAttempts; attempt++ {
-		webhookURL := s.config.Webhook.URL
-		// Check if test mode is detected and update URL
-		if _, testURL, isTestMode := s.testMode.ProcessTestMessage(payload.Message.Body, s.config.Webhook.URL); isTestMode {
-			webhookURL = testURL
+	for attempt := 1; attempt <= t.retryAttempts; attempt++ {
+		response, err := s.sendWebhookAttempt(ctx, payload, t, deliveryID)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
-
-		err := s.sendWebhookAttempt(payload)
 		if err == nil {
 			// Success
 			s.updateStats(true, "")
 			s.logger.Info("Webhook sent successfully",
+				zap.String("target", t.name),
+				zap.String("delivery_id", deliveryID),
 				zap.Int("attempt", attempt),
 				zap.String("from", msg.From),
 				zap.String("to", msg.To),
 				zap.String("url", webhookURL),
 			)
-
-			// Call the callback if set
-			s.mu.RLock()
-			callback := s.onMessageSent
-			s.mu.RUnlock()
-			if callback != nil {
-				callback(msg)
-			}
-
-			return
+			return response, nil
 		}
 
 		lastErr = err
 		s.logger.Warn("Webhook attempt failed",
+			zap.String("target", t.name),
+			zap.String("delivery_id", deliveryID),
 			zap.Int("attempt", attempt),
-			zap.Int("max_attempts", s.config.Webhook.RetryAttempts),
+			zap.Int("max_attempts", t.retryAttempts),
 			zap.Error(err),
 			zap.String("from", msg.From),
 			zap.String("url", webhookURL),
 		)
 
 		// Don't wait after last attempt
-		if attempt < s.config.Webhook.RetryAttempts {
+		if attempt < t.retryAttempts {
 			// Exponential backoff
-			backoff := time.Duration(attempt*attempt) * time.Second
-			time.Sleep(backoff)
+			backoff := time.NewTimer(time.Duration(attempt*attempt) * time.Second)
+			select {
+			case <-ctx.Done():
+				backoff.Stop()
+				return nil, ctx.Err()
+			case <-backoff.C:
+			}
 		}
 	}
 
 	// All attempts failed
-	var errorMsg string
-	if lastErr != nil {
-		errorMsg = lastErr.Error()
-	} else {
-		errorMsg = "unknown error"
-	}
-	// Determine final webhook URL for logging
-	webhookURL := s.config.Webhook.URL
-	if _, testURL, isTestMode := s.testMode.ProcessTestMessage(payload.Message.Body, s.config.Webhook.URL); isTestMode {
-		webhookURL = testURL
+	if lastErr == nil {
+		lastErr = fmt.Errorf("unknown error")
 	}
 
-	s.updateStats(false, errorMsg)
+	s.updateStats(false, lastErr.Error())
 	s.logger.Error("Webhook failed after all attempts",
-		zap.Int("attempts", s.config.Webhook.RetryAttempts),
+		zap.String("target", t.name),
+		zap.String("delivery_id", deliveryID),
+		zap.Int("attempts", t.retryAttempts),
 		zap.Error(lastErr),
 		zap.String("from", msg.From),
 		zap.String("to", msg.To),
 		zap.String("url", webhookURL),
 	)
+
+	s.addDeadLetter(models.DeadLetter{
+		Target:   t.name,
+		URL:      webhookURL,
+		Message:  msg,
+		Error:    lastErr.Error(),
+		Attempts: t.retryAttempts,
+	})
+
+	return nil, lastErr
 }
 
-// sendWebhookAttempt sends single webhook attempt
-func (s *Service) sendWebhookAttempt(payload models.WebhookPayload) error {
-	if s.config.Webhook.URL == "" {
-		return fmt.Errorf("webhook URL is not configured")
+// addDeadLetter keeps a message that could not be delivered
+func (s *Service) addDeadLetter(letter models.DeadLetter) {
+	letter.FailedAt = time.Now().UTC()
+	stored, err := s.deadLetters.add(letter)
+	if err != nil {
+		s.logger.Error("Failed to keep webhook dead letter",
+			zap.Error(err),
+			zap.String("target", letter.Target),
+			zap.String("from", letter.Message.From),
+		)
+		return
 	}
 
+	s.logger.Info("Webhook message kept as dead letter",
+		zap.String("id", stored.ID),
+		zap.String("target", letter.Target),
+		zap.String("from", letter.Message.From),
+	)
+}
+
+// DeadLetters returns the messages that could not be delivered, oldest first
+func (s *Service) DeadLetters() []models.DeadLetter {
+	return s.deadLetters.list()
+}
+
+// ReplayDeadLetters queues dead letters for delivery again, each to the target it failed for,
+// and removes them. No IDs replays all of them. It returns how many were queued.
+func (s *Service) ReplayDeadLetters(ids []string) (int, error) {
+	letters, err := s.deadLetters.get(ids)
+	if err != nil {
+		return 0, err
+	}
+
+	var queueErr error
+	queued := make([]string, 0, len(letters))
+	for _, letter := range letters {
+		if err := s.queue.push(letter.Message, letter.Target); err != nil {
+			queueErr = err
+			break
+		}
+		queued = append(queued, letter.ID)
+	}
+
+	if len(queued) > 0 {
+		if _, err := s.deadLetters.remove(queued); err != nil {
+			return len(queued), err
+		}
+		s.logger.Info("Webhook dead letters replayed", zap.Int("count", len(queued)))
+	}
+
+	return len(queued), queueErr
+}
+
+// DeleteDeadLetters removes dead letters, or all of them when no IDs are given, and returns how
+// many were removed
+func (s *Service) DeleteDeadLetters(ids []string) (int, error) {
+	return s.deadLetters.remove(ids)
+}
+
+// sendWebhookAttempt sends single webhook attempt to a target. The response body is returned
+// when reply actions are enabled.
+func (s *Service) sendWebhookAttempt(ctx context.Context, payload models.WebhookPayload, t *target, deliveryID string) ([]byte, error) {
+	if t.url == "" {
+		return nil, fmt.Errorf("webhook URL is not configured")
+	}
+
+	// Signatures cover the time of the attempt, so receivers can reject replays
+	payload.Timestamp = time.Now().UTC().Format(time.RFC3339)
+
 	// Process message for test mode
-	processedBody, webhookURL, isTestMode := s.testMode.ProcessTestMessage(payload.Message.Body, s.config.Webhook.URL)
+	processedBody, webhookURL, isTestMode := s.testMode.ProcessTestMessage(payload.Message.Body, t.url)
 
 	// Update message body if test mode is detected
 	if isTestMode {
 		payload.Message.Body = processedBody
 		s.logger.Debug("Test mode detected, using modified webhook URL",
-			zap.String("original_url", s.config.Webhook.URL),
+			zap.String("original_url", t.url),
 			zap.String("test_url", webhookURL),
 			zap.String("original_body", payload.Message.Body),
 		)
@@ -278,20 +504,29 @@ func (s *Service) sendWebhookAttempt(payload models.WebhookPayload) error {
 	// Marshal payload
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+		return nil, fmt.Errorf("failed to marshal webhook payload: %w", err)
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	// Target headers first, so they cannot replace the ones the bot sets
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "Jabber-Bot/1.0.0")
 	req.Header.Set("X-Webhook-Source", "jabber-bot")
-	req.Header.Set("X-Webhook-Timestamp", payload.Timestamp)
+	req.Header.Set(webhooksig.TimestampHeader, payload.Timestamp)
+	req.Header.Set(webhooksig.DeliveryIDHeader, deliveryID)
+	if len(t.signingSecrets) > 0 {
+		req.Header.Set(webhooksig.SignatureHeader, webhooksig.Header(t.signingSecrets, payload.Timestamp, jsonData))
+	}
 
 	// Add test mode header for debugging
 	if isTestMode {
@@ -299,24 +534,41 @@ func (s *Service) sendWebhookAttempt(payload models.WebhookPayload) error {
 	}
 
 	// Add API key header if configured
-	if s.config.Webhook.APIKey != "" {
-		req.Header.Set("API-Key", s.config.Webhook.APIKey)
+	if t.apiKey != "" {
+		req.Header.Set("API-Key", t.apiKey)
+	}
+
+	// Targets with their own timeout share the transport of the service client
+	httpClient := s.httpClient
+	if t.timeout > 0 {
+		targetClient := *s.httpClient
+		targetClient.Timeout = t.timeout
+		httpClient = &targetClient
 	}
 
 	// Send request
-	resp, err := s.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
 	}
 	//goland:noinspection GoUnhandledErrorResult
 	defer resp.Body.Close()
 
 	// Check response status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("webhook returned status %d", resp.StatusCode)
 	}
 
-	return nil
+	if !s.config.Webhook.ReplyActions {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook response: %w", err)
+	}
+
+	return body, nil
 }
 
 // updateStats updates webhook statistics
@@ -334,9 +586,22 @@ func (s *Service) updateStats(success bool, errorMsg string) {
 	}
 }
 
+// updateActionStats updates reply action statistics
+func (s *Service) updateActionStats(err error) {
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+
+	if err == nil {
+		s.stats.ActionsExecuted++
+	} else {
+		s.stats.ActionsFailed++
+		s.stats.LastActionError = err.Error()
+	}
+}
+
 // GetQueueLength returns current queue length
 func (s *Service) GetQueueLength() int {
-	return len(s.messageQueue)
+	return s.queue.len()
 }
 
 // IsHealthy checks webhook service health
@@ -345,7 +610,7 @@ func (s *Service) IsHealthy() bool {
 		return false
 	}
 
-	if s.config.Webhook.URL == "" {
+	if s.config.Webhook.URL == "" && len(s.targets) == 0 && s.subscriptionCount() == 0 {
 		return false
 	}
 
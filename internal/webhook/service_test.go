@@ -1,16 +1,20 @@
 package webhook
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"jabber-bot/internal/config"
 	"jabber-bot/internal/models"
+	"jabber-bot/pkg/webhooksig"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,7 +37,8 @@ func TestNewService(t *testing.T) {
 	assert.Equal(t, cfg, service.config)
 	assert.Equal(t, logger, service.logger)
 	assert.NotNil(t, service.httpClient)
-	assert.NotNil(t, service.messageQueue)
+	assert.NotNil(t, service.queue)
+	assert.NotNil(t, service.deadLetters)
 	assert.False(t, service.isRunning())
 }
 
@@ -154,8 +159,9 @@ func TestService_SendMessage_QueueFull(t *testing.T) {
 		httpClient: &http.Client{
 			Timeout: cfg.Webhook.Timeout,
 		},
-		messageQueue: make(chan models.Message, 2), // Small queue
-		stats:        &Stats{},
+		queue:       newQueue("", 2, 1), // Small queue
+		deadLetters: newDeadLetters("", 10),
+		stats:       &Stats{},
 	}
 
 	// Start service but fill queue to capacity
@@ -216,7 +222,7 @@ func TestService_SendWebhook_Success(t *testing.T) {
 		Body: "Hello",
 	}
 
-	service.sendWebhook(msg)
+	service.sendWebhook(context.Background(), msg)
 
 	// Check stats
 	stats := service.GetStats()
@@ -224,6 +230,120 @@ func TestService_SendWebhook_Success(t *testing.T) {
 	assert.Equal(t, int64(0), stats.TotalFailed)
 }
 
+func TestService_SendWebhook_EventType(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			Timeout:       5 * time.Second,
+			RetryAttempts: 1,
+		},
+	}
+
+	service := NewService(cfg, logger)
+
+	var events []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload models.WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		events = append(events, payload.Event)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	service.config.Webhook.URL = server.URL
+
+	service.sendWebhook(context.Background(), models.Message{From: "test@example.com", Body: "Hello"})
+	service.sendWebhook(context.Background(), models.Message{
+		From:   "alice@example.com",
+		Event:  "invite",
+		Invite: &models.Invite{Room: "room@conference.example.com", Inviter: "alice@example.com"},
+	})
+
+	assert.Equal(t, []string{"message", "invite"}, events)
+}
+
+func TestService_SendWebhook_AccountTarget(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	var received []string
+	defaultServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, "default:"+r.Header.Get("API-Key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer defaultServer.Close()
+	alertsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, "alerts:"+r.Header.Get("API-Key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer alertsServer.Close()
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			URL:           defaultServer.URL,
+			APIKey:        "default-key",
+			Timeout:       5 * time.Second,
+			RetryAttempts: 1,
+		},
+		Accounts: []config.AccountConfig{
+			{
+				Name:    "alerts",
+				JID:     "alerts@example.com",
+				Webhook: config.AccountWebhookConfig{URL: alertsServer.URL, APIKey: "alerts-key"},
+			},
+			{Name: "support", JID: "support@example.com"},
+		},
+	}
+
+	service := NewService(cfg, logger)
+
+	service.sendWebhook(context.Background(), models.Message{From: "test@example.com", Body: "Hello", Account: config.DefaultAccount})
+	service.sendWebhook(context.Background(), models.Message{From: "test@example.com", Body: "Hello", Account: "alerts"})
+	service.sendWebhook(context.Background(), models.Message{From: "test@example.com", Body: "Hello", Account: "support"})
+
+	assert.Equal(t, []string{"default:default-key", "alerts:alerts-key", "default:default-key"}, received)
+}
+
+func TestService_SendWebhook_Signed(t *testing.T) {
+	var deliveryIDs []string
+	var verified []bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deliveryIDs = append(deliveryIDs, r.Header.Get(webhooksig.DeliveryIDHeader))
+
+		// Receivers that still have the previous secret accept the delivery during rotation
+		_, errOld := webhooksig.VerifyRequest(r, []string{"previous"}, webhooksig.DefaultTolerance)
+		_, errNew := webhooksig.VerifyRequest(r, []string{"current"}, webhooksig.DefaultTolerance)
+		_, errOther := webhooksig.VerifyRequest(r, []string{"other"}, webhooksig.DefaultTolerance)
+		verified = append(verified, errOld == nil && errNew == nil && errOther != nil)
+
+		// Fail the first attempt to check that retries keep the delivery ID
+		if len(deliveryIDs) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			URL:            server.URL,
+			Timeout:        5 * time.Second,
+			RetryAttempts:  2,
+			SigningSecrets: []string{"current", "previous"},
+		},
+	}
+	service := NewService(cfg, zaptest.NewLogger(t))
+
+	service.sendWebhook(context.Background(), models.Message{From: "test@example.com", Body: "Hello"})
+	service.sendWebhook(context.Background(), models.Message{From: "test@example.com", Body: "Hello again"})
+
+	require.Len(t, deliveryIDs, 3)
+	assert.Equal(t, []bool{true, true, true}, verified)
+	assert.NotEmpty(t, deliveryIDs[0])
+	assert.Equal(t, deliveryIDs[0], deliveryIDs[1])
+	assert.NotEqual(t, deliveryIDs[1], deliveryIDs[2])
+}
+
 func TestService_SendWebhook_Failure(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	cfg := &config.Config{
@@ -245,7 +365,7 @@ func TestService_SendWebhook_Failure(t *testing.T) {
 		Body: "Hello",
 	}
 
-	service.sendWebhook(msg)
+	service.sendWebhook(context.Background(), msg)
 
 	// Check stats
 	stats := service.GetStats()
@@ -280,7 +400,7 @@ func TestService_SendWebhook_HTTPError(t *testing.T) {
 		Body: "Hello",
 	}
 
-	service.sendWebhook(msg)
+	service.sendWebhook(context.Background(), msg)
 
 	// Check stats
 	stats := service.GetStats()
@@ -305,13 +425,37 @@ func TestService_SendWebhook_NoURL(t *testing.T) {
 		Body: "Hello",
 	}
 
-	service.sendWebhook(msg)
+	service.sendWebhook(context.Background(), msg)
 
-	// Check stats
+	// Without any webhook there is nothing to deliver to, so nothing fails
 	stats := service.GetStats()
 	assert.Equal(t, int64(0), stats.TotalSent)
-	assert.Equal(t, int64(1), stats.TotalFailed)
-	assert.Contains(t, stats.LastError, "webhook URL is not configured")
+	assert.Equal(t, int64(0), stats.TotalFailed)
+	assert.Empty(t, service.targetsFor(msg))
+}
+
+func TestService_SendMessage_NoTargets(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			Timeout:        5 * time.Second,
+			QueuePath:      filepath.Join(dir, "queue.json"),
+			DeadLetterPath: filepath.Join(dir, "dead_letters.json"),
+		},
+	}
+
+	service := NewService(cfg, zaptest.NewLogger(t))
+	require.NoError(t, service.Start())
+	defer service.Stop()
+
+	require.NoError(t, service.SendMessage(models.Message{From: "test@example.com", Body: "Hello"}))
+
+	// The message is neither queued nor journaled, and does not become a dead letter
+	assert.Equal(t, 0, service.GetQueueLength())
+	assert.Empty(t, service.DeadLetters())
+	journal, err := os.ReadFile(cfg.Webhook.QueuePath)
+	require.NoError(t, err)
+	assert.Empty(t, journal)
 }
 
 func TestService_GetStats(t *testing.T) {
@@ -357,9 +501,9 @@ func TestService_GetQueueLength(t *testing.T) {
 
 	// Add messages to queue
 	for i := 0; i < 5; i++ {
-		service.messageQueue <- models.Message{
+		require.NoError(t, service.queue.push(models.Message{
 			From: fmt.Sprintf("sender%d@example.com", i),
-		}
+		}, ""))
 	}
 
 	assert.Equal(t, 5, service.GetQueueLength())
@@ -437,7 +581,7 @@ func TestService_RetryAttempts(t *testing.T) {
 	}
 
 	// Send webhook (should retry 3 times)
-	service.sendWebhook(msg)
+	service.sendWebhook(context.Background(), msg)
 
 	// Should have attempted 3 times
 	assert.Equal(t, 3, attempts)
@@ -525,7 +669,7 @@ func TestService_TestModeProcessing(t *testing.T) {
 		Body: "normal message",
 	}
 
-	service.sendWebhook(msg1)
+	service.sendWebhook(context.Background(), msg1)
 	assert.Equal(t, "normal message", receivedBody)
 	assert.Equal(t, "", isTestModeHeader)
 
@@ -535,7 +679,7 @@ func TestService_TestModeProcessing(t *testing.T) {
 		Body: "[test] test message",
 	}
 
-	service.sendWebhook(msg2)
+	service.sendWebhook(context.Background(), msg2)
 	assert.Equal(t, "test message", receivedBody) // [test] prefix removed
 	assert.Equal(t, "true", isTestModeHeader)     // Test mode header added
 }
@@ -638,7 +782,7 @@ func TestService_TestModeURLModification(t *testing.T) {
 				Body: tt.messageBody,
 			}
 
-			service.sendWebhook(msg)
+			service.sendWebhook(context.Background(), msg)
 
 			assert.Equal(t, tt.expectedBody, actualBody)
 			if tt.expectTestMode {
@@ -0,0 +1,280 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/fileutil"
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrSubscriptionNotFound is returned for unknown subscription IDs
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	// ErrInvalidSubscription wraps validation errors of subscriptions created through the API
+	ErrInvalidSubscription = errors.New("invalid webhook subscription")
+)
+
+// subscription is a webhook subscription and the target its messages are delivered to
+type subscription struct {
+	models.WebhookSubscription
+	target *target
+}
+
+// Subscribe registers a callback URL for the messages matching its filters. A subscription for
+// a URL that is already registered replaces it and keeps its ID, so subscribers can register
+// again every time they start. A secret is generated when none is given.
+func (s *Service) Subscribe(sub models.WebhookSubscription) (models.WebhookSubscription, error) {
+	if !strings.HasPrefix(sub.URL, "http://") && !strings.HasPrefix(sub.URL, "https://") {
+		return models.WebhookSubscription{}, fmt.Errorf("%w: url must be an http or https URL", ErrInvalidSubscription)
+	}
+	if sub.ExpiresAt != nil {
+		if !sub.ExpiresAt.After(time.Now()) {
+			return models.WebhookSubscription{}, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidSubscription)
+		}
+		expiresAt := sub.ExpiresAt.UTC()
+		sub.ExpiresAt = &expiresAt
+	}
+	if sub.Secret == "" {
+		secret, err := randomHex(32)
+		if err != nil {
+			return models.WebhookSubscription{}, fmt.Errorf("failed to generate secret: %w", err)
+		}
+		sub.Secret = secret
+	}
+
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+
+	sub.ID = ""
+	for id, existing := range s.subscriptions {
+		if existing.URL == sub.URL {
+			sub.ID = id
+		}
+	}
+	if sub.ID == "" {
+		id, err := randomHex(8)
+		if err != nil {
+			return models.WebhookSubscription{}, fmt.Errorf("failed to generate subscription ID: %w", err)
+		}
+		sub.ID = "sub-" + id
+	}
+	sub.CreatedAt = time.Now().UTC()
+
+	entry, err := newSubscription(sub, s.config)
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	previous, replaced := s.subscriptions[sub.ID]
+	s.subscriptions[sub.ID] = entry
+	if err := s.saveSubscriptions(); err != nil {
+		if replaced {
+			s.subscriptions[sub.ID] = previous
+		} else {
+			delete(s.subscriptions, sub.ID)
+		}
+		return models.WebhookSubscription{}, err
+	}
+
+	s.logger.Info("Webhook subscription registered",
+		zap.String("id", sub.ID),
+		zap.String("url", sub.URL),
+		zap.Bool("replaced", replaced),
+	)
+
+	return sub, nil
+}
+
+// Subscriptions returns the subscriptions that have not expired, oldest first, without their
+// secrets
+func (s *Service) Subscriptions() []models.WebhookSubscription {
+	s.subscriptionsMu.RLock()
+	defer s.subscriptionsMu.RUnlock()
+
+	now := time.Now()
+	subscriptions := make([]models.WebhookSubscription, 0, len(s.subscriptions))
+	for _, entry := range s.subscriptions {
+		if entry.expired(now) {
+			continue
+		}
+		sub := entry.WebhookSubscription
+		sub.Secret = ""
+		subscriptions = append(subscriptions, sub)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+	return subscriptions
+}
+
+// Unsubscribe removes a subscription
+func (s *Service) Unsubscribe(id string) error {
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+
+	entry, ok := s.subscriptions[id]
+	if !ok {
+		return ErrSubscriptionNotFound
+	}
+
+	delete(s.subscriptions, id)
+	if err := s.saveSubscriptions(); err != nil {
+		s.subscriptions[id] = entry
+		return err
+	}
+
+	s.logger.Info("Webhook subscription removed", zap.String("id", id), zap.String("url", entry.URL))
+	return nil
+}
+
+// subscriptionTargets returns the targets of the subscriptions matching a message. Expired
+// subscriptions are removed.
+func (s *Service) subscriptionTargets(msg models.Message) []*target {
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+
+	now := time.Now()
+	var matched []*subscription
+	expired := false
+	for id, entry := range s.subscriptions {
+		if entry.expired(now) {
+			s.logger.Info("Webhook subscription expired", zap.String("id", id), zap.String("url", entry.URL))
+			delete(s.subscriptions, id)
+			expired = true
+			continue
+		}
+		if entry.target.match.matches(msg) {
+			matched = append(matched, entry)
+		}
+	}
+
+	if expired {
+		if err := s.saveSubscriptions(); err != nil {
+			s.logger.Error("Failed to save webhook subscriptions", zap.Error(err))
+		}
+	}
+
+	// Map order is random, deliver in registration order
+	sort.Slice(matched, func(i, j int) bool { return matched[i].CreatedAt.Before(matched[j].CreatedAt) })
+	targets := make([]*target, len(matched))
+	for i, entry := range matched {
+		targets[i] = entry.target
+	}
+	return targets
+}
+
+// subscriptionCount returns the number of subscriptions, including expired ones not removed yet
+func (s *Service) subscriptionCount() int {
+	s.subscriptionsMu.RLock()
+	defer s.subscriptionsMu.RUnlock()
+	return len(s.subscriptions)
+}
+
+// loadSubscriptions reads the persisted subscriptions and drops the expired ones
+func (s *Service) loadSubscriptions() error {
+	data, err := os.ReadFile(s.config.Webhook.SubscriptionsPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read webhook subscriptions: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	var stored []models.WebhookSubscription
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to parse webhook subscriptions %s: %w", s.config.Webhook.SubscriptionsPath, err)
+	}
+
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+
+	now := time.Now()
+	for _, sub := range stored {
+		entry, err := newSubscription(sub, s.config)
+		if err != nil {
+			s.logger.Error("Invalid webhook subscription, ignored", zap.String("id", sub.ID), zap.Error(err))
+			continue
+		}
+		if entry.expired(now) {
+			continue
+		}
+		s.subscriptions[sub.ID] = entry
+	}
+
+	s.logger.Info("Webhook subscriptions loaded", zap.Int("count", len(s.subscriptions)))
+	return nil
+}
+
+// saveSubscriptions writes the subscriptions to disk. The caller must hold the lock.
+func (s *Service) saveSubscriptions() error {
+	stored := make([]models.WebhookSubscription, 0, len(s.subscriptions))
+	for _, entry := range s.subscriptions {
+		stored = append(stored, entry.WebhookSubscription)
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].CreatedAt.Before(stored[j].CreatedAt) })
+
+	if err := fileutil.WriteJSONAtomic(s.config.Webhook.SubscriptionsPath, stored); err != nil {
+		return fmt.Errorf("failed to write webhook subscriptions: %w", err)
+	}
+	return nil
+}
+
+// newSubscription validates a subscription and builds its delivery target
+func newSubscription(sub models.WebhookSubscription, cfg *config.Config) (*subscription, error) {
+	rules := subscriptionMatch(sub.Filters)
+	if err := rules.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSubscription, err)
+	}
+	match, err := newMatcher(rules)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSubscription, err)
+	}
+
+	return &subscription{
+		WebhookSubscription: sub,
+		target: &target{
+			name:           "subscription " + sub.ID,
+			url:            sub.URL,
+			signingSecrets: []string{sub.Secret},
+			retryAttempts:  cfg.Webhook.RetryAttempts,
+			match:          match,
+		},
+	}, nil
+}
+
+func (s *subscription) expired(now time.Time) bool {
+	return s.ExpiresAt != nil && !s.ExpiresAt.After(now)
+}
+
+// subscriptionMatch converts subscription filters to the rules of webhook targets
+func subscriptionMatch(filter models.WebhookFilter) config.WebhookMatchConfig {
+	return config.WebhookMatchConfig{
+		From:   filter.From,
+		Rooms:  filter.Rooms,
+		Types:  filter.Types,
+		Events: filter.Events,
+		Body:   filter.Body,
+	}
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+	"jabber-bot/pkg/webhooksig"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestService_Subscriptions(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	secrets := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		// Deliveries are signed with the secret of the subscription
+		if _, err := webhooksig.VerifyRequest(r, []string{secrets[r.URL.Path]}, webhooksig.DefaultTolerance); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received = append(received, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			Timeout:           5 * time.Second,
+			RetryAttempts:     1,
+			SubscriptionsPath: filepath.Join(t.TempDir(), "subscriptions.json"),
+		},
+	}
+	service := NewService(cfg, zaptest.NewLogger(t))
+
+	ops, err := service.Subscribe(models.WebhookSubscription{
+		URL:     server.URL + "/ops",
+		Filters: models.WebhookFilter{Rooms: []string{"ops@conference.example.com"}},
+		Secret:  "ops-secret",
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, ops.ID)
+
+	expiresAt := time.Now().Add(time.Hour)
+	all, err := service.Subscribe(models.WebhookSubscription{URL: server.URL + "/all", ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+	assert.Len(t, all.Secret, 64, "a secret is generated")
+
+	// Registering a URL again replaces its subscription
+	again, err := service.Subscribe(models.WebhookSubscription{URL: server.URL + "/all", ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+	assert.Equal(t, all.ID, again.ID)
+	assert.NotEqual(t, all.Secret, again.Secret)
+	secrets["/ops"] = "ops-secret"
+	secrets["/all"] = again.Secret
+
+	_, err = service.Subscribe(models.WebhookSubscription{URL: server.URL, Filters: models.WebhookFilter{Body: "("}})
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+	_, err = service.Subscribe(models.WebhookSubscription{URL: "ftp://example.com/hook"})
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+
+	list := service.Subscriptions()
+	require.Len(t, list, 2)
+	assert.Equal(t, ops.ID, list[0].ID)
+	assert.Empty(t, list[0].Secret, "secrets are not listed")
+
+	service.sendWebhook(context.Background(), models.Message{From: "ops@conference.example.com/alice", Type: "groupchat", Body: "disk full"})
+	service.sendWebhook(context.Background(), models.Message{From: "bob@example.com/phone", Type: "chat", Body: "hi"})
+	assert.Equal(t, []string{"/ops", "/all", "/all"}, received)
+	assert.Equal(t, int64(0), service.GetStats().TotalFailed, "no webhook.url is not a failure with subscriptions")
+
+	// Subscriptions survive a restart
+	restarted := NewService(cfg, zaptest.NewLogger(t))
+	require.NoError(t, restarted.loadSubscriptions())
+	assert.Equal(t, list, restarted.Subscriptions())
+
+	require.NoError(t, restarted.Unsubscribe(ops.ID))
+	assert.ErrorIs(t, restarted.Unsubscribe(ops.ID), ErrSubscriptionNotFound)
+
+	// Expired subscriptions are dropped
+	restarted.subscriptions[again.ID].ExpiresAt = &time.Time{}
+	assert.Empty(t, restarted.Subscriptions())
+	assert.Empty(t, restarted.targetsFor(models.Message{From: "bob@example.com/phone", Type: "chat"}))
+	assert.Equal(t, 0, restarted.subscriptionCount())
+}
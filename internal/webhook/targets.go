@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// target is a webhook endpoint messages are delivered to
+type target struct {
+	name           string
+	url            string
+	apiKey         string
+	signingSecrets []string      // sign deliveries; none sends them unsigned
+	timeout        time.Duration // 0 uses the timeout of the service HTTP client
+	retryAttempts  int
+	headers        map[string]string
+	match          *matcher // nil matches every message
+}
+
+// matcher evaluates the rules of a webhook target against messages
+type matcher struct {
+	from   []string
+	rooms  []string
+	types  []string
+	events []string
+	body   *regexp.Regexp
+}
+
+// newMatcher compiles the rules of a webhook target. The configuration was validated on load.
+func newMatcher(match config.WebhookMatchConfig) (*matcher, error) {
+	m := &matcher{
+		from:   lowerAll(match.From),
+		rooms:  lowerAll(match.Rooms),
+		types:  match.Types,
+		events: match.Events,
+	}
+	if match.Body != "" {
+		body, err := regexp.Compile(match.Body)
+		if err != nil {
+			return nil, err
+		}
+		m.body = body
+	}
+	return m, nil
+}
+
+// matches reports whether a message satisfies every rule that is set
+func (m *matcher) matches(msg models.Message) bool {
+	if m == nil {
+		return true
+	}
+
+	if len(m.from) > 0 {
+		from := strings.ToLower(bareJID(msg.From))
+		domain := from[strings.Index(from, "@")+1:]
+		if !slices.Contains(m.from, from) && !slices.Contains(m.from, domain) {
+			return false
+		}
+	}
+	if len(m.rooms) > 0 && !slices.Contains(m.rooms, strings.ToLower(messageRoom(msg))) {
+		return false
+	}
+	if len(m.types) > 0 && !slices.Contains(m.types, msg.Type) {
+		return false
+	}
+	if len(m.events) > 0 && !slices.Contains(m.events, messageEvent(msg)) {
+		return false
+	}
+	if m.body != nil && !m.body.MatchString(msg.Body) {
+		return false
+	}
+	return true
+}
+
+// newTargets returns the configured webhook targets. Targets with rules that do not compile
+// are left out.
+func newTargets(cfg *config.Config, logger *zap.Logger) []*target {
+	targets := make([]*target, 0, len(cfg.Webhook.Targets))
+	for _, targetConfig := range cfg.Webhook.Targets {
+		match, err := newMatcher(targetConfig.Match)
+		if err != nil {
+			logger.Error("Invalid webhook target rules, target disabled",
+				zap.String("target", targetConfig.Name),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		retryAttempts := targetConfig.RetryAttempts
+		if retryAttempts == 0 {
+			retryAttempts = cfg.Webhook.RetryAttempts
+		}
+		signingSecrets := targetConfig.SigningSecrets
+		if len(signingSecrets) == 0 {
+			signingSecrets = cfg.Webhook.SigningSecrets
+		}
+		targets = append(targets, &target{
+			name:           targetConfig.Name,
+			url:            targetConfig.URL,
+			apiKey:         targetConfig.APIKey,
+			timeout:        targetConfig.Timeout,
+			retryAttempts:  retryAttempts,
+			headers:        targetConfig.Headers,
+			signingSecrets: signingSecrets,
+			match:          match,
+		})
+	}
+	return targets
+}
+
+// targetsFor returns the targets a message is delivered to: the webhook of the receiving
+// account, when its URL is configured, and every target and subscription whose rules match.
+// Messages without targets are not queued.
+func (s *Service) targetsFor(msg models.Message) []*target {
+	url, apiKey := s.config.WebhookTarget(msg.Account)
+
+	var matched []*target
+	if url != "" {
+		matched = append(matched, s.defaultTarget(url, apiKey))
+	}
+	for _, t := range s.targets {
+		if t.match.matches(msg) {
+			matched = append(matched, t)
+		}
+	}
+	return append(matched, s.subscriptionTargets(msg)...)
+}
+
+// targetByName returns the target with a name, regardless of its rules, or nil when it does not
+// exist (anymore)
+func (s *Service) targetByName(name string, msg models.Message) *target {
+	if name == "default" {
+		return s.defaultTarget(s.config.WebhookTarget(msg.Account))
+	}
+	for _, t := range s.targets {
+		if t.name == name {
+			return t
+		}
+	}
+
+	s.subscriptionsMu.RLock()
+	defer s.subscriptionsMu.RUnlock()
+	now := time.Now()
+	for _, entry := range s.subscriptions {
+		if entry.target.name == name && !entry.expired(now) {
+			return entry.target
+		}
+	}
+	return nil
+}
+
+// defaultTarget returns the target of webhook.url, or of the webhook of an account
+func (s *Service) defaultTarget(url, apiKey string) *target {
+	return &target{
+		name:           "default",
+		url:            url,
+		apiKey:         apiKey,
+		retryAttempts:  s.config.Webhook.RetryAttempts,
+		signingSecrets: s.config.Webhook.SigningSecrets,
+	}
+}
+
+// messageRoom returns the room a message or event belongs to, or "" outside of rooms
+func messageRoom(msg models.Message) string {
+	switch {
+	case msg.Invite != nil:
+		return msg.Invite.Room
+	case msg.Occupant != nil:
+		return msg.Occupant.Room
+	case msg.Type == "groupchat" || msg.MUCPrivate:
+		return bareJID(msg.From)
+	default:
+		return ""
+	}
+}
+
+// messageEvent returns the webhook event of a message
+func messageEvent(msg models.Message) string {
+	if msg.Event == "" {
+		return "message"
+	}
+	return msg.Event
+}
+
+func bareJID(jid string) string {
+	if i := strings.Index(jid, "/"); i >= 0 {
+		return jid[:i]
+	}
+	return jid
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
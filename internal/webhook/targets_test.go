@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMatcher(t *testing.T) {
+	match, err := newMatcher(config.WebhookMatchConfig{
+		From:  []string{"alice@example.com", "Partner.org"},
+		Types: []string{"chat"},
+		Body:  `^deploy\b`,
+	})
+	require.NoError(t, err)
+
+	assert.True(t, match.matches(models.Message{From: "alice@example.com/phone", Type: "chat", Body: "deploy api"}))
+	assert.True(t, match.matches(models.Message{From: "bob@partner.org", Type: "chat", Body: "deploy web"}))
+	assert.False(t, match.matches(models.Message{From: "eve@example.com/laptop", Type: "chat", Body: "deploy api"}))
+	assert.False(t, match.matches(models.Message{From: "alice@example.com/phone", Type: "normal", Body: "deploy api"}))
+	assert.False(t, match.matches(models.Message{From: "alice@example.com/phone", Type: "chat", Body: "please deploy"}))
+
+	rooms, err := newMatcher(config.WebhookMatchConfig{
+		Rooms:  []string{"ops@conference.example.com"},
+		Events: []string{"message", "occupant_joined"},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, rooms.matches(models.Message{From: "ops@conference.example.com/alice", Type: "groupchat"}))
+	assert.True(t, rooms.matches(models.Message{From: "ops@conference.example.com/alice", Type: "chat", MUCPrivate: true}))
+	assert.True(t, rooms.matches(models.Message{
+		Event:    "occupant_joined",
+		Occupant: &models.Occupant{Room: "ops@conference.example.com", Nick: "bob"},
+	}))
+	assert.False(t, rooms.matches(models.Message{
+		Event:  "invite",
+		Invite: &models.Invite{Room: "ops@conference.example.com"},
+	}))
+	assert.False(t, rooms.matches(models.Message{From: "dev@conference.example.com/alice", Type: "groupchat"}))
+	assert.False(t, rooms.matches(models.Message{From: "alice@example.com/phone", Type: "chat"}))
+
+	_, err = newMatcher(config.WebhookMatchConfig{Body: "(unclosed"})
+	assert.Error(t, err)
+}
+
+func TestService_SendWebhook_Targets(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string][]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], r.Header.Get("X-Workflow")+"|"+r.Header.Get("API-Key"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			URL:           server.URL + "/all",
+			Timeout:       5 * time.Second,
+			RetryAttempts: 1,
+			Targets: []config.WebhookTargetConfig{
+				{
+					Name:    "ops",
+					URL:     server.URL + "/ops",
+					APIKey:  "ops-key",
+					Timeout: 2 * time.Second,
+					Headers: map[string]string{"X-Workflow": "ops-alerts"},
+					Match:   config.WebhookMatchConfig{Rooms: []string{"ops@conference.example.com"}},
+				},
+				{
+					Name:  "support",
+					URL:   server.URL + "/support",
+					Match: config.WebhookMatchConfig{From: []string{"customer.org"}, Types: []string{"chat"}},
+				},
+			},
+		},
+	}
+
+	var callbacks int
+	service := NewService(cfg, zaptest.NewLogger(t))
+	service.SetOnMessageSent(func(models.Message) { callbacks++ })
+
+	service.sendWebhook(context.Background(), models.Message{From: "ops@conference.example.com/alice", Type: "groupchat", Body: "disk full"})
+	service.sendWebhook(context.Background(), models.Message{From: "carol@customer.org/web", Type: "chat", Body: "help"})
+	service.sendWebhook(context.Background(), models.Message{From: "dave@example.com/phone", Type: "chat", Body: "hi"})
+
+	assert.Equal(t, map[string][]string{
+		"/all":     {"|", "|", "|"},
+		"/ops":     {"ops-alerts|ops-key"},
+		"/support": {"|"},
+	}, received)
+	assert.Equal(t, 3, callbacks)
+	assert.Equal(t, int64(5), service.GetStats().TotalSent)
+
+	// Without a global URL only matching targets receive messages
+	cfg.Webhook.URL = ""
+	service.sendWebhook(context.Background(), models.Message{From: "dave@example.com/phone", Type: "chat", Body: "hi"})
+	assert.Len(t, received["/all"], 3)
+	assert.Equal(t, int64(0), service.GetStats().TotalFailed)
+}
@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// worker delivers the messages of its queue shard, the (target, conversation) pairs hashed to it, one at a
+// time, so the messages of a conversation reach a target in order while other conversations and
+// targets progress on other workers
+type worker struct {
+	id int
+
+	mu           sync.Mutex
+	target       string    // target being delivered to
+	conversation string    // conversation being delivered
+	busySince    time.Time // zero when idle
+	processed    int64
+}
+
+// WorkerStats reports what a webhook worker is doing
+type WorkerStats struct {
+	Worker       int        `json:"worker"`
+	InFlight     int        `json:"in_flight"` // messages being delivered
+	Queued       int        `json:"queued"`    // messages waiting for the worker
+	Processed    int64      `json:"processed"`
+	Target       string     `json:"target,omitempty"`
+	Conversation string     `json:"conversation,omitempty"`
+	BusySince    *time.Time `json:"busy_since,omitempty"`
+}
+
+// newWorkers creates the webhook workers; fewer than one worker means one
+func newWorkers(n int) []*worker {
+	if n < 1 {
+		n = 1
+	}
+	workers := make([]*worker, n)
+	for i := range workers {
+		workers[i] = &worker{id: i}
+	}
+	return workers
+}
+
+// runWorker delivers the queued messages of a worker until ctx is cancelled
+func (s *Service) runWorker(ctx context.Context, w *worker) {
+	defer s.wg.Done()
+	s.logger.Debug("Starting webhook worker", zap.Int("worker", w.id))
+	defer s.logger.Debug("Webhook worker stopped", zap.Int("worker", w.id))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		changed := s.queue.wait(w.id)
+		entry, ok := s.queue.next(w.id)
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+			continue
+		}
+
+		w.begin(entry.Target, conversationKey(entry.Message))
+		err := s.processQueued(ctx, entry)
+		w.end()
+
+		if err != nil {
+			// Interrupted by shutdown, the message is delivered again on the next start
+			s.queue.release(w.id)
+			return
+		}
+
+		if err := s.queue.done(w.id, entry.ID); err != nil {
+			s.logger.Error("Failed to remove delivered message from webhook queue",
+				zap.Error(err),
+				zap.String("id", entry.ID),
+			)
+		}
+		s.forgetGroup(entry.Group)
+	}
+}
+
+// workerFor returns the worker delivering the messages of a conversation to the target of an entry
+func (s *Service) workerFor(entry queuedMessage) *worker {
+	return s.workers[s.queue.shardFor(entry)]
+}
+
+// WorkerStats returns the state of each webhook worker
+func (s *Service) WorkerStats() []WorkerStats {
+	stats := make([]WorkerStats, len(s.workers))
+	for i, w := range s.workers {
+		w.mu.Lock()
+		stats[i] = WorkerStats{
+			Worker:       w.id,
+			Processed:    w.processed,
+			Target:       w.target,
+			Conversation: w.conversation,
+		}
+		if !w.busySince.IsZero() {
+			busySince := w.busySince
+			stats[i].InFlight = 1
+			stats[i].BusySince = &busySince
+		}
+		w.mu.Unlock()
+
+		stats[i].Queued = s.queue.waiting(w.id)
+	}
+	return stats
+}
+
+func (w *worker) begin(target, conversation string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.target = target
+	w.conversation = conversation
+	w.busySince = time.Now().UTC()
+}
+
+func (w *worker) end() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.target = ""
+	w.conversation = ""
+	w.busySince = time.Time{}
+	w.processed++
+}
+
+// conversationKey returns the conversation a message belongs to: its room, or the bare JID of
+// its sender
+func conversationKey(msg models.Message) string {
+	if room := messageRoom(msg); room != "" {
+		return room
+	}
+	return bareJID(msg.From)
+}
@@ -0,0 +1,202 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestService_Workers(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload models.WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		if payload.Message.Body == "slow 1" {
+			<-release
+		}
+		mu.Lock()
+		received = append(received, payload.Message.Body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			URL:           server.URL,
+			Timeout:       5 * time.Second,
+			RetryAttempts: 1,
+			Workers:       4,
+		},
+	}
+	service := NewService(cfg, zaptest.NewLogger(t))
+	require.Len(t, service.workers, 4)
+
+	slow := models.Message{From: "slow@example.com/phone", Type: "chat"}
+	fast := models.Message{From: "user0@example.com/phone", Type: "chat"}
+	for i := 1; workerOf(service, fast) == workerOf(service, slow); i++ {
+		fast.From = fmt.Sprintf("user%d@example.com/phone", i)
+	}
+	// Messages of a room share the worker of the room
+	inRoom := models.Message{From: "ops@conference.example.com/alice", Type: "groupchat"}
+	assert.Equal(t, workerOf(service, inRoom), workerOf(service, models.Message{From: "ops@conference.example.com/bob", Type: "groupchat"}))
+
+	require.NoError(t, service.Start())
+	defer service.Stop()
+
+	slow.Body = "slow 1"
+	require.NoError(t, service.SendMessage(slow))
+	slow.Body = "slow 2"
+	require.NoError(t, service.SendMessage(slow))
+	fast.Body = "fast"
+	require.NoError(t, service.SendMessage(fast))
+
+	// The slow endpoint does not hold up other conversations
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"fast"}, received)
+
+	stats := service.WorkerStats()
+	busy := stats[workerOf(service, slow).id]
+	assert.Equal(t, 1, busy.InFlight)
+	assert.Equal(t, 1, busy.Queued)
+	assert.Equal(t, "default", busy.Target)
+	assert.Equal(t, "slow@example.com", busy.Conversation)
+	assert.NotNil(t, busy.BusySince)
+	idle := stats[workerOf(service, fast).id]
+	assert.Equal(t, 0, idle.InFlight)
+	assert.Equal(t, int64(1), idle.Processed)
+
+	// Messages of a conversation are delivered in order
+	close(release)
+	require.Eventually(t, func() bool { return service.GetQueueLength() == 0 }, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, []string{"fast", "slow 1", "slow 2"}, received)
+	mu.Unlock()
+}
+
+// workerOf returns the worker delivering a message to the default target
+func workerOf(service *Service, msg models.Message) *worker {
+	return service.workerFor(queuedMessage{Message: msg, Target: "default"})
+}
+
+func TestService_Workers_IndependentTargets(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+		mu.Lock()
+		received = append(received, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			URL:           server.URL + "/slow",
+			Timeout:       5 * time.Second,
+			RetryAttempts: 1,
+			Workers:       4,
+			Targets: []config.WebhookTargetConfig{
+				{Name: "ops", URL: server.URL + "/ops"},
+			},
+		},
+	}
+	service := NewService(cfg, zaptest.NewLogger(t))
+	var callbacks int
+	service.SetOnMessageSent(func(models.Message) {
+		mu.Lock()
+		callbacks++
+		mu.Unlock()
+	})
+
+	msg := models.Message{From: "alice@example.com/phone", Type: "chat", Body: "hi"}
+	for i := 1; service.workerFor(queuedMessage{Message: msg, Target: "default"}) == service.workerFor(queuedMessage{Message: msg, Target: "ops"}); i++ {
+		msg.From = fmt.Sprintf("user%d@example.com/phone", i)
+	}
+
+	require.NoError(t, service.Start())
+	defer service.Stop()
+
+	require.NoError(t, service.SendMessage(msg))
+	require.NoError(t, service.SendMessage(msg))
+	assert.Equal(t, 4, service.GetQueueLength(), "a message is queued once per target")
+
+	// The slow target does not hold up the deliveries of the message to other targets
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, []string{"/ops", "/ops"}, received)
+	assert.Equal(t, 2, callbacks)
+	mu.Unlock()
+
+	// The callback runs once per message, whichever target received it first
+	release <- struct{}{}
+	release <- struct{}{}
+	require.Eventually(t, func() bool { return service.GetQueueLength() == 0 }, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, 2, callbacks)
+	mu.Unlock()
+	assert.Eventually(t, func() bool {
+		service.mu.RLock()
+		defer service.mu.RUnlock()
+		return len(service.notified) == 0
+	}, 5*time.Second, 10*time.Millisecond, "the callback state is dropped once the message is delivered")
+}
+
+func TestService_Stop_InterruptsRetries(t *testing.T) {
+	attempts := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts <- struct{}{}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			URL:           server.URL,
+			Timeout:       5 * time.Second,
+			RetryAttempts: 3,
+		},
+	}
+	service := NewService(cfg, zaptest.NewLogger(t))
+	require.NoError(t, service.Start())
+	require.NoError(t, service.SendMessage(models.Message{From: "alice@example.com/phone", Body: "hi"}))
+	<-attempts
+
+	// Stop does not wait for the backoff between attempts
+	start := time.Now()
+	require.NoError(t, service.Stop())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// The interrupted message stays queued instead of becoming a dead letter
+	assert.Equal(t, 1, service.GetQueueLength())
+	assert.Empty(t, service.DeadLetters())
+	entry, ok := service.queue.next(0)
+	require.True(t, ok)
+	assert.Equal(t, "hi", entry.Message.Body)
+}
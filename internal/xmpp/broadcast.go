@@ -0,0 +1,57 @@
+package xmpp
+
+import (
+	"sync"
+
+	"jabber-bot/internal/models"
+)
+
+// broadcastConcurrency is the number of broadcast messages sent at the same time. It also bounds
+// the share of the rate limit queue a single broadcast can take.
+const broadcastConcurrency = 8
+
+// MaxBroadcastBodyLength is the largest body, in bytes, of a broadcast message
+const MaxBroadcastBodyLength = 10000
+
+// Broadcast sends messages concurrently through the send path, so they are paced by the rate
+// limits and queued while disconnected like single sends. The results are in message order.
+func (m *Manager) Broadcast(account string, messages []models.BroadcastMessage) []models.BroadcastResult {
+	results := make([]models.BroadcastResult, len(messages))
+	slots := make(chan struct{}, broadcastConcurrency)
+
+	var wg sync.WaitGroup
+	for i, msg := range messages {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = m.broadcastMessage(account, msg)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (m *Manager) broadcastMessage(account string, msg models.BroadcastMessage) models.BroadcastResult {
+	var result models.SendResult
+	var err error
+	if msg.Room {
+		result, err = m.SendMUCMessage(account, msg.To, msg.Body, "", nil)
+	} else {
+		result, err = m.SendMessage(account, "", msg.To, msg.Body, msg.Type)
+	}
+
+	if err != nil {
+		return models.BroadcastResult{To: msg.To, Room: msg.Room, Error: err.Error()}
+	}
+	return models.BroadcastResult{
+		To:        msg.To,
+		Room:      msg.Room,
+		Success:   true,
+		Queued:    result.Queued,
+		MessageID: result.MessageID,
+		DelayMs:   result.Delay.Milliseconds(),
+	}
+}
@@ -0,0 +1,52 @@
+package xmpp
+
+import (
+	"testing"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"gosrc.io/xmpp/stanza"
+)
+
+func TestManager_Broadcast(t *testing.T) {
+	cfg := &config.Config{}
+	manager := NewManager(cfg, zaptest.NewLogger(t))
+	client, stream := newStreamClient(t, cfg)
+	manager.clients[config.DefaultAccount] = client
+
+	var messages []models.BroadcastMessage
+	for _, jid := range []string{"alice@example.org", "bob@example.org", "carol@example.org"} {
+		messages = append(messages, models.BroadcastMessage{To: jid, Body: "Maintenance at 22:00", Type: "chat"})
+	}
+	messages = append(messages, models.BroadcastMessage{To: "ops@conference.example.org", Room: true, Body: "Maintenance at 22:00"})
+
+	results := manager.Broadcast("", messages)
+	require.Len(t, results, 4)
+	for i, result := range results {
+		assert.True(t, result.Success, result.Error)
+		assert.Equal(t, messages[i].To, result.To)
+	}
+	assert.True(t, results[3].Room)
+
+	recipients := make(map[string]stanza.StanzaType)
+	for _, packet := range stream.sent {
+		msg := packet.(stanza.Message)
+		recipients[msg.To] = msg.Type
+	}
+	assert.Equal(t, map[string]stanza.StanzaType{
+		"alice@example.org":          "chat",
+		"bob@example.org":            "chat",
+		"carol@example.org":          "chat",
+		"ops@conference.example.org": "groupchat",
+	}, recipients)
+
+	// Failures are reported per message
+	results = manager.Broadcast("support", messages[:1])
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Success)
+	assert.Contains(t, results[0].Error, "Unknown")
+}
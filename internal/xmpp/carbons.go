@@ -0,0 +1,84 @@
+package xmpp
+
+import (
+	"encoding/xml"
+
+	"go.uber.org/zap"
+	"gosrc.io/xmpp/stanza"
+)
+
+const (
+	nsCarbons = "urn:xmpp:carbons:2"
+	nsForward = "urn:xmpp:forward:0"
+)
+
+// CarbonsEnable is the IQ payload enabling message carbons (XEP-0280)
+type CarbonsEnable struct {
+	XMLName xml.Name `xml:"urn:xmpp:carbons:2 enable"`
+}
+
+func (e CarbonsEnable) Namespace() string {
+	return nsCarbons
+}
+
+func (e CarbonsEnable) GetSet() *stanza.ResultSet {
+	return nil
+}
+
+// CarbonReceived wraps a copy of a message delivered to another resource of the account
+type CarbonReceived struct {
+	XMLName   xml.Name  `xml:"urn:xmpp:carbons:2 received"`
+	Forwarded Forwarded `xml:"urn:xmpp:forward:0 forwarded"`
+}
+
+// CarbonSent wraps a copy of a message sent by another resource of the account
+type CarbonSent struct {
+	XMLName   xml.Name  `xml:"urn:xmpp:carbons:2 sent"`
+	Forwarded Forwarded `xml:"urn:xmpp:forward:0 forwarded"`
+}
+
+// Forwarded is a forwarded stanza (XEP-0297)
+type Forwarded struct {
+	Message stanza.Message `xml:"jabber:client message"`
+}
+
+func init() {
+	stanza.TypeRegistry.MapExtension(stanza.PKTMessage, xml.Name{Space: nsCarbons, Local: "received"}, CarbonReceived{})
+	stanza.TypeRegistry.MapExtension(stanza.PKTMessage, xml.Name{Space: nsCarbons, Local: "sent"}, CarbonSent{})
+}
+
+// enableCarbons asks the server to copy messages of the other resources of the account to the bot
+func (c *Client) enableCarbons() {
+	if !c.config.XMPP.Carbons || c.isComponent() {
+		return
+	}
+
+	iq := newIQ("carbons", stanza.IQTypeSet, "", &CarbonsEnable{})
+	if _, err := c.sendIQ(iq, defaultIQTimeout); err != nil {
+		c.logger.Error("Failed to enable message carbons", zap.Error(err))
+		return
+	}
+
+	c.logger.Info("Message carbons enabled")
+}
+
+// unwrapCarbon returns the message to handle, and false if it is dropped. Copies of messages
+// received by other resources are handled like messages to the bot; copies of sent messages are
+// dropped. Carbons must come from our own bare JID, otherwise anyone could inject messages
+// (XEP-0280 section 11).
+func (c *Client) unwrapCarbon(msg stanza.Message) (stanza.Message, bool) {
+	var received CarbonReceived
+	if msg.Get(&received) {
+		if msg.From != bareJID(c.config.XMPP.JID) {
+			return stanza.Message{}, false
+		}
+		return received.Forwarded.Message, true
+	}
+
+	var sent CarbonSent
+	if msg.Get(&sent) {
+		return stanza.Message{}, false
+	}
+
+	return msg, true
+}
@@ -8,7 +8,9 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
@@ -32,17 +34,37 @@ type UploadSlot struct {
 type Client struct {
 	config       *config.Config
 	logger       *zap.Logger
-	client       *xmpp.Client
+	client       xmpp.StreamClient
 	router       *xmpp.Router
 	connected    int32
 	messageChan  chan models.Message
 	mu           sync.RWMutex
 	cancelFunc   context.CancelFunc
 	streamLogger *os.File
+	resolver     srvResolver // looks up the server when none is configured
+	address      string      // address of the current connection
+
+	// Each dial attempt gets a sequence number; events of streams other than the active one
+	// are ignored, so a late error from a replaced connection cannot trigger a reconnect
+	streamSeq    uint64
+	activeStream uint64
+	reconnectCh  chan struct{}
+	stats        connectionStats
+	statsMu      sync.Mutex
 
 	// Track the actual connection state reported by the XMPP library
 	// This is updated by the EventHandler when the library reports state changes
 	libraryConnected int32
+
+	// Joined MUC rooms keyed by bare room JID
+	rooms   map[string]*MUCRoom
+	roomsMu sync.RWMutex
+
+	// Domains confirmed as multi-user chat services by service discovery, guarded by roomsMu
+	mucServices map[string]bool
+
+	// Outbound rate limit shared by the accounts of the manager, nil when disabled
+	limiter *rateLimiter
 }
 
 // NewClient creates new XMPP client
@@ -51,13 +73,30 @@ func NewClient(cfg *config.Config, logger *zap.Logger) *Client {
 		config:      cfg,
 		logger:      logger,
 		messageChan: make(chan models.Message, 100),
+		rooms:       make(map[string]*MUCRoom),
+		mucServices: make(map[string]bool),
+		resolver:    net.DefaultResolver,
+		reconnectCh: make(chan struct{}, 1),
 	}
 }
 
 // Connect establishes XMPP connection
 func (c *Client) Connect(ctx context.Context) error {
+	return c.connect(ctx, false)
+}
+
+// ConnectOrRetry establishes XMPP connection like Connect. When the first connection fails and
+// reconnection is enabled, the client keeps connecting in the background with the reconnection
+// backoff; the error is returned either way.
+func (c *Client) ConnectOrRetry(ctx context.Context) error {
+	return c.connect(ctx, true)
+}
+
+func (c *Client) connect(ctx context.Context, retry bool) error {
 	ctx, cancel := context.WithCancel(ctx)
+	c.mu.Lock()
 	c.cancelFunc = cancel
+	c.mu.Unlock()
 
 	// Create temporary file for XMPP stream logging
 	tempFile, err := os.CreateTemp("", "xmpp-stream-*.log")
@@ -69,71 +108,211 @@ func (c *Client) Connect(ctx context.Context) error {
 		// Start goroutine to monitor and read from temp file
 		go c.monitorXMPPStreamLogs(tempFile)
 	}
-	c.streamLogger = tempFile
-
-	// Create XMPP client configuration
-	clientConfig := xmpp.Config{
-		TransportConfiguration: xmpp.TransportConfiguration{
-			Address: c.config.XMPP.Server,
-		},
-		Jid:          c.config.XMPP.JID,
-		Credential:   xmpp.Password(c.config.XMPP.Password),
-		StreamLogger: tempFile,
-	}
-
 	// Create router
+	c.mu.Lock()
+	c.streamLogger = tempFile
 	c.router = xmpp.NewRouter()
+	c.mu.Unlock()
 	c.setupHandlers()
 
-	// Create XMPP client
-	client, err := xmpp.NewClient(&clientConfig, c.router, func(err error) {
-		c.logger.Error("XMPP error", zap.Error(err))
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create XMPP client: %w", err)
-	}
-	c.client = client
-
-	// Set up event handler to track actual connection state from the XMPP library
-	c.client.SetHandler(c.handleConnectionEvent)
-
-	// Connect to XMPP server
-	if err := c.client.Connect(); err != nil {
-		return fmt.Errorf("failed to connect to XMPP server: %w", err)
+	if err := c.dial(ctx); err != nil {
+		c.recordError(err)
+		if retry && c.config.Reconnection.Enabled {
+			go c.selfPingLoop(ctx)
+			go c.handleReconnection(ctx)
+			c.reconnectCh <- struct{}{}
+		}
+		return err
 	}
 
 	c.setConnected(true)
 	atomic.StoreInt32(&c.libraryConnected, 1)
 	c.logger.Info("Successfully connected to XMPP server",
+		zap.String("mode", c.config.XMPP.Mode),
 		zap.String("jid", c.config.XMPP.JID),
-		zap.String("server", c.config.XMPP.Server),
+		zap.String("server", c.serverAddress()),
 	)
 
+	c.recordConnected(false)
+
+	// Join rooms from configuration
+	c.joinConfiguredRooms()
+	c.enableCarbons()
+	go c.selfPingLoop(ctx)
+
 	// Start reconnection handler
 	go c.handleReconnection(ctx)
 
 	return nil
 }
 
-// Disconnect closes XMPP connection
-func (c *Client) Disconnect() error {
+// dial creates the stream client, an external component (XEP-0114) in component mode, and
+// connects it. Without a configured server the candidate addresses are resolved from DNS SRV
+// records, or the WebSocket endpoint from host-meta, on every call, so reconnects follow
+// changes to the cluster.
+func (c *Client) dial(ctx context.Context) error {
+	var proxyURL *url.URL
+	if c.config.XMPP.Proxy != "" {
+		var err error
+		if proxyURL, err = url.Parse(c.config.XMPP.Proxy); err != nil {
+			return fmt.Errorf("invalid proxy: %w", err)
+		}
+	}
+
+	if c.isComponent() {
+		seq := atomic.AddUint64(&c.streamSeq, 1)
+		component, err := c.newComponent(proxyURL, seq)
+		if err != nil {
+			return fmt.Errorf("failed to create XMPP client: %w", err)
+		}
+		return c.connectStream(component, c.config.XMPP.Server, seq)
+	}
+
+	tlsConfig, err := buildTLSConfig(c.config.XMPP.TLS)
+	if err != nil {
+		return fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+
+	// Each account has its own HTTP client for WebSocket connections and host-meta discovery
+	httpClient := &http.Client{Transport: newHTTPTransport(tlsConfig, proxyURL)}
+
+	directTLS := c.config.XMPP.TLS.Mode == config.TLSModeDirect
+	targets := []serverTarget{{Address: c.config.XMPP.Server, DirectTLS: directTLS}}
+	switch {
+	case c.config.XMPP.Transport == config.TransportWebSocket:
+		if c.config.XMPP.Server == "" {
+			endpoint, err := discoverWebSocket(ctx, httpClient, jidDomain(c.config.XMPP.JID))
+			if err != nil {
+				return fmt.Errorf("failed to discover XMPP WebSocket endpoint: %w", err)
+			}
+			c.logger.Info("Discovered XMPP WebSocket endpoint", zap.String("server", endpoint))
+			targets = []serverTarget{{Address: endpoint}}
+		}
+	case c.config.XMPP.Server == "":
+		targets = resolveServer(ctx, c.resolver, jidDomain(c.config.XMPP.JID), c.logger)
+	}
+
+	// Disconnect closes the stream logger, possibly while a reconnect dials
+	c.mu.RLock()
+	streamLogger := c.streamLogger
+	c.mu.RUnlock()
+
+	var lastErr error
+	for _, target := range targets {
+		clientConfig := xmpp.Config{
+			TransportConfiguration: xmpp.TransportConfiguration{
+				TLSConfig:  tlsConfig,
+				HTTPClient: httpClient,
+			},
+			Jid:          c.config.XMPP.JID,
+			Credential:   credential(c.config.XMPP),
+			StreamLogger: streamLogger,
+		}
+
+		// Each target is secured the way its record says; the library has no Direct TLS
+		if target.DirectTLS || config.NegotiatedByBot(c.config.XMPP) {
+			clientConfig.Address = negotiatedAddress(target)
+			clientConfig.Dial = c.negotiatedDialer(proxyURL, target.DirectTLS, tlsConfig)
+			clientConfig.Negotiated = true
+		} else {
+			clientConfig.Address = target.Address
+			clientConfig.Dial = proxyDialer(proxyURL)
+		}
+
+		seq := atomic.AddUint64(&c.streamSeq, 1)
+		client, err := xmpp.NewClient(&clientConfig, c.router, func(err error) {
+			c.logger.Error("XMPP error", zap.Error(err))
+			c.connectionLost(seq, err)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create XMPP client: %w", err)
+		}
+
+		if lastErr = c.connectStream(client, target.Address, seq); lastErr == nil {
+			return nil
+		}
+		if len(targets) > 1 {
+			c.logger.Warn("Failed to connect to XMPP server address",
+				zap.String("server", target.Address),
+				zap.Error(lastErr),
+			)
+		}
+	}
+
+	return lastErr
+}
+
+// connectStream connects a stream client and makes it the current connection
+func (c *Client) connectStream(client xmpp.StreamClient, address string, seq uint64) error {
+	// Set up event handler to track actual connection state from the XMPP library
+	client.SetHandler(func(event xmpp.Event) error {
+		return c.handleConnectionEvent(seq, &event)
+	})
+
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to XMPP server %s: %w", address, describeConnectError(c.config.XMPP, err))
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	// Events of the previous stream are ignored from now on
+	atomic.StoreUint64(&c.activeStream, seq)
+	if c.client != nil {
+		// The library waits for the server to close the stream, which a lost connection
+		// never does, so the previous stream is closed in the background
+		go c.closeStream(c.client)
+	}
+	c.client = client
+	c.address = address
+	return nil
+}
+
+// closeStream disconnects a stream client that is no longer the current connection
+func (c *Client) closeStream(client xmpp.StreamClient) {
+	if err := client.Disconnect(); err != nil {
+		c.logger.Debug("Error closing previous XMPP stream", zap.Error(err))
+	}
+}
+
+// stream returns the current stream client
+func (c *Client) stream() xmpp.StreamClient {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.client
+}
+
+// serverAddress returns the address of the current connection
+func (c *Client) serverAddress() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.address
+}
+
+// Disconnect closes XMPP connection
+func (c *Client) Disconnect() error {
+	c.mu.Lock()
 	if c.cancelFunc != nil {
 		c.cancelFunc()
 	}
 
 	c.setConnected(false)
 	atomic.StoreInt32(&c.libraryConnected, 0)
+	client := c.client
+	c.mu.Unlock()
 
-	if c.client != nil {
-		if err := c.client.Disconnect(); err != nil {
+	// The lock is not held while the library waits for the server to close the stream, since
+	// the handlers of the stanzas received meanwhile use the client
+	if client != nil {
+		if err := client.Disconnect(); err != nil {
 			c.logger.Error("Error during XMPP disconnect", zap.Error(err))
 			return err
 		}
 	}
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	// Clean up stream logger temp file if it exists
 	if c.streamLogger != nil {
 		//goland:noinspection GoUnhandledErrorResult
@@ -148,8 +327,9 @@ func (c *Client) Disconnect() error {
 	return nil
 }
 
-// SendMessage sends message to specified JID
-func (c *Client) SendMessage(to, body, messageType string) error {
+// SendMessage sends message to specified JID. In component mode from selects the
+// localpart of the sender address; empty uses the default sender.
+func (c *Client) SendMessage(from, to, body, messageType string) error {
 	if !c.isConnected() {
 		return fmt.Errorf("XMPP client is not connected")
 	}
@@ -158,12 +338,30 @@ func (c *Client) SendMessage(to, body, messageType string) error {
 		messageType = "chat"
 	}
 
+	sender, err := c.senderJID(from)
+	if err != nil {
+		return err
+	}
+
+	if limit := c.config.XMPP.MaxBodySize; limit > 0 && len(body) > limit {
+		return c.sendLongBody(to, false, body, func(part string) error {
+			return c.sendMessage(sender, to, part, messageType, "")
+		})
+	}
+
+	return c.sendMessage(sender, to, body, messageType, "")
+}
+
+// sendMessage sends a message stanza from the given sender address, in a thread when set
+func (c *Client) sendMessage(sender, to, body, messageType, thread string) error {
 	msg := stanza.Message{
 		Attrs: stanza.Attrs{
+			From: sender,
 			To:   to,
 			Type: stanza.StanzaType(messageType),
 		},
-		Body: body,
+		Body:   body,
+		Thread: thread,
 	}
 
 	// XEP-0184: Request delivery receipt for chat messages (not groupchat)
@@ -176,7 +374,12 @@ func (c *Client) SendMessage(to, body, messageType string) error {
 		msg.Extensions = append(msg.Extensions, stanza.StateActive{})
 	}
 
-	if err := c.client.Send(msg); err != nil {
+	// Replies to room occupants are MUC private messages (XEP-0045 7.5)
+	if messageType != "groupchat" && c.isOccupantJID(to) {
+		msg.Extensions = append(msg.Extensions, MUCUser{})
+	}
+
+	if err := c.stream().Send(msg); err != nil {
 		c.logger.Error("Failed to send XMPP message",
 			zap.String("to", to),
 			zap.Error(err),
@@ -193,25 +396,48 @@ func (c *Client) SendMessage(to, body, messageType string) error {
 	return nil
 }
 
-// SendMUCMessage sends message to Multi-User Chat room
-func (c *Client) SendMUCMessage(room, body, subject string) error {
+// SendMUCMessage sends message to Multi-User Chat room.
+// Mentioned nicks are highlighted with XEP-0372 references.
+func (c *Client) SendMUCMessage(room, body, subject string, mentions []string) error {
 	if !c.isConnected() {
 		return fmt.Errorf("XMPP client is not connected")
 	}
 
+	// Missing nicks are prefixed before the size check, so the prefix counts against the limit
+	body = prefixMentions(body, mentions)
+
+	if limit := c.config.XMPP.MaxBodySize; limit > 0 && len(body) > limit {
+		// The subject goes with the first part; every part references the nicks it contains
+		return c.sendLongBody(room, true, body, func(part string) error {
+			err := c.sendMUCMessage(room, part, subject, mentionsIn(part, mentions), "")
+			subject = ""
+			return err
+		})
+	}
+
+	return c.sendMUCMessage(room, body, subject, mentions, "")
+}
+
+// sendMUCMessage sends a groupchat message stanza to a room, in a thread when set
+func (c *Client) sendMUCMessage(room, body, subject string, mentions []string, thread string) error {
 	msg := stanza.Message{
 		Attrs: stanza.Attrs{
 			To:   room,
 			Type: stanza.StanzaType("groupchat"),
 		},
-		Body: body,
+		Body:   body,
+		Thread: thread,
+	}
+
+	if len(mentions) > 0 {
+		msg.Body, msg.Extensions = buildMentions(bareJID(room), body, mentions)
 	}
 
 	if subject != "" {
 		msg.Subject = subject
 	}
 
-	if err := c.client.Send(msg); err != nil {
+	if err := c.stream().Send(msg); err != nil {
 		c.logger.Error("Failed to send MUC message",
 			zap.String("room", room),
 			zap.Error(err),
@@ -252,7 +478,7 @@ func (c *Client) SendChatState(to string, state ChatState) error {
 	msg := stanza.Message{
 		Attrs: stanza.Attrs{
 			To:   to,
-			Type: stanza.StanzaType("chat"),
+			Type: c.messageTypeFor(to),
 		},
 		Body: "",
 		Extensions: []stanza.MsgExtension{
@@ -260,7 +486,7 @@ func (c *Client) SendChatState(to string, state ChatState) error {
 		},
 	}
 
-	if err := c.client.Send(msg); err != nil {
+	if err := c.stream().Send(msg); err != nil {
 		c.logger.Error("Failed to send chat state notification",
 			zap.String("to", to),
 			zap.String("state", string(state)),
@@ -277,14 +503,21 @@ func (c *Client) SendChatState(to string, state ChatState) error {
 	return nil
 }
 
-// SendDeliveryReceipt sends a delivery receipt (XEP-0184)
-func (c *Client) SendDeliveryReceipt(to, messageID string) error {
+// SendDeliveryReceipt sends a delivery receipt (XEP-0184). from is the address the
+// acknowledged message was sent to; components answer from that address.
+func (c *Client) SendDeliveryReceipt(from, to, messageID string) error {
 	if to == "" || messageID == "" {
 		return fmt.Errorf("recipient and message ID are required")
 	}
 
+	var sender string
+	if c.isComponent() {
+		sender = bareJID(from)
+	}
+
 	receipt := stanza.Message{
 		Attrs: stanza.Attrs{
+			From: sender,
 			To:   to,
 			Type: stanza.StanzaType("chat"),
 		},
@@ -293,7 +526,7 @@ func (c *Client) SendDeliveryReceipt(to, messageID string) error {
 		},
 	}
 
-	if err := c.client.Send(receipt); err != nil {
+	if err := c.stream().Send(receipt); err != nil {
 		c.logger.Error("Failed to send delivery receipt",
 			zap.String("to", to),
 			zap.String("message_id", messageID),
@@ -322,7 +555,7 @@ func (c *Client) SendFile(to, fileURL, fileName, fileType string) error {
 	msg := stanza.Message{
 		Attrs: stanza.Attrs{
 			To:   to,
-			Type: stanza.StanzaType("chat"),
+			Type: c.messageTypeFor(to),
 		},
 		Body: body,
 	}
@@ -335,13 +568,15 @@ func (c *Client) SendFile(to, fileURL, fileName, fileType string) error {
 	}
 	msg.Extensions = append(msg.Extensions, oob)
 
-	// Request delivery receipt
-	msg.Extensions = append(msg.Extensions, stanza.ReceiptRequest{})
+	// Request delivery receipt, which rooms do not send
+	if msg.Type != stanza.MessageTypeGroupchat {
+		msg.Extensions = append(msg.Extensions, stanza.ReceiptRequest{})
+	}
 
 	// Add active chat state
 	msg.Extensions = append(msg.Extensions, stanza.StateActive{})
 
-	if err := c.client.Send(msg); err != nil {
+	if err := c.stream().Send(msg); err != nil {
 		c.logger.Error("Failed to send file",
 			zap.String("to", to),
 			zap.String("file", fileName),
@@ -373,7 +608,7 @@ func (c *Client) discoverUploadService(serverDomain string) (string, error) {
 		Payload: &stanza.DiscoItems{},
 	}
 
-	respChan, err := c.client.SendIQ(context.Background(), &iq)
+	respChan, err := c.stream().SendIQ(context.Background(), &iq)
 	if err != nil {
 		return "", fmt.Errorf("failed to send service discovery request: %w", err)
 	}
@@ -411,21 +646,6 @@ func (c *Client) SendFileXEP0363(to, filePath, fileName, fileType string) error
 		return fmt.Errorf("XMPP client is not connected")
 	}
 
-	domain := strings.Split(c.config.XMPP.JID, "@")
-	if len(domain) < 2 {
-		return fmt.Errorf("invalid JID format")
-	}
-	serverDomain := domain[1]
-
-	uploadService, err := c.discoverUploadService(serverDomain)
-	if err != nil {
-		return fmt.Errorf("failed to discover upload service: %w", err)
-	}
-
-	c.logger.Info("Discovered upload service",
-		zap.String("upload_service", uploadService),
-	)
-
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
@@ -442,18 +662,9 @@ func (c *Client) SendFileXEP0363(to, filePath, fileName, fileType string) error
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
-	slot, err := c.requestUploadSlot(uploadService, fileName, size, fileType)
+	slot, err := c.uploadData(fileName, fileType, fileData)
 	if err != nil {
-		return fmt.Errorf("failed to request upload slot: %w", err)
-	}
-
-	timeout := c.config.FileTransfer.Timeout
-	if timeout == 0 {
-		timeout = 60 * time.Second
-	}
-
-	if err := c.uploadFileToURL(slot.PutURL, fileData, fileType, timeout); err != nil {
-		return fmt.Errorf("failed to upload file: %w", err)
+		return err
 	}
 
 	if err := c.sendFileWithXEP0447(to, slot.GetURL, fileName, fileType, size, fileData); err != nil {
@@ -470,6 +681,40 @@ func (c *Client) SendFileXEP0363(to, filePath, fileName, fileType string) error
 	return nil
 }
 
+// uploadData uploads data through the HTTP File Upload service of the server (XEP-0363)
+func (c *Client) uploadData(fileName, fileType string, fileData []byte) (*UploadSlot, error) {
+	domain := strings.Split(c.config.XMPP.JID, "@")
+	if len(domain) < 2 {
+		return nil, fmt.Errorf("invalid JID format")
+	}
+	serverDomain := domain[1]
+
+	uploadService, err := c.discoverUploadService(serverDomain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover upload service: %w", err)
+	}
+
+	c.logger.Info("Discovered upload service",
+		zap.String("upload_service", uploadService),
+	)
+
+	slot, err := c.requestUploadSlot(uploadService, fileName, int64(len(fileData)), fileType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request upload slot: %w", err)
+	}
+
+	timeout := c.config.FileTransfer.Timeout
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+
+	if err := c.uploadFileToURL(slot.PutURL, fileData, fileType, timeout); err != nil {
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	return slot, nil
+}
+
 func (c *Client) sendFileWithXEP0447(to, fileURL, fileName, fileType string, size int64, fileData []byte) error {
 	hash := sha256.Sum256(fileData)
 	hashBase64 := base64.StdEncoding.EncodeToString(hash[:])
@@ -517,7 +762,7 @@ func (c *Client) sendFileWithXEP0447(to, fileURL, fileName, fileType string, siz
 	msg.Extensions = append(msg.Extensions, stanza.ReceiptRequest{})
 	msg.Extensions = append(msg.Extensions, stanza.StateActive{})
 
-	if err := c.client.Send(msg); err != nil {
+	if err := c.stream().Send(msg); err != nil {
 		c.logger.Error("Failed to send file via XEP-0447",
 			zap.String("to", to),
 			zap.String("file", fileName),
@@ -566,7 +811,7 @@ func (c *Client) requestUploadSlot(uploadService, filename string, size int64, c
 		},
 	}
 
-	respChan, err := c.client.SendIQ(context.Background(), &iq)
+	respChan, err := c.stream().SendIQ(context.Background(), &iq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send upload slot request: %w", err)
 	}
@@ -685,11 +930,30 @@ func (c *Client) setupHandlers() {
 			return
 		}
 
+		// Carbon copies carry the message in a forwarded wrapper (XEP-0280)
+		if msg, ok = c.unwrapCarbon(msg); !ok {
+			return
+		}
+
+		// MUC invitations usually carry no body, handle them first
+		if c.handleInvitation(msg) {
+			return
+		}
+
 		// Skip empty messages or system messages
 		if msg.Body == "" || msg.From == "" {
 			return
 		}
 
+		// Apply the room trigger to groupchat messages
+		mentioned := false
+		if msg.Type == stanza.MessageTypeGroupchat {
+			var forward bool
+			if forward, mentioned = c.filterGroupchat(msg); !forward {
+				return
+			}
+		}
+
 		// Convert to internal model
 		receiptRequested := false
 		for _, ext := range msg.Extensions {
@@ -714,6 +978,8 @@ func (c *Client) setupHandlers() {
 			Thread:           msg.Thread,
 			Stamp:            "",
 			ReceiptRequested: receiptRequested,
+			Mentioned:        mentioned,
+			MUCPrivate:       c.isMUCPrivateMessage(msg),
 		}
 
 		// Send to channel (non-blocking)
@@ -732,6 +998,16 @@ func (c *Client) setupHandlers() {
 		}
 	})
 
+	// Presence handler, used to track MUC occupants
+	c.router.HandleFunc("presence", func(s xmpp.Sender, p stanza.Packet) {
+		pres, ok := p.(stanza.Presence)
+		if !ok {
+			return
+		}
+
+		c.handleMUCPresence(pres)
+	})
+
 	// Information query received handler
 	c.router.HandleFunc("iq", func(s xmpp.Sender, p stanza.Packet) {
 		iq, ok := p.(*stanza.IQ)
@@ -770,64 +1046,34 @@ func (c *Client) setupHandlers() {
 					zap.Error(err),
 				)
 			}
-		}
-	})
-}
-
-// handleReconnection handles automatic reconnection
-func (c *Client) handleReconnection(ctx context.Context) {
-	if !c.config.Reconnection.Enabled {
-		return
-	}
 
-	c.logger.Info("Reconnection enabled, starting reconnection monitor")
+		case *Ping:
+			// Answer pings, including MUC self-pings forwarded to us by the room (XEP-0410)
+			pong := stanza.IQ{
+				Attrs: stanza.Attrs{
+					Id:   iq.Id,
+					Type: stanza.IQTypeResult,
+					To:   iq.From,
+					From: iq.To,
+				},
+			}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-time.After(10 * time.Second):
-			if !c.isConnected() && c.client != nil {
-				c.logger.Warn("XMPP connection lost, attempting to reconnect")
-				if err := c.reconnect(); err != nil {
-					c.logger.Error("Reconnection failed", zap.Error(err))
-				}
+			if err := s.Send(&pong); err != nil {
+				c.logger.Error("Failed to send ping response",
+					zap.Error(err),
+				)
 			}
 		}
-	}
+	})
 }
 
-// reconnect attempts to reconnect to XMPP server
-func (c *Client) reconnect() error {
-	for attempt := 1; attempt <= c.config.Reconnection.MaxAttempts; attempt++ {
-		c.logger.Info("Reconnection attempt",
-			zap.Int("attempt", attempt),
-			zap.Int("max_attempts", c.config.Reconnection.MaxAttempts),
-		)
-
-		time.Sleep(c.config.Reconnection.Backoff)
-
-		if err := c.client.Connect(); err != nil {
-			c.logger.Error("Reconnection attempt failed",
-				zap.Int("attempt", attempt),
-				zap.Error(err),
-			)
-			continue
-		}
-
-		c.setConnected(true)
-		atomic.StoreInt32(&c.libraryConnected, 1)
-		c.logger.Info("Reconnection successful",
-			zap.Int("attempt", attempt),
-		)
+// handleConnectionEvent handles XMPP connection state changes from the xmpp library
+func (c *Client) handleConnectionEvent(seq uint64, event *xmpp.Event) error {
+	if seq != atomic.LoadUint64(&c.activeStream) {
+		// Event of a connection that has been replaced
 		return nil
 	}
 
-	return fmt.Errorf("failed to reconnect after %d attempts", c.config.Reconnection.MaxAttempts)
-}
-
-// handleConnectionEvent handles XMPP connection state changes from the xmpp library
-func (c *Client) handleConnectionEvent(event xmpp.Event) error {
 	// Track connection state based on events from the library
 	// The EventHandler is called when the connection state changes
 	// We use this to keep our internal flag in sync with the actual connection state
@@ -843,12 +1089,11 @@ func (c *Client) handleConnectionEvent(event xmpp.Event) error {
 
 	// If there's a stream error, the connection is broken
 	if streamErr != "" {
-		atomic.StoreInt32(&c.libraryConnected, 0)
-		c.setConnected(false)
 		c.logger.Error("XMPP stream error detected",
 			zap.String("error", streamErr),
 			zap.String("description", desc),
 		)
+		c.connectionLost(seq, fmt.Errorf("stream error: %s", streamErr))
 		return nil
 	}
 
@@ -868,7 +1113,7 @@ func (c *Client) isConnected() bool {
 
 	// If we have a real XMPP client connection, verify it's actually alive
 	// This ensures we detect actual disconnections even if the flag wasn't updated
-	if c.client != nil {
+	if c.stream() != nil {
 		return c.checkConnectionHealth()
 	}
 
@@ -893,11 +1138,11 @@ func (c *Client) checkConnectionHealth() bool {
 	}
 
 	// We don't need a response, just check if sending succeeds
-	_, err := c.client.SendIQ(ctx, &iq)
+	_, err := c.stream().SendIQ(ctx, &iq)
 	if err != nil {
 		// Connection is likely broken
 		c.logger.Debug("Connection health check failed", zap.Error(err))
-		atomic.StoreInt32(&c.connected, 0)
+		c.connectionLost(atomic.LoadUint64(&c.activeStream), fmt.Errorf("health check failed: %w", err))
 		return false
 	}
 
@@ -64,7 +64,7 @@ func TestClient_SendMessage_NotConnected(t *testing.T) {
 	cfg := &config.Config{}
 	client := NewClient(cfg, logger)
 
-	err := client.SendMessage("test@example.com", "Hello", "chat")
+	err := client.SendMessage("", "test@example.com", "Hello", "chat")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not connected")
@@ -75,7 +75,7 @@ func TestClient_SendMUCMessage_NotConnected(t *testing.T) {
 	cfg := &config.Config{}
 	client := NewClient(cfg, logger)
 
-	err := client.SendMUCMessage("room@conference.example.com", "Hello room", "")
+	err := client.SendMUCMessage("room@conference.example.com", "Hello room", "", nil)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not connected")
@@ -202,7 +202,7 @@ func TestManager_SendMessage_NoDefaultClient(t *testing.T) {
 	cfg := &config.Config{}
 	manager := NewManager(cfg, logger)
 
-	err := manager.SendMessage("test@example.com", "Hello", "chat")
+	_, err := manager.SendMessage("", "", "test@example.com", "Hello", "chat")
 	assert.Error(t, err)
 	assert.Equal(t, ErrNoDefaultClient, err)
 }
@@ -212,11 +212,53 @@ func TestManager_SendMUCMessage_NoDefaultClient(t *testing.T) {
 	cfg := &config.Config{}
 	manager := NewManager(cfg, logger)
 
-	err := manager.SendMUCMessage("room@conference.example.com", "Hello room", "")
+	_, err := manager.SendMUCMessage("", "room@conference.example.com", "Hello room", "", nil)
 	assert.Error(t, err)
 	assert.Equal(t, ErrNoDefaultClient, err)
 }
 
+func TestManager_GetClient_UnknownAccount(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{}
+	manager := NewManager(cfg, logger)
+	manager.clients[config.DefaultAccount] = NewClient(cfg, logger)
+
+	client, err := manager.GetClient("")
+	require.NoError(t, err)
+	assert.Same(t, manager.GetDefaultClient(), client)
+
+	_, err = manager.GetClient("support")
+	assert.ErrorIs(t, err, ErrUnknownAccount)
+
+	_, err = manager.SendMessage("support", "", "test@example.com", "Hello", "chat")
+	assert.ErrorIs(t, err, ErrUnknownAccount)
+}
+
+func TestManager_GetAccountsStatus(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		XMPP: config.XMPPConfig{JID: "bot@example.com"},
+		Accounts: []config.AccountConfig{
+			{Name: "alerts", JID: "alerts@example.com"},
+			{Name: "support", JID: "support@example.com"},
+		},
+	}
+	manager := NewManager(cfg, logger)
+	manager.clients[config.DefaultAccount] = NewClient(cfg, logger)
+	manager.clients["alerts"] = NewClient(cfg.ForAccount(cfg.Accounts[0]), logger)
+	manager.clients["alerts"].setConnected(true)
+
+	// Accounts are listed in configuration order, skipping those without a client
+	accounts := manager.GetAccountsStatus()
+	require.Len(t, accounts, 2)
+	assert.Equal(t, config.DefaultAccount, accounts[0].Name)
+	assert.Equal(t, "bot@example.com", accounts[0].JID)
+	assert.False(t, accounts[0].Connected)
+	assert.Equal(t, "alerts", accounts[1].Name)
+	assert.Equal(t, "alerts@example.com", accounts[1].JID)
+	assert.True(t, accounts[1].Connected)
+}
+
 func TestManager_IsConnected_NoClients(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	cfg := &config.Config{}
@@ -0,0 +1,108 @@
+package xmpp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"jabber-bot/internal/config"
+
+	"go.uber.org/zap"
+	"gosrc.io/xmpp"
+	"gosrc.io/xmpp/stanza"
+)
+
+// componentClient connects the bot as an external component (XEP-0114). A component has no
+// account of its own, so every stanza it sends must carry a from address under its domain.
+type componentClient struct {
+	*xmpp.Component
+	from string
+}
+
+// newComponent creates the library component for the configured domain
+func (c *Client) newComponent(proxyURL *url.URL, seq uint64) (xmpp.StreamClient, error) {
+	component, err := xmpp.NewComponent(xmpp.ComponentOptions{
+		TransportConfiguration: xmpp.TransportConfiguration{
+			Address: c.config.XMPP.Server,
+			Domain:  c.config.XMPP.Domain,
+			Dial:    proxyDialer(proxyURL),
+		},
+		Domain:   c.config.XMPP.Domain,
+		Secret:   c.config.XMPP.Secret,
+		Name:     "jabber-bot",
+		Category: "component",
+		Type:     "generic",
+	}, c.router, func(err error) {
+		c.logger.Error("XMPP component error", zap.Error(err))
+		c.connectionLost(seq, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &componentClient{Component: component, from: c.config.XMPP.JID}, nil
+}
+
+// Send stamps stanzas without a from address with the default sender
+func (cc *componentClient) Send(packet stanza.Packet) error {
+	return cc.Component.Send(withFrom(packet, cc.from))
+}
+
+// SendIQ stamps the request with the default sender if it has no from address
+func (cc *componentClient) SendIQ(ctx context.Context, iq *stanza.IQ) (chan stanza.IQ, error) {
+	if iq.From == "" {
+		iq.From = cc.from
+	}
+	return cc.Component.SendIQ(ctx, iq)
+}
+
+func withFrom(packet stanza.Packet, from string) stanza.Packet {
+	switch p := packet.(type) {
+	case stanza.Message:
+		if p.From == "" {
+			p.From = from
+		}
+		return p
+	case *stanza.Message:
+		if p.From == "" {
+			p.From = from
+		}
+	case stanza.Presence:
+		if p.From == "" {
+			p.From = from
+		}
+		return p
+	case *stanza.Presence:
+		if p.From == "" {
+			p.From = from
+		}
+	case *stanza.IQ:
+		if p.From == "" {
+			p.From = from
+		}
+	}
+	return packet
+}
+
+// isComponent reports whether the bot is connected as an external component
+func (c *Client) isComponent() bool {
+	return c.config.XMPP.Mode == config.ModeComponent
+}
+
+// senderJID returns the address a message is sent from. A localpart can only be chosen in
+// component mode, where any address under the component domain belongs to the bot. An empty
+// localpart leaves the default sender.
+func (c *Client) senderJID(localpart string) (string, error) {
+	if localpart == "" {
+		return "", nil
+	}
+	if !c.isComponent() {
+		return "", fmt.Errorf("choosing the sender is only supported in component mode")
+	}
+	if strings.ContainsAny(localpart, "@/ ") {
+		return "", fmt.Errorf("invalid sender localpart %q", localpart)
+	}
+
+	return localpart + "@" + c.config.XMPP.Domain, nil
+}
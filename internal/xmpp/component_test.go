@@ -0,0 +1,112 @@
+package xmpp
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"gosrc.io/xmpp"
+	"gosrc.io/xmpp/stanza"
+)
+
+// recordingStream is a stream client that records sent packets instead of writing them to a connection
+type recordingStream struct {
+	mu           sync.Mutex
+	sent         []stanza.Packet
+	disconnected bool
+}
+
+func (r *recordingStream) Connect() error                       { return nil }
+func (r *recordingStream) Resume() error                        { return nil }
+func (r *recordingStream) SendRaw(string) error                 { return nil }
+func (r *recordingStream) SetHandler(handler xmpp.EventHandler) {}
+
+func (r *recordingStream) Disconnect() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.disconnected = true
+	return nil
+}
+
+func (r *recordingStream) isDisconnected() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.disconnected
+}
+
+func (r *recordingStream) Send(packet stanza.Packet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, packet)
+	return nil
+}
+
+func (r *recordingStream) SendIQ(ctx context.Context, iq *stanza.IQ) (chan stanza.IQ, error) {
+	return make(chan stanza.IQ), nil
+}
+
+func newComponentClient(t *testing.T) (*Client, *recordingStream) {
+	t.Helper()
+	cfg := &config.Config{XMPP: config.XMPPConfig{
+		Mode:   config.ModeComponent,
+		JID:    "bot.example.org",
+		Domain: "bot.example.org",
+	}}
+	client := NewClient(cfg, zaptest.NewLogger(t))
+
+	stream := &recordingStream{}
+	client.client = stream
+	client.setConnected(true)
+	return client, stream
+}
+
+func TestClient_SenderJID(t *testing.T) {
+	client, _ := newComponentClient(t)
+
+	from, err := client.senderJID("alerts")
+	require.NoError(t, err)
+	assert.Equal(t, "alerts@bot.example.org", from)
+
+	from, err = client.senderJID("")
+	require.NoError(t, err)
+	assert.Empty(t, from)
+
+	_, err = client.senderJID("alerts@example.com")
+	assert.Error(t, err)
+
+	regular := NewClient(&config.Config{XMPP: config.XMPPConfig{Mode: config.ModeClient}}, zaptest.NewLogger(t))
+	_, err = regular.senderJID("alerts")
+	assert.ErrorContains(t, err, "component mode")
+}
+
+func TestClient_SendMessage_ComponentSender(t *testing.T) {
+	client, stream := newComponentClient(t)
+
+	require.NoError(t, client.SendMessage("deploy", "alice@example.com", "Deployed", "chat"))
+	require.NoError(t, client.SendDeliveryReceipt("alerts@bot.example.org/ignored", "alice@example.com/phone", "msg-1"))
+
+	require.Len(t, stream.sent, 2)
+	assert.Equal(t, "deploy@bot.example.org", stream.sent[0].(stanza.Message).From)
+	assert.Equal(t, "alerts@bot.example.org", stream.sent[1].(stanza.Message).From)
+}
+
+func TestWithFrom(t *testing.T) {
+	msg := withFrom(stanza.Message{Attrs: stanza.Attrs{To: "alice@example.com"}}, "bot.example.org")
+	assert.Equal(t, "bot.example.org", msg.(stanza.Message).From)
+
+	// An explicit sender is kept
+	msg = withFrom(stanza.Message{Attrs: stanza.Attrs{From: "deploy@bot.example.org"}}, "bot.example.org")
+	assert.Equal(t, "deploy@bot.example.org", msg.(stanza.Message).From)
+
+	presence := withFrom(stanza.Presence{Attrs: stanza.Attrs{To: "room@conference.example.org/bot"}}, "bot.example.org")
+	assert.Equal(t, "bot.example.org", presence.(stanza.Presence).From)
+
+	iq := &stanza.IQ{Attrs: stanza.Attrs{Type: stanza.IQTypeGet}}
+	withFrom(iq, "bot.example.org")
+	assert.Equal(t, "bot.example.org", iq.From)
+}
@@ -0,0 +1,78 @@
+package xmpp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gosrc.io/xmpp/stanza"
+)
+
+const defaultIQTimeout = 10 * time.Second
+
+// IQError is an error returned by the remote entity in response to an IQ request
+type IQError struct {
+	Condition string
+	Text      string
+}
+
+func (e *IQError) Error() string {
+	if e.Text != "" {
+		return fmt.Sprintf("%s: %s", e.Condition, e.Text)
+	}
+	return e.Condition
+}
+
+// newIQ builds an IQ request with a unique ID using the given prefix
+func newIQ(prefix string, iqType stanza.StanzaType, to string, payload stanza.IQPayload) *stanza.IQ {
+	return &stanza.IQ{
+		Attrs: stanza.Attrs{
+			Id:   fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano()),
+			Type: iqType,
+			To:   to,
+		},
+		Payload: payload,
+	}
+}
+
+// sendIQ sends an IQ request and waits for its result.
+// IQ errors from the remote entity are returned as *IQError.
+func (c *Client) sendIQ(iq *stanza.IQ, timeout time.Duration) (stanza.IQ, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	respChan, err := c.stream().SendIQ(ctx, iq)
+	if err != nil {
+		return stanza.IQ{}, fmt.Errorf("failed to send IQ: %w", err)
+	}
+
+	select {
+	case resp, ok := <-respChan:
+		if !ok {
+			return stanza.IQ{}, fmt.Errorf("IQ response channel closed")
+		}
+
+		if resp.Type == stanza.IQTypeError {
+			return resp, iqError(resp)
+		}
+
+		return resp, nil
+
+	case <-ctx.Done():
+		return stanza.IQ{}, fmt.Errorf("timeout waiting for IQ response")
+	}
+}
+
+// iqError converts an IQ error response to *IQError
+func iqError(resp stanza.IQ) error {
+	if resp.Error == nil {
+		return &IQError{Condition: "undefined-condition"}
+	}
+
+	condition := resp.Error.Reason
+	if condition == "" {
+		condition = string(resp.Error.Type)
+	}
+
+	return &IQError{Condition: condition, Text: resp.Error.Text}
+}
@@ -0,0 +1,37 @@
+package xmpp
+
+import "strings"
+
+// bareJID strips the resource part from a JID
+func bareJID(jid string) string {
+	if idx := strings.Index(jid, "/"); idx >= 0 {
+		return jid[:idx]
+	}
+	return jid
+}
+
+// jidResource returns the resource part of a JID (the occupant nick for MUC JIDs)
+func jidResource(jid string) string {
+	if idx := strings.Index(jid, "/"); idx >= 0 {
+		return jid[idx+1:]
+	}
+	return ""
+}
+
+// jidDomain returns the domain part of a JID
+func jidDomain(jid string) string {
+	bare := bareJID(jid)
+	if idx := strings.Index(bare, "@"); idx >= 0 {
+		return bare[idx+1:]
+	}
+	return bare
+}
+
+// jidLocal returns the localpart of a JID, or "" for a domain JID
+func jidLocal(jid string) string {
+	bare := bareJID(jid)
+	if idx := strings.Index(bare, "@"); idx >= 0 {
+		return bare[:idx]
+	}
+	return ""
+}
@@ -0,0 +1,250 @@
+package xmpp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"jabber-bot/internal/config"
+
+	"go.uber.org/zap"
+)
+
+const (
+	codeFence = "```"
+
+	// previewSize is the length of the preview sent in place of an uploaded long message
+	previewSize = 280
+
+	longMessageFileName = "message.txt"
+	longMessageFileType = "text/plain; charset=utf-8"
+)
+
+// sendLongBody sends a body over the configured size limit to a recipient or room: uploaded as
+// a text file with a preview when configured, otherwise split into numbered parts. send is
+// called for every message to send. The caller paced the first message; every further part
+// takes its own slot of the rate limit.
+func (c *Client) sendLongBody(target string, room bool, body string, send func(part string) error) error {
+	limit := c.config.XMPP.MaxBodySize
+
+	if c.config.XMPP.LongMessages == config.LongMessagesUpload {
+		preview, err := c.uploadLongBody(body, limit)
+		if err == nil {
+			return send(preview)
+		}
+		c.logger.Warn("Failed to upload long message, splitting it instead", zap.Error(err))
+	}
+
+	parts := splitBody(body, limit)
+	c.logger.Info("Splitting long message",
+		zap.Int("body_length", len(body)),
+		zap.Int("parts", len(parts)),
+	)
+	for i, part := range parts {
+		if i > 0 && c.limiter != nil {
+			// The message was admitted as a whole, so its parts are not rejected by the queue bound
+			//goland:noinspection GoUnhandledErrorResult
+			c.limiter.wait(target, room, false)
+		}
+		if err := send(part); err != nil {
+			return fmt.Errorf("failed to send part %d/%d: %w", i+1, len(parts), err)
+		}
+	}
+	return nil
+}
+
+// uploadLongBody uploads the body through HTTP File Upload (XEP-0363) and returns the message
+// sent in its place: the beginning of the body and the link to the full text
+func (c *Client) uploadLongBody(body string, limit int) (string, error) {
+	slot, err := c.uploadData(longMessageFileName, longMessageFileType, []byte(body))
+	if err != nil {
+		return "", err
+	}
+
+	link := "\n…\nFull message: " + slot.GetURL
+	size := min(previewSize, limit-len(link))
+	if size <= 0 {
+		return "", fmt.Errorf("upload URL too long for xmpp.max_body_size %d", limit)
+	}
+
+	return packLines(strings.Split(body, "\n"), size)[0] + link, nil
+}
+
+// splitBody splits a body longer than limit bytes into parts of at most limit bytes, each
+// marked "(1/3)". Parts break between paragraphs where possible, then between lines, then
+// between words. Code blocks are kept whole, or fenced again in every part they span.
+func splitBody(body string, limit int) []string {
+	if len(body) <= limit {
+		return []string{body}
+	}
+
+	// The marker takes "(n/n) ", so the space left depends on the number of parts
+	for digits := 1; ; digits++ {
+		parts := packBlocks(bodyBlocks(body), limit-2*digits-4)
+		if len(strconv.Itoa(len(parts))) > digits {
+			continue
+		}
+
+		for i, part := range parts {
+			// A fence only opens a code block at the start of a line
+			separator := " "
+			if strings.HasPrefix(part, codeFence) {
+				separator = "\n"
+			}
+			parts[i] = fmt.Sprintf("(%d/%d)%s%s", i+1, len(parts), separator, part)
+		}
+		return parts
+	}
+}
+
+// bodyBlocks splits a body into paragraphs and code blocks
+func bodyBlocks(body string) []string {
+	var blocks, lines []string
+	flush := func() {
+		if len(lines) > 0 {
+			blocks = append(blocks, strings.Join(lines, "\n"))
+			lines = nil
+		}
+	}
+
+	inCode := false
+	for _, line := range strings.Split(body, "\n") {
+		fence := strings.HasPrefix(strings.TrimSpace(line), codeFence)
+		switch {
+		case fence && !inCode:
+			flush()
+			lines = append(lines, line)
+			inCode = true
+		case fence && inCode:
+			lines = append(lines, line)
+			flush()
+			inCode = false
+		case inCode:
+			lines = append(lines, line)
+		case strings.TrimSpace(line) == "":
+			flush()
+		default:
+			lines = append(lines, line)
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+// packBlocks fills parts of at most size bytes with whole blocks, splitting blocks that do not
+// fit into a part of their own
+func packBlocks(blocks []string, size int) []string {
+	var parts []string
+	current := ""
+	add := func(piece, separator string) {
+		if current != "" && len(current)+len(separator)+len(piece) <= size {
+			current += separator + piece
+			return
+		}
+		if current != "" {
+			parts = append(parts, current)
+		}
+		current = piece
+	}
+
+	for _, block := range blocks {
+		if len(block) <= size {
+			add(block, "\n\n")
+			continue
+		}
+		for i, piece := range splitBlock(block, size) {
+			separator := "\n"
+			if i == 0 {
+				separator = "\n\n"
+			}
+			add(piece, separator)
+		}
+	}
+	if current != "" {
+		parts = append(parts, current)
+	}
+
+	return parts
+}
+
+// splitBlock splits a paragraph or code block longer than size at line boundaries. Every piece
+// of a code block is fenced with the fence of the block, unless the fences leave no room for
+// the code.
+func splitBlock(block string, size int) []string {
+	lines := strings.Split(block, "\n")
+	if !strings.HasPrefix(strings.TrimSpace(lines[0]), codeFence) {
+		return packLines(lines, size)
+	}
+
+	open := lines[0]
+	inner := size - len(open) - len(codeFence) - 2
+	if inner < 1 {
+		return packLines(lines, size)
+	}
+
+	lines = lines[1:]
+	if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == codeFence {
+		lines = lines[:len(lines)-1]
+	}
+
+	pieces := packLines(lines, inner)
+	for i, piece := range pieces {
+		pieces[i] = open + "\n" + piece + "\n" + codeFence
+	}
+	return pieces
+}
+
+// packLines joins lines into pieces of at most size bytes, splitting lines that are too long
+func packLines(lines []string, size int) []string {
+	var pieces []string
+	current := ""
+	started := false
+	for _, line := range lines {
+		for _, piece := range splitLine(line, size) {
+			if started && len(current)+1+len(piece) <= size {
+				current += "\n" + piece
+				continue
+			}
+			if started {
+				pieces = append(pieces, current)
+			}
+			current = piece
+			started = true
+		}
+	}
+	if started {
+		pieces = append(pieces, current)
+	}
+
+	return pieces
+}
+
+// splitLine splits a line longer than size bytes between words, or anywhere between characters
+// when a word does not fit. A character longer than size makes a piece of its own.
+func splitLine(line string, size int) []string {
+	size = max(size, 1)
+
+	var pieces []string
+	for len(line) > size {
+		cut := size
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			_, cut = utf8.DecodeRuneInString(line)
+		}
+		if space := strings.LastIndexByte(line[:cut], ' '); space > size/2 {
+			cut = space
+		}
+
+		pieces = append(pieces, strings.TrimRight(line[:cut], " "))
+		line = strings.TrimLeft(line[cut:], " ")
+	}
+
+	if line == "" && len(pieces) > 0 {
+		return pieces
+	}
+	return append(pieces, line)
+}
@@ -0,0 +1,176 @@
+package xmpp
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gosrc.io/xmpp/stanza"
+)
+
+func assertParts(t *testing.T, parts []string, limit int) {
+	t.Helper()
+	for _, part := range parts {
+		assert.LessOrEqual(t, len(part), limit, part)
+		assert.True(t, utf8.ValidString(part), part)
+	}
+}
+
+func TestSplitBody_Paragraphs(t *testing.T) {
+	body := strings.Repeat("a", 40) + "\n\n" + strings.Repeat("b", 40) + "\n\n" + strings.Repeat("c", 40)
+
+	parts := splitBody(body, 70)
+	assertParts(t, parts, 70)
+	assert.Equal(t, []string{
+		"(1/3) " + strings.Repeat("a", 40),
+		"(2/3) " + strings.Repeat("b", 40),
+		"(3/3) " + strings.Repeat("c", 40),
+	}, parts)
+
+	// Short bodies are sent as they are
+	assert.Equal(t, []string{body}, splitBody(body, len(body)))
+}
+
+func TestSplitBody_Lines(t *testing.T) {
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, "line "+strings.Repeat("x", 10))
+	}
+
+	parts := splitBody(strings.Join(lines, "\n"), 64)
+	assertParts(t, parts, 64)
+	for _, part := range parts {
+		// Lines are never cut
+		for _, line := range strings.Split(part[strings.Index(part, " ")+1:], "\n") {
+			assert.Equal(t, "line "+strings.Repeat("x", 10), line)
+		}
+	}
+}
+
+func TestSplitBody_CodeBlock(t *testing.T) {
+	var code []string
+	for i := 0; i < 30; i++ {
+		code = append(code, "ERROR something failed")
+	}
+	body := "Log excerpt:\n\n```log\n" + strings.Join(code, "\n") + "\n```"
+
+	parts := splitBody(body, 200)
+	require.Greater(t, len(parts), 2)
+	assertParts(t, parts, 200)
+
+	// The code block does not fit after the paragraph, and is fenced again in every part
+	assert.Equal(t, "(1/"+strconv.Itoa(len(parts))+") Log excerpt:", parts[0])
+	for i, part := range parts[1:] {
+		assert.True(t, strings.HasPrefix(part, "("+strconv.Itoa(i+2)+"/"+strconv.Itoa(len(parts))+")\n```log\n"), part)
+		assert.True(t, strings.HasSuffix(part, "\n```"), part)
+	}
+}
+
+func TestSplitBody_LongLine(t *testing.T) {
+	body := strings.Repeat("é", 100) + " " + strings.Repeat("word ", 30)
+
+	parts := splitBody(body, 64)
+	assertParts(t, parts, 64)
+
+	var rejoined string
+	for _, part := range parts {
+		rejoined += part[strings.Index(part, " ")+1:]
+	}
+	assert.Equal(t, strings.ReplaceAll(body, " ", ""), strings.ReplaceAll(rejoined, " ", ""))
+}
+
+func TestSplitBody_NarrowCodeBlock(t *testing.T) {
+	code := strings.Repeat("ERROR something failed\n", 10)
+	tests := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "fence line longer than the limit",
+			body: "```" + strings.Repeat("x", 80) + "\n" + code + "```",
+		},
+		{
+			name: "fence line of limit-6 bytes",
+			body: "```" + strings.Repeat("x", 64-6-3) + "\n" + code + "```",
+		},
+		{
+			name: "multi-byte runes",
+			body: "```\n" + strings.Repeat("日本語のログ", 20) + "\n```",
+		},
+		{
+			name: "multi-byte fence line",
+			body: "```" + strings.Repeat("語", 30) + "\n" + code + "```",
+		},
+	}
+
+	strip := strings.NewReplacer(" ", "", "\n", "", "`", "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitBody(tt.body, 64)
+			assertParts(t, parts, 64)
+
+			var rejoined string
+			for _, part := range parts {
+				rejoined += part[strings.Index(part, ")")+1:]
+			}
+			assert.Equal(t, strip.Replace(tt.body), strip.Replace(rejoined))
+		})
+	}
+}
+
+func TestSplitLine_RuneWiderThanSize(t *testing.T) {
+	assert.Equal(t, []string{"日", "本"}, splitLine("日本", 2))
+	assert.Equal(t, []string{"a", "b"}, splitLine("ab", 0))
+}
+
+func TestClient_SendMessage_Split(t *testing.T) {
+	cfg := &config.Config{XMPP: config.XMPPConfig{
+		JID:          "bot.example.org",
+		MaxBodySize:  64,
+		LongMessages: config.LongMessagesUpload,
+	}}
+	client, stream := newStreamClient(t, cfg)
+
+	// Uploads need a JID with a server domain, so the body is split instead
+	body := strings.Repeat("a", 50) + "\n\n" + strings.Repeat("b", 50)
+	require.NoError(t, client.SendMessage("", "alice@example.org", body, "chat"))
+	require.Len(t, stream.sent, 2)
+	assert.Equal(t, "(1/2) "+strings.Repeat("a", 50), stream.sent[0].(stanza.Message).Body)
+	assert.Equal(t, "(2/2) "+strings.Repeat("b", 50), stream.sent[1].(stanza.Message).Body)
+
+	// The subject is only set on the first part
+	stream.sent = nil
+	require.NoError(t, client.SendMUCMessage("ops@conference.example.org", body, "Alert", nil))
+	require.Len(t, stream.sent, 2)
+	assert.Equal(t, "Alert", stream.sent[0].(stanza.Message).Subject)
+	assert.Empty(t, stream.sent[1].(stanza.Message).Subject)
+}
+
+func TestClient_SendMUCMessage_SplitMentions(t *testing.T) {
+	cfg := &config.Config{XMPP: config.XMPPConfig{JID: "bot@example.org", MaxBodySize: 64}}
+	client, stream := newStreamClient(t, cfg)
+
+	// The body fits, but not with the prefix of the missing nick
+	body := strings.Repeat("a", 40) + "\n\n" + strings.Repeat("b", 20)
+	require.NoError(t, client.SendMUCMessage("ops@conference.example.org", body, "", []string{"alice", "bob"}))
+	require.Len(t, stream.sent, 2)
+	for _, packet := range stream.sent {
+		assert.LessOrEqual(t, len(packet.(stanza.Message).Body), 64)
+	}
+
+	first := stream.sent[0].(stanza.Message)
+	assert.Equal(t, "(1/2) alice, bob: "+strings.Repeat("a", 40), first.Body)
+	require.Len(t, first.Extensions, 2)
+	ref := first.Extensions[0].(Reference)
+	assert.Equal(t, 6, *ref.Begin)
+	assert.Equal(t, 11, *ref.End)
+
+	// The second part mentions nobody and gets no prefix
+	assert.Equal(t, "(2/2) "+strings.Repeat("b", 20), stream.sent[1].(stanza.Message).Body)
+	assert.Empty(t, stream.sent[1].(stanza.Message).Extensions)
+}
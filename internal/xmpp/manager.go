@@ -2,6 +2,8 @@ package xmpp
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -9,6 +11,7 @@ import (
 	"jabber-bot/internal/models"
 
 	"go.uber.org/zap"
+	"gosrc.io/xmpp/stanza"
 )
 
 // Manager manages XMPP connections and message handling
@@ -18,46 +21,104 @@ type Manager struct {
 	clients     map[string]*Client
 	mu          sync.RWMutex
 	webhookChan chan models.Message
+	outbox      *outbox
+	limiter     *rateLimiter
 	stopChan    chan struct{}
 	wg          sync.WaitGroup
 }
 
 // NewManager creates new XMPP manager
 func NewManager(cfg *config.Config, logger *zap.Logger) *Manager {
-	return &Manager{
+	m := &Manager{
 		config:      cfg,
 		logger:      logger,
 		clients:     make(map[string]*Client),
 		webhookChan: make(chan models.Message, 1000),
 		stopChan:    make(chan struct{}),
 	}
+	if cfg.RateLimit.Enabled {
+		m.limiter = newRateLimiter(cfg.RateLimit, m.stopChan)
+	}
+	return m
 }
 
-// Start starts XMPP manager
+// Start connects the default account and every additional account from the configuration
 func (m *Manager) Start() error {
 	m.logger.Info("Starting XMPP manager")
 
-	// Create default client
-	client := NewClient(m.config, m.logger)
-
 	// Connect using background context
 	ctx := context.Background()
-	if err := client.Connect(ctx); err != nil {
-		return err
+
+	if m.config.Outbox.Enabled {
+		box, err := newOutbox(m.config.Outbox, m.logger)
+		if err != nil {
+			return err
+		}
+		m.outbox = box
 	}
 
-	m.mu.Lock()
-	m.clients["default"] = client
-	m.mu.Unlock()
+	// An account that fails to connect keeps reconnecting in the background, so one unreachable
+	// account does not take down the others
+	accounts := m.accountConfigs()
+	var failures []error
+	for _, account := range accounts {
+		client := NewClient(account.config, m.logger.With(zap.String("account", account.name)))
+		client.limiter = m.limiter
+		if err := client.ConnectOrRetry(ctx); err != nil {
+			m.logger.Error("Failed to connect account",
+				zap.String("account", account.name),
+				zap.Bool("reconnecting", account.config.Reconnection.Enabled),
+				zap.Error(err),
+			)
+			failures = append(failures, fmt.Errorf("account %s: %w", account.name, err))
+		}
+
+		m.mu.Lock()
+		m.clients[account.name] = client
+		m.mu.Unlock()
+	}
+
+	if len(failures) == len(accounts) {
+		m.mu.Lock()
+		for _, client := range m.clients {
+			//goland:noinspection GoUnhandledErrorResult
+			client.Disconnect()
+		}
+		m.clients = make(map[string]*Client)
+		m.mu.Unlock()
+		return fmt.Errorf("no XMPP account could connect: %w", errors.Join(failures...))
+	}
 
 	// Start webhook dispatcher
 	m.wg.Add(1)
 	go m.dispatchWebhooks()
 
-	m.logger.Info("XMPP manager started successfully")
+	if m.outbox != nil {
+		m.wg.Add(1)
+		go m.flushOutbox()
+	}
+
+	m.logger.Info("XMPP manager started successfully",
+		zap.Int("accounts", len(accounts)),
+		zap.Int("connected", len(accounts)-len(failures)),
+	)
 	return nil
 }
 
+type accountConfig struct {
+	name   string
+	config *config.Config
+}
+
+// accountConfigs returns the client configuration of each account, the default account first
+func (m *Manager) accountConfigs() []accountConfig {
+	accounts := []accountConfig{{name: config.DefaultAccount, config: m.config}}
+	for _, account := range m.config.Accounts {
+		accounts = append(accounts, accountConfig{name: account.Name, config: m.config.ForAccount(account)})
+	}
+	return accounts
+}
+
 // Stop stops XMPP manager and all connections
 func (m *Manager) Stop() error {
 	m.logger.Info("Stopping XMPP manager")
@@ -94,70 +155,391 @@ func (m *Manager) GetDefaultClient() *Client {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	if client, exists := m.clients["default"]; exists {
+	if client, exists := m.clients[config.DefaultAccount]; exists {
 		return client
 	}
 	return nil
 }
 
-// SendMessage sends message using default client
-func (m *Manager) SendMessage(to, body, messageType string) error {
-	client := m.GetDefaultClient()
-	if client == nil {
-		return ErrNoDefaultClient
+// GetClient returns the client of a named account. An empty name selects the default account.
+func (m *Manager) GetClient(account string) (*Client, error) {
+	if account == "" || account == config.DefaultAccount {
+		client := m.GetDefaultClient()
+		if client == nil {
+			return nil, ErrNoDefaultClient
+		}
+		return client, nil
 	}
 
-	return client.SendMessage(to, body, messageType)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	client, exists := m.clients[account]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, account)
+	}
+	return client, nil
 }
 
-// SendMUCMessage sends MUC message using default client
-func (m *Manager) SendMUCMessage(room, body, subject string) error {
-	client := m.GetDefaultClient()
-	if client == nil {
-		return ErrNoDefaultClient
+// GetAccountsStatus returns the connection state and room health of every account
+func (m *Manager) GetAccountsStatus() []models.AccountStatus {
+	// Copy the clients so the connection checks run without holding the lock
+	m.mu.RLock()
+	clients := make(map[string]*Client, len(m.clients))
+	for name, client := range m.clients {
+		clients[name] = client
 	}
+	m.mu.RUnlock()
 
-	return client.SendMUCMessage(room, body, subject)
+	accounts := make([]models.AccountStatus, 0, len(clients))
+	for _, account := range m.accountConfigs() {
+		client, exists := clients[account.name]
+		if !exists {
+			continue
+		}
+		accounts = append(accounts, models.AccountStatus{
+			Name:            account.name,
+			JID:             account.config.XMPP.JID,
+			Connected:       client.IsConnected(),
+			ConnectionStats: client.ConnectionStats(),
+			Rooms:           client.RoomsHealth(),
+		})
+	}
+	return accounts
 }
 
-// SendChatState sends a chat state notification (XEP-0085)
-func (m *Manager) SendChatState(to string, state ChatState) error {
-	client := m.GetDefaultClient()
-	if client == nil {
-		return ErrNoDefaultClient
+// SendMessage sends message using the given account. from selects the sender localpart in component mode.
+// While the account is disconnected and the outbox is enabled, the message is queued instead.
+func (m *Manager) SendMessage(account, from, to, body, messageType string) (models.SendResult, error) {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return models.SendResult{}, err
+	}
+
+	result, queued, err := m.queueMessage(client, outboundMessage{
+		Account: account,
+		From:    from,
+		To:      to,
+		Body:    body,
+		Type:    messageType,
+	})
+	if queued || err != nil {
+		return result, err
+	}
+
+	delay, err := m.pace(to, false, true)
+	if err != nil {
+		return models.SendResult{}, err
+	}
+
+	return models.SendResult{Delay: delay}, client.SendMessage(from, to, body, messageType)
+}
+
+// SendMUCMessage sends MUC message using the given account. While the account is disconnected and
+// the outbox is enabled, the message is queued instead.
+func (m *Manager) SendMUCMessage(account, room, body, subject string, mentions []string) (models.SendResult, error) {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return models.SendResult{}, err
+	}
+
+	result, queued, err := m.queueMessage(client, outboundMessage{
+		Account:  account,
+		To:       room,
+		Room:     true,
+		Body:     body,
+		Subject:  subject,
+		Mentions: mentions,
+	})
+	if queued || err != nil {
+		return result, err
+	}
+
+	delay, err := m.pace(room, true, true)
+	if err != nil {
+		return models.SendResult{}, err
+	}
+
+	return models.SendResult{Delay: delay}, client.SendMUCMessage(room, body, subject, mentions)
+}
+
+// SendReply answers a received message with the account that received it
+func (m *Manager) SendReply(msg models.Message, body string) (models.SendResult, error) {
+	client, err := m.GetClient(msg.Account)
+	if err != nil {
+		return models.SendResult{}, err
+	}
+
+	to, messageType := ReplyTarget(msg)
+	delay, err := m.pace(to, messageType == "groupchat", true)
+	if err != nil {
+		return models.SendResult{}, err
+	}
+
+	return models.SendResult{Delay: delay}, client.SendReply(msg, body)
+}
+
+// SendReaction reacts to a received message with the account that received it
+func (m *Manager) SendReaction(msg models.Message, emojis []string) error {
+	client, err := m.GetClient(msg.Account)
+	if err != nil {
+		return err
+	}
+
+	to, messageType := ReplyTarget(msg)
+	if _, err := m.pace(to, messageType == "groupchat", true); err != nil {
+		return err
+	}
+
+	return client.SendReaction(msg, emojis)
+}
+
+// SetPresence changes the presence of the given account
+func (m *Manager) SetPresence(account, show, status string) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pace("", false, true); err != nil {
+		return err
+	}
+
+	return client.SetPresence(show, status)
+}
+
+// InviteToRoom invites users into a MUC room using the given account
+func (m *Manager) InviteToRoom(account, room string, jids []string, reason string, mediated bool) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	// A mediated invitation is a single message to the room, direct invitations are a message
+	// per invitee. The request is admitted as a whole, so only its first message can be rejected
+	// by the rate limit queue bound.
+	if mediated {
+		if _, err := m.pace(room, true, true); err != nil {
+			return err
+		}
+		return client.InviteToRoom(room, jids, reason, true)
+	}
+
+	for i, jid := range jids {
+		if _, err := m.pace(jid, false, i == 0); err != nil {
+			return err
+		}
+		if err := client.InviteToRoom(room, []string{jid}, reason, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAffiliations lists room affiliations using the given account
+func (m *Manager) GetAffiliations(account, room, affiliation string) ([]models.MUCItem, error) {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := m.pace(room, true, true); err != nil {
+		return nil, err
+	}
+
+	return client.GetAffiliations(room, affiliation)
+}
+
+// SetAffiliation changes a user's room affiliation using the given account
+func (m *Manager) SetAffiliation(account, room, jid, affiliation, reason string) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pace(room, true, true); err != nil {
+		return err
+	}
+
+	return client.SetAffiliation(room, jid, affiliation, reason)
+}
+
+// GetRoles lists room occupants by role using the given account
+func (m *Manager) GetRoles(account, room, role string) ([]models.MUCItem, error) {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := m.pace(room, true, true); err != nil {
+		return nil, err
+	}
+
+	return client.GetRoles(room, role)
+}
+
+// SetRole changes an occupant's role using the given account
+func (m *Manager) SetRole(account, room, nick, role, reason string) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pace(room, true, true); err != nil {
+		return err
+	}
+
+	return client.SetRole(room, nick, role, reason)
+}
+
+// KickOccupant kicks an occupant from a room using the given account
+func (m *Manager) KickOccupant(account, room, nick, reason string) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pace(room, true, true); err != nil {
+		return err
+	}
+
+	return client.KickOccupant(room, nick, reason)
+}
+
+// SetRoomSubject changes a room subject using the given account
+func (m *Manager) SetRoomSubject(account, room, subject string) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pace(room, true, true); err != nil {
+		return err
+	}
+
+	return client.SetRoomSubject(room, subject)
+}
+
+// CreateRoom creates and configures a room using the given account
+func (m *Manager) CreateRoom(account, room, nick string, settings models.MUCRoomSettings) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pace(room, true, true); err != nil {
+		return err
+	}
+
+	return client.CreateRoom(room, nick, settings)
+}
+
+// ConfigureRoom changes a room configuration using the given account
+func (m *Manager) ConfigureRoom(account, room string, settings models.MUCRoomSettings) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pace(room, true, true); err != nil {
+		return err
+	}
+
+	return client.ConfigureRoom(room, settings)
+}
+
+// DestroyRoom destroys a room using the given account
+func (m *Manager) DestroyRoom(account, room, alternateVenue, reason string) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pace(room, true, true); err != nil {
+		return err
+	}
+
+	return client.DestroyRoom(room, alternateVenue, reason)
+}
+
+// GetRoomState returns the state and occupants of a joined room using the given account
+func (m *Manager) GetRoomState(account, room string) (*models.MUCRoomState, error) {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetRoomState(room)
+}
+
+// SendMUCPrivateMessage sends a private message to a room occupant using the given account
+func (m *Manager) SendMUCPrivateMessage(account, room, nick, body string) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pace(room, true, true); err != nil {
+		return err
+	}
+
+	return client.SendMUCPrivateMessage(room, nick, body)
+}
+
+// SendChatState sends a chat state notification (XEP-0085) using the given account
+func (m *Manager) SendChatState(account, to string, state ChatState) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pace(to, client.messageTypeFor(to) == stanza.MessageTypeGroupchat, true); err != nil {
+		return err
 	}
 
 	return client.SendChatState(to, state)
 }
 
-// SendFile sends a file to a recipient
-func (m *Manager) SendFile(to, fileURL, fileName, fileType string) error {
-	client := m.GetDefaultClient()
-	if client == nil {
-		return ErrNoDefaultClient
+// SendFile sends a file to a recipient using the given account
+func (m *Manager) SendFile(account, to, fileURL, fileName, fileType string) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pace(to, client.messageTypeFor(to) == stanza.MessageTypeGroupchat, true); err != nil {
+		return err
 	}
 
 	return client.SendFile(to, fileURL, fileName, fileType)
 }
 
-// SendFileXEP0363 uploads a file via HTTP and sends it using XEP-0363
-func (m *Manager) SendFileXEP0363(to, filePath, fileName, fileType string) error {
-	client := m.GetDefaultClient()
-	if client == nil {
-		return ErrNoDefaultClient
+// SendFileXEP0363 uploads a file via HTTP and sends it using XEP-0363 with the given account
+func (m *Manager) SendFileXEP0363(account, to, filePath, fileName, fileType string) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pace(to, client.messageTypeFor(to) == stanza.MessageTypeGroupchat, true); err != nil {
+		return err
 	}
 
 	return client.SendFileXEP0363(to, filePath, fileName, fileType)
 }
 
-// SendDeliveryReceipt sends a delivery receipt (XEP-0184)
-func (m *Manager) SendDeliveryReceipt(to, messageID string) error {
-	client := m.GetDefaultClient()
-	if client == nil {
-		return ErrNoDefaultClient
+// SendDeliveryReceipt sends a delivery receipt (XEP-0184) using the given account
+func (m *Manager) SendDeliveryReceipt(account, from, to, messageID string) error {
+	client, err := m.GetClient(account)
+	if err != nil {
+		return err
 	}
 
-	return client.SendDeliveryReceipt(to, messageID)
+	if _, err := m.pace(to, false, true); err != nil {
+		return err
+	}
+
+	return client.SendDeliveryReceipt(from, to, messageID)
 }
 
 // IsConnected checks if default client is connected
@@ -177,12 +559,12 @@ func (m *Manager) dispatchWebhooks() {
 	m.logger.Info("Starting webhook dispatcher")
 	defer m.logger.Info("Webhook dispatcher stopped")
 
-	// Get message channels from all clients
-	var messageChans []<-chan models.Message
+	// Get message channels from all clients, keyed by account
+	messageChans := make(map[string]<-chan models.Message)
 
 	m.mu.RLock()
-	for _, client := range m.clients {
-		messageChans = append(messageChans, client.GetMessageChannel())
+	for account, client := range m.clients {
+		messageChans[account] = client.GetMessageChannel()
 	}
 	m.mu.RUnlock()
 
@@ -192,7 +574,7 @@ func (m *Manager) dispatchWebhooks() {
 	}
 
 	// Use fan-in pattern to receive messages from all clients
-	merged := m.mergeChannels(messageChans...)
+	merged := m.mergeChannels(messageChans)
 
 	for {
 		select {
@@ -207,6 +589,7 @@ func (m *Manager) dispatchWebhooks() {
 			select {
 			case m.webhookChan <- msg:
 				m.logger.Debug("Message forwarded to webhook channel",
+					zap.String("account", msg.Account),
 					zap.String("from", msg.From),
 					zap.String("to", msg.To),
 				)
@@ -232,20 +615,22 @@ func (m *Manager) dispatchWebhooks() {
 	}
 }
 
-// mergeChannels merges multiple channels into one using fan-in pattern
-func (m *Manager) mergeChannels(channels ...<-chan models.Message) <-chan models.Message {
+// mergeChannels merges the account channels into one using fan-in pattern,
+// tagging every message with the account it was received on
+func (m *Manager) mergeChannels(channels map[string]<-chan models.Message) <-chan models.Message {
 	output := make(chan models.Message)
 
 	var wg sync.WaitGroup
 	wg.Add(len(channels))
 
-	for _, ch := range channels {
-		go func(c <-chan models.Message) {
+	for account, ch := range channels {
+		go func(account string, c <-chan models.Message) {
 			defer wg.Done()
 			for msg := range c {
+				msg.Account = account
 				output <- msg
 			}
-		}(ch)
+		}(account, ch)
 	}
 
 	// Start goroutine to close output channel when all inputs are closed
@@ -262,6 +647,14 @@ var (
 		Code:    "NO_DEFAULT_CLIENT",
 		Message: "No default XMPP client available",
 	}
+	ErrUnknownAccount = &XMPPError{
+		Code:    "UNKNOWN_ACCOUNT",
+		Message: "Unknown XMPP account",
+	}
+	ErrRoomNotJoined = &XMPPError{
+		Code:    "ROOM_NOT_JOINED",
+		Message: "Not joined to room",
+	}
 )
 
 // XMPPError represents XMPP related errors
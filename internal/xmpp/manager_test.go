@@ -26,7 +26,10 @@ func TestManager_MergeChannels(t *testing.T) {
 	ch2 <- models.Message{From: "sender2", Body: "message2"}
 
 	// Merge channels
-	merged := manager.mergeChannels(ch1, ch2)
+	merged := manager.mergeChannels(map[string]<-chan models.Message{
+		config.DefaultAccount: ch1,
+		"alerts":              ch2,
+	})
 	defer close(ch1)
 	defer close(ch2)
 
@@ -48,6 +51,15 @@ func TestManager_MergeChannels(t *testing.T) {
 	messageFrom2 := messages[0].From == "sender2" || messages[1].From == "sender2"
 	assert.True(t, messageFrom1, "Should have message from sender1")
 	assert.True(t, messageFrom2, "Should have message from sender2")
+
+	// Messages are tagged with the account they were received on
+	for _, msg := range messages {
+		if msg.From == "sender1" {
+			assert.Equal(t, config.DefaultAccount, msg.Account)
+		} else {
+			assert.Equal(t, "alerts", msg.Account)
+		}
+	}
 }
 
 func TestManager_WebhookChannel_ThreadSafety(t *testing.T) {
@@ -0,0 +1,508 @@
+package xmpp
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+	"gosrc.io/xmpp/stanza"
+)
+
+const (
+	nsMUCUser       = "http://jabber.org/protocol/muc#user"
+	nsDirectInvite  = "jabber:x:conference"
+	eventTypeInvite = "invite"
+)
+
+// MUCUser is the muc#user extension used for mediated invitations and declines (XEP-0045)
+// and for occupant information in room presence
+type MUCUser struct {
+	XMLName  xml.Name    `xml:"http://jabber.org/protocol/muc#user x"`
+	Invites  []MUCInvite `xml:"invite,omitempty"`
+	Decline  *MUCDecline `xml:"decline,omitempty"`
+	Items    []MUCItem   `xml:"item,omitempty"`
+	Statuses []MUCStatus `xml:"status,omitempty"`
+	Password string      `xml:"password,omitempty"`
+}
+
+// MUCStatus is a muc#user status code (XEP-0045 15.6.2)
+type MUCStatus struct {
+	XMLName xml.Name `xml:"status"`
+	Code    int      `xml:"code,attr"`
+}
+
+type MUCInvite struct {
+	XMLName xml.Name `xml:"invite"`
+	From    string   `xml:"from,attr,omitempty"`
+	To      string   `xml:"to,attr,omitempty"`
+	Reason  string   `xml:"reason,omitempty"`
+}
+
+type MUCDecline struct {
+	XMLName xml.Name `xml:"decline"`
+	From    string   `xml:"from,attr,omitempty"`
+	To      string   `xml:"to,attr,omitempty"`
+	Reason  string   `xml:"reason,omitempty"`
+}
+
+// DirectInvite is a direct MUC invitation (XEP-0249)
+type DirectInvite struct {
+	XMLName  xml.Name `xml:"jabber:x:conference x"`
+	JID      string   `xml:"jid,attr"`
+	Password string   `xml:"password,attr,omitempty"`
+	Reason   string   `xml:"reason,attr,omitempty"`
+	Continue bool     `xml:"continue,attr,omitempty"`
+	Thread   string   `xml:"thread,attr,omitempty"`
+}
+
+func init() {
+	stanza.TypeRegistry.MapExtension(stanza.PKTMessage, xml.Name{Space: nsMUCUser, Local: "x"}, MUCUser{})
+	stanza.TypeRegistry.MapExtension(stanza.PKTMessage, xml.Name{Space: nsDirectInvite, Local: "x"}, DirectInvite{})
+	stanza.TypeRegistry.MapExtension(stanza.PKTPresence, xml.Name{Space: nsMUCUser, Local: "x"}, MUCUser{})
+}
+
+// MUCRoom holds the state of a room the bot has joined
+type MUCRoom struct {
+	JID         string
+	Nick        string
+	Password    string
+	Role        string
+	Affiliation string
+
+	// Occupants keyed by nick, including the bot itself
+	Occupants map[string]*models.Occupant
+	// synced is set once the self-presence arrived; presences before it describe
+	// occupants already in the room and do not raise join events
+	synced bool
+
+	health roomHealth
+}
+
+// JoinRoom joins a Multi-User Chat room. An empty nick falls back to the configured MUC nick.
+func (c *Client) JoinRoom(room, nick, password string) error {
+	if !c.isConnected() {
+		return fmt.Errorf("XMPP client is not connected")
+	}
+
+	room = bareJID(room)
+	if nick == "" {
+		nick = c.defaultNick()
+	}
+
+	presence := stanza.Presence{
+		Attrs: stanza.Attrs{
+			To: room + "/" + nick,
+		},
+		Extensions: []stanza.PresExtension{
+			stanza.MucPresence{
+				Password: password,
+				// Skip the discussion history so old messages are not forwarded to the webhook
+				History: stanza.History{MaxStanzas: stanza.NewNullableInt(0)},
+			},
+		},
+	}
+
+	if err := c.stream().Send(presence); err != nil {
+		c.logger.Error("Failed to join MUC room",
+			zap.String("room", room),
+			zap.String("nick", nick),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to join room: %w", err)
+	}
+
+	c.roomsMu.Lock()
+	joined := &MUCRoom{
+		JID:       room,
+		Nick:      nick,
+		Password:  password,
+		Occupants: make(map[string]*models.Occupant),
+		health:    roomHealth{healthy: true},
+	}
+	if existing, ok := c.rooms[room]; ok {
+		// Keep the self-ping history across rejoins
+		joined.health = existing.health
+	}
+	c.rooms[room] = joined
+	c.roomsMu.Unlock()
+
+	c.logger.Info("Joined MUC room",
+		zap.String("room", room),
+		zap.String("nick", nick),
+	)
+
+	return nil
+}
+
+// LeaveRoom leaves a Multi-User Chat room
+func (c *Client) LeaveRoom(room string) error {
+	if !c.isConnected() {
+		return fmt.Errorf("XMPP client is not connected")
+	}
+
+	room = bareJID(room)
+	joined, ok := c.getRoom(room)
+	if !ok {
+		return fmt.Errorf("not joined to room %s", room)
+	}
+
+	presence := stanza.Presence{
+		Attrs: stanza.Attrs{
+			To:   room + "/" + joined.Nick,
+			Type: stanza.PresenceTypeUnavailable,
+		},
+	}
+
+	if err := c.stream().Send(presence); err != nil {
+		return fmt.Errorf("failed to leave room: %w", err)
+	}
+
+	c.roomsMu.Lock()
+	delete(c.rooms, room)
+	c.roomsMu.Unlock()
+
+	c.logger.Info("Left MUC room", zap.String("room", room))
+	return nil
+}
+
+// JoinedRooms returns the bare JIDs of rooms the bot has joined
+func (c *Client) JoinedRooms() []string {
+	c.roomsMu.RLock()
+	defer c.roomsMu.RUnlock()
+
+	rooms := make([]string, 0, len(c.rooms))
+	for jid := range c.rooms {
+		rooms = append(rooms, jid)
+	}
+	slices.Sort(rooms)
+	return rooms
+}
+
+// InviteToRoom invites users into a room, either directly (XEP-0249) or mediated by the room (XEP-0045)
+func (c *Client) InviteToRoom(room string, jids []string, reason string, mediated bool) error {
+	if !c.isConnected() {
+		return fmt.Errorf("XMPP client is not connected")
+	}
+
+	room = bareJID(room)
+	password := ""
+	if joined, ok := c.getRoom(room); ok {
+		password = joined.Password
+	}
+
+	var msgs []stanza.Message
+	if mediated {
+		// A single mediated invitation may carry several invitees
+		user := MUCUser{}
+		for _, jid := range jids {
+			user.Invites = append(user.Invites, MUCInvite{To: jid, Reason: reason})
+		}
+		msgs = append(msgs, stanza.Message{
+			Attrs:      stanza.Attrs{To: room},
+			Extensions: []stanza.MsgExtension{user},
+		})
+	} else {
+		for _, jid := range jids {
+			msgs = append(msgs, stanza.Message{
+				Attrs: stanza.Attrs{To: jid},
+				Extensions: []stanza.MsgExtension{
+					DirectInvite{JID: room, Password: password, Reason: reason},
+				},
+			})
+		}
+	}
+
+	for _, msg := range msgs {
+		if err := c.stream().Send(msg); err != nil {
+			c.logger.Error("Failed to send MUC invitation",
+				zap.String("room", room),
+				zap.String("to", msg.To),
+				zap.Error(err),
+			)
+			return fmt.Errorf("failed to send invitation: %w", err)
+		}
+	}
+
+	c.logger.Info("MUC invitations sent",
+		zap.String("room", room),
+		zap.Strings("jids", jids),
+		zap.Bool("mediated", mediated),
+	)
+
+	return nil
+}
+
+// joinConfiguredRooms joins all rooms listed in the MUC configuration
+func (c *Client) joinConfiguredRooms() {
+	for _, room := range c.config.MUC.Rooms {
+		if err := c.JoinRoom(room.JID, room.Nick, room.Password); err != nil {
+			c.logger.Error("Failed to join configured room",
+				zap.String("room", room.JID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// handleInvitation processes a direct or mediated invitation. It returns false if the message
+// is not an invitation.
+func (c *Client) handleInvitation(msg stanza.Message) bool {
+	invite, password, ok := parseInvitation(msg)
+	if !ok {
+		return false
+	}
+
+	if !invite.Mediated {
+		c.processInvitation(msg, invite, password)
+		return true
+	}
+
+	// A mediated invitation is relayed by the room, which stamps the inviter. It is only trusted
+	// when it comes from the bare JID of a room on a multi-user chat service.
+	if jidLocal(msg.From) == "" || jidResource(msg.From) != "" {
+		c.logger.Warn("Ignoring mediated invitation not sent by a room",
+			zap.String("from", msg.From),
+		)
+		return true
+	}
+
+	// Service discovery waits for an IQ result, which is dispatched by the goroutine running
+	// this handler
+	go func() {
+		if !c.isMUCService(jidDomain(invite.Room)) {
+			c.logger.Warn("Ignoring mediated invitation from an unknown MUC service",
+				zap.String("from", msg.From),
+			)
+			return
+		}
+		c.processInvitation(msg, invite, password)
+	}()
+
+	return true
+}
+
+// processInvitation accepts or declines a validated invitation and forwards it to the webhook
+func (c *Client) processInvitation(msg stanza.Message, invite models.Invite, password string) {
+	invite.Accepted = isInviteAllowed(c.config.MUC.Invitations, invite.Inviter)
+
+	c.logger.Info("Received MUC invitation",
+		zap.String("room", invite.Room),
+		zap.String("inviter", invite.Inviter),
+		zap.Bool("mediated", invite.Mediated),
+		zap.Bool("accepted", invite.Accepted),
+	)
+
+	if invite.Accepted {
+		if err := c.JoinRoom(invite.Room, "", password); err != nil {
+			c.logger.Error("Failed to accept MUC invitation",
+				zap.String("room", invite.Room),
+				zap.Error(err),
+			)
+			invite.Accepted = false
+		}
+	} else if invite.Mediated {
+		// Direct invitations (XEP-0249) have no decline mechanism, they are simply ignored
+		c.declineInvitation(invite.Room, invite.Inviter)
+	}
+
+	message := models.Message{
+		ID:     msg.Id,
+		From:   invite.Inviter,
+		To:     msg.To,
+		Body:   invite.Reason,
+		Type:   string(msg.Type),
+		Event:  eventTypeInvite,
+		Invite: &invite,
+	}
+
+	select {
+	case c.messageChan <- message:
+	default:
+		c.logger.Warn("Message channel full, dropping invite event",
+			zap.String("room", invite.Room),
+		)
+	}
+}
+
+// isMUCService reports whether domain hosts a multi-user chat service: it serves a configured or
+// joined room, or reports a conference identity through service discovery (XEP-0045 6.1)
+func (c *Client) isMUCService(domain string) bool {
+	for _, room := range c.config.MUC.Rooms {
+		if strings.EqualFold(jidDomain(room.JID), domain) {
+			return true
+		}
+	}
+
+	domain = strings.ToLower(domain)
+
+	c.roomsMu.RLock()
+	known := c.mucServices[domain]
+	for room := range c.rooms {
+		if strings.EqualFold(jidDomain(room), domain) {
+			known = true
+		}
+	}
+	c.roomsMu.RUnlock()
+
+	if known {
+		return true
+	}
+
+	isService, err := c.discoverMUCService(domain)
+	if err != nil {
+		c.logger.Debug("MUC service discovery failed",
+			zap.String("domain", domain),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	if isService {
+		c.roomsMu.Lock()
+		c.mucServices[domain] = true
+		c.roomsMu.Unlock()
+	}
+
+	return isService
+}
+
+// discoverMUCService queries the disco#info identities of domain for a conference service
+func (c *Client) discoverMUCService(domain string) (bool, error) {
+	if !c.isConnected() {
+		return false, fmt.Errorf("XMPP client is not connected")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	iq := stanza.IQ{
+		Attrs: stanza.Attrs{
+			Type: stanza.IQTypeGet,
+			To:   domain,
+		},
+		Payload: &stanza.DiscoInfo{},
+	}
+
+	respChan, err := c.stream().SendIQ(ctx, &iq)
+	if err != nil {
+		return false, fmt.Errorf("failed to send service discovery request: %w", err)
+	}
+
+	select {
+	case resp, ok := <-respChan:
+		if !ok {
+			return false, fmt.Errorf("discovery response channel closed")
+		}
+
+		if resp.Attrs.Type == stanza.IQTypeError {
+			return false, fmt.Errorf("service discovery failed")
+		}
+
+		info, ok := resp.Payload.(*stanza.DiscoInfo)
+		if !ok {
+			return false, fmt.Errorf("invalid discovery response")
+		}
+
+		for _, identity := range info.Identity {
+			if identity.Category == "conference" && identity.Type == "text" {
+				return true, nil
+			}
+		}
+
+		return false, nil
+
+	case <-ctx.Done():
+		return false, fmt.Errorf("service discovery timed out")
+	}
+}
+
+// declineInvitation declines a mediated invitation (XEP-0045 7.8.2)
+func (c *Client) declineInvitation(room, inviter string) {
+	msg := stanza.Message{
+		Attrs: stanza.Attrs{To: room},
+		Extensions: []stanza.MsgExtension{
+			MUCUser{Decline: &MUCDecline{To: inviter}},
+		},
+	}
+
+	if err := c.stream().Send(msg); err != nil {
+		c.logger.Error("Failed to decline MUC invitation",
+			zap.String("room", room),
+			zap.String("inviter", inviter),
+			zap.Error(err),
+		)
+	}
+}
+
+// parseInvitation extracts a direct (XEP-0249) or mediated (XEP-0045) invitation from a message
+func parseInvitation(msg stanza.Message) (models.Invite, string, bool) {
+	var direct DirectInvite
+	if msg.Get(&direct) && direct.JID != "" {
+		return models.Invite{
+			Room:    bareJID(direct.JID),
+			Inviter: msg.From,
+			Reason:  direct.Reason,
+		}, direct.Password, true
+	}
+
+	var user MUCUser
+	if msg.Get(&user) && len(user.Invites) > 0 {
+		return models.Invite{
+			Room:     bareJID(msg.From),
+			Inviter:  user.Invites[0].From,
+			Reason:   user.Invites[0].Reason,
+			Mediated: true,
+		}, user.Password, true
+	}
+
+	return models.Invite{}, "", false
+}
+
+// isInviteAllowed checks the inviter against the invitation allowlist.
+// With both allowlists empty any inviter is accepted.
+func isInviteAllowed(cfg config.InvitationConfig, inviter string) bool {
+	if !cfg.AutoAccept {
+		return false
+	}
+
+	if len(cfg.AllowedDomains) == 0 && len(cfg.AllowedInviters) == 0 {
+		return true
+	}
+
+	bare := strings.ToLower(bareJID(inviter))
+	for _, allowed := range cfg.AllowedInviters {
+		if strings.ToLower(allowed) == bare {
+			return true
+		}
+	}
+
+	domain := jidDomain(bare)
+	for _, allowed := range cfg.AllowedDomains {
+		if strings.ToLower(allowed) == domain {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Client) getRoom(room string) (*MUCRoom, bool) {
+	c.roomsMu.RLock()
+	defer c.roomsMu.RUnlock()
+	joined, ok := c.rooms[room]
+	return joined, ok
+}
+
+func (c *Client) defaultNick() string {
+	if c.config.MUC.Nick != "" {
+		return c.config.MUC.Nick
+	}
+	return strings.Split(c.config.XMPP.JID, "@")[0]
+}
@@ -0,0 +1,209 @@
+package xmpp
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+	"gosrc.io/xmpp/stanza"
+)
+
+const nsMUCAdmin = "http://jabber.org/protocol/muc#admin"
+
+// MUC affiliations and roles (XEP-0045 5.1, 5.2)
+const (
+	AffiliationOwner   = "owner"
+	AffiliationAdmin   = "admin"
+	AffiliationMember  = "member"
+	AffiliationOutcast = "outcast"
+	AffiliationNone    = "none"
+
+	RoleModerator   = "moderator"
+	RoleParticipant = "participant"
+	RoleVisitor     = "visitor"
+	RoleNone        = "none"
+)
+
+// MUCAdminQuery is the muc#admin IQ payload used to list and modify affiliations and roles
+type MUCAdminQuery struct {
+	XMLName xml.Name  `xml:"http://jabber.org/protocol/muc#admin query"`
+	Items   []MUCItem `xml:"item"`
+}
+
+func (q MUCAdminQuery) Namespace() string {
+	return nsMUCAdmin
+}
+
+func (q MUCAdminQuery) GetSet() *stanza.ResultSet {
+	return nil
+}
+
+// MUCItem describes an occupant or an affiliated user of a room
+type MUCItem struct {
+	XMLName     xml.Name `xml:"item"`
+	Affiliation string   `xml:"affiliation,attr,omitempty"`
+	Role        string   `xml:"role,attr,omitempty"`
+	JID         string   `xml:"jid,attr,omitempty"`
+	Nick        string   `xml:"nick,attr,omitempty"`
+	Reason      string   `xml:"reason,omitempty"`
+}
+
+func init() {
+	stanza.TypeRegistry.MapExtension(stanza.PKTIQ, xml.Name{Space: nsMUCAdmin, Local: "query"}, MUCAdminQuery{})
+}
+
+// GetAffiliations lists users with the given affiliation. An empty affiliation lists
+// owners, admins, members and outcasts.
+func (c *Client) GetAffiliations(room, affiliation string) ([]models.MUCItem, error) {
+	if !c.isConnected() {
+		return nil, fmt.Errorf("XMPP client is not connected")
+	}
+
+	affiliations := []string{affiliation}
+	if affiliation == "" {
+		affiliations = []string{AffiliationOwner, AffiliationAdmin, AffiliationMember, AffiliationOutcast}
+	}
+
+	var result []models.MUCItem
+	for _, aff := range affiliations {
+		items, err := c.queryMUCAdmin(bareJID(room), MUCItem{Affiliation: aff})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s affiliations: %w", aff, err)
+		}
+		result = append(result, items...)
+	}
+
+	return result, nil
+}
+
+// SetAffiliation changes the affiliation of a user. Setting "outcast" bans the user.
+func (c *Client) SetAffiliation(room, jid, affiliation, reason string) error {
+	if !c.isConnected() {
+		return fmt.Errorf("XMPP client is not connected")
+	}
+
+	item := MUCItem{JID: bareJID(jid), Affiliation: affiliation, Reason: reason}
+	if err := c.setMUCAdmin(bareJID(room), item); err != nil {
+		return fmt.Errorf("failed to set affiliation: %w", err)
+	}
+
+	c.logger.Info("MUC affiliation changed",
+		zap.String("room", room),
+		zap.String("jid", jid),
+		zap.String("affiliation", affiliation),
+	)
+
+	return nil
+}
+
+// GetRoles lists occupants with the given role
+func (c *Client) GetRoles(room, role string) ([]models.MUCItem, error) {
+	if !c.isConnected() {
+		return nil, fmt.Errorf("XMPP client is not connected")
+	}
+
+	items, err := c.queryMUCAdmin(bareJID(room), MUCItem{Role: role})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s roles: %w", role, err)
+	}
+
+	return items, nil
+}
+
+// SetRole changes the role of an occupant identified by nick
+func (c *Client) SetRole(room, nick, role, reason string) error {
+	if !c.isConnected() {
+		return fmt.Errorf("XMPP client is not connected")
+	}
+
+	item := MUCItem{Nick: nick, Role: role, Reason: reason}
+	if err := c.setMUCAdmin(bareJID(room), item); err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+
+	c.logger.Info("MUC role changed",
+		zap.String("room", room),
+		zap.String("nick", nick),
+		zap.String("role", role),
+	)
+
+	return nil
+}
+
+// KickOccupant removes an occupant from the room by revoking its role
+func (c *Client) KickOccupant(room, nick, reason string) error {
+	return c.SetRole(room, nick, RoleNone, reason)
+}
+
+// SetRoomSubject changes the room subject without sending a message body
+func (c *Client) SetRoomSubject(room, subject string) error {
+	if !c.isConnected() {
+		return fmt.Errorf("XMPP client is not connected")
+	}
+
+	msg := stanza.Message{
+		Attrs: stanza.Attrs{
+			To:   bareJID(room),
+			Type: stanza.MessageTypeGroupchat,
+		},
+		Subject: subject,
+	}
+
+	if err := c.stream().Send(msg); err != nil {
+		c.logger.Error("Failed to set MUC subject",
+			zap.String("room", room),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to set subject: %w", err)
+	}
+
+	c.logger.Info("MUC subject changed",
+		zap.String("room", room),
+		zap.String("subject", subject),
+	)
+
+	return nil
+}
+
+// queryMUCAdmin sends a muc#admin get request filtered by the given item
+func (c *Client) queryMUCAdmin(room string, filter MUCItem) ([]models.MUCItem, error) {
+	iq := newIQ("muc-admin", stanza.IQTypeGet, room, &MUCAdminQuery{Items: []MUCItem{filter}})
+
+	resp, err := c.sendIQ(iq, defaultIQTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	query, ok := resp.Payload.(*MUCAdminQuery)
+	if !ok {
+		// An empty list may come back without a payload
+		return []models.MUCItem{}, nil
+	}
+
+	items := make([]models.MUCItem, 0, len(query.Items))
+	for _, item := range query.Items {
+		items = append(items, item.toModel())
+	}
+
+	return items, nil
+}
+
+// setMUCAdmin sends a muc#admin set request for a single item
+func (c *Client) setMUCAdmin(room string, item MUCItem) error {
+	iq := newIQ("muc-admin", stanza.IQTypeSet, room, &MUCAdminQuery{Items: []MUCItem{item}})
+
+	_, err := c.sendIQ(iq, defaultIQTimeout)
+	return err
+}
+
+func (i MUCItem) toModel() models.MUCItem {
+	return models.MUCItem{
+		JID:         i.JID,
+		Nick:        i.Nick,
+		Affiliation: i.Affiliation,
+		Role:        i.Role,
+		Reason:      i.Reason,
+	}
+}
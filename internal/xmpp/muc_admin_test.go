@@ -0,0 +1,70 @@
+package xmpp
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"gosrc.io/xmpp/stanza"
+)
+
+func TestMUCAdminQuery_Marshal(t *testing.T) {
+	iq := newIQ("muc-admin", stanza.IQTypeSet, "room@conference.example.com", &MUCAdminQuery{
+		Items: []MUCItem{{JID: "spammer@example.com", Affiliation: AffiliationOutcast, Reason: "Spam"}},
+	})
+
+	data, err := xml.Marshal(iq)
+	require.NoError(t, err)
+
+	out := string(data)
+	assert.Contains(t, out, `xmlns="http://jabber.org/protocol/muc#admin"`)
+	assert.Contains(t, out, `affiliation="outcast"`)
+	assert.Contains(t, out, `jid="spammer@example.com"`)
+	assert.Contains(t, out, `<reason>Spam</reason>`)
+	assert.NotContains(t, out, `role=`)
+}
+
+func TestMUCAdminQuery_Unmarshal(t *testing.T) {
+	raw := `<iq type="result" id="muc-admin-1" from="room@conference.example.com">
+		<query xmlns="http://jabber.org/protocol/muc#admin">
+			<item affiliation="member" jid="alice@example.com" nick="alice"/>
+			<item affiliation="member" jid="bob@example.com"/>
+		</query>
+	</iq>`
+
+	var iq stanza.IQ
+	require.NoError(t, xml.Unmarshal([]byte(raw), &iq))
+
+	query, ok := iq.Payload.(*MUCAdminQuery)
+	require.True(t, ok)
+	require.Len(t, query.Items, 2)
+
+	item := query.Items[0].toModel()
+	assert.Equal(t, "alice@example.com", item.JID)
+	assert.Equal(t, "alice", item.Nick)
+	assert.Equal(t, AffiliationMember, item.Affiliation)
+}
+
+func TestIQError(t *testing.T) {
+	err := iqError(stanza.IQ{Error: &stanza.Err{Reason: "forbidden", Text: "Only owners may do this"}})
+	assert.Equal(t, "forbidden: Only owners may do this", err.Error())
+
+	err = iqError(stanza.IQ{})
+	assert.Equal(t, "undefined-condition", err.Error())
+}
+
+func TestClient_MUCAdmin_NotConnected(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	client := NewClient(&config.Config{}, logger)
+
+	_, err := client.GetAffiliations("room@conference.example.com", "")
+	assert.ErrorContains(t, err, "not connected")
+
+	assert.ErrorContains(t, client.SetAffiliation("room@conference.example.com", "a@example.com", AffiliationOutcast, ""), "not connected")
+	assert.ErrorContains(t, client.KickOccupant("room@conference.example.com", "alice", ""), "not connected")
+	assert.ErrorContains(t, client.SetRoomSubject("room@conference.example.com", "Topic"), "not connected")
+}
@@ -0,0 +1,186 @@
+package xmpp
+
+import (
+	"encoding/xml"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"jabber-bot/internal/config"
+
+	"gosrc.io/xmpp/stanza"
+)
+
+const (
+	nsReference       = "urn:xmpp:reference:0"
+	referenceMention  = "mention"
+	xmppURIScheme     = "xmpp:"
+	mentionSeparator  = ", "
+	mentionTerminator = ": "
+)
+
+// Reference marks a part of the message body as referring to an entity (XEP-0372)
+type Reference struct {
+	XMLName xml.Name `xml:"urn:xmpp:reference:0 reference"`
+	Type    string   `xml:"type,attr"`
+	URI     string   `xml:"uri,attr"`
+	Begin   *int     `xml:"begin,attr,omitempty"`
+	End     *int     `xml:"end,attr,omitempty"`
+}
+
+func init() {
+	stanza.TypeRegistry.MapExtension(stanza.PKTMessage, xml.Name{Space: nsReference, Local: "reference"}, Reference{})
+}
+
+// roomTrigger returns the trigger and command prefix configured for a room,
+// falling back to the MUC defaults
+func (c *Client) roomTrigger(room string) (string, string) {
+	trigger, prefix := c.config.MUC.Trigger, c.config.MUC.CommandPrefix
+	for _, cfg := range c.config.MUC.Rooms {
+		if bareJID(cfg.JID) != room {
+			continue
+		}
+		if cfg.Trigger != "" {
+			trigger = cfg.Trigger
+		}
+		if cfg.CommandPrefix != "" {
+			prefix = cfg.CommandPrefix
+		}
+		break
+	}
+
+	if trigger == "" {
+		trigger = config.TriggerAll
+	}
+	if prefix == "" {
+		prefix = "!"
+	}
+	return trigger, prefix
+}
+
+// filterGroupchat decides whether a groupchat message is forwarded to the webhook according to
+// the room trigger. It also reports whether the message mentions the bot.
+func (c *Client) filterGroupchat(msg stanza.Message) (forward bool, mentioned bool) {
+	room := bareJID(msg.From)
+
+	nick := c.defaultNick()
+	if joined, ok := c.getRoom(room); ok {
+		nick = joined.Nick
+	}
+
+	mentioned = isMentioned(msg, room, nick, bareJID(c.config.XMPP.JID))
+
+	trigger, prefix := c.roomTrigger(room)
+	switch trigger {
+	case config.TriggerMention:
+		return mentioned, mentioned
+	case config.TriggerCommandPrefix:
+		return strings.HasPrefix(strings.TrimSpace(msg.Body), prefix), mentioned
+	default:
+		return true, mentioned
+	}
+}
+
+// isMentioned reports whether a groupchat message addresses the bot, either through a
+// XEP-0372 mention reference or by its nick appearing in the body
+func isMentioned(msg stanza.Message, room, nick, ownJID string) bool {
+	for _, ext := range msg.Extensions {
+		ref, ok := ext.(*Reference)
+		if !ok || ref.Type != referenceMention {
+			continue
+		}
+
+		target, err := url.PathUnescape(strings.TrimPrefix(ref.URI, xmppURIScheme))
+		if err != nil {
+			continue
+		}
+		if target == room+"/"+nick || (jidResource(target) == "" && strings.EqualFold(target, ownJID)) {
+			return true
+		}
+	}
+
+	return nick != "" && mentionIndex(msg.Body, nick, false) >= 0
+}
+
+// mentionIndex returns the byte offset of nick in body where it stands as a word of its own,
+// e.g. "bot: hi", "@bot" or "ask bot." but not "robot". It returns -1 if there is none.
+func mentionIndex(body, nick string, caseSensitive bool) int {
+	haystack, needle := body, nick
+	if !caseSensitive {
+		haystack, needle = strings.ToLower(body), strings.ToLower(nick)
+	}
+
+	for offset := 0; offset < len(haystack); {
+		i := strings.Index(haystack[offset:], needle)
+		if i < 0 {
+			return -1
+		}
+		start := offset + i
+		end := start + len(needle)
+
+		before, _ := utf8.DecodeLastRuneInString(haystack[:start])
+		after, _ := utf8.DecodeRuneInString(haystack[end:])
+		if (start == 0 || !isWordRune(before)) && (end == len(haystack) || !isWordRune(after)) {
+			return start
+		}
+		offset = start + 1
+	}
+
+	return -1
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
+
+// buildMentions makes sure every mentioned nick appears in the body, prefixing the missing ones
+// ("alice, bob: ..."), and returns a XEP-0372 reference for each of them
+func buildMentions(room, body string, nicks []string) (string, []stanza.MsgExtension) {
+	body = prefixMentions(body, nicks)
+
+	refs := make([]stanza.MsgExtension, 0, len(nicks))
+	for _, nick := range nicks {
+		i := mentionIndex(body, nick, true)
+		if i < 0 {
+			continue
+		}
+		// Offsets are counted in characters, not bytes
+		begin := utf8.RuneCountInString(body[:i])
+		end := begin + utf8.RuneCountInString(nick)
+
+		refs = append(refs, Reference{
+			Type:  referenceMention,
+			URI:   xmppURIScheme + room + "/" + url.PathEscape(nick),
+			Begin: &begin,
+			End:   &end,
+		})
+	}
+
+	return body, refs
+}
+
+// prefixMentions prefixes the mentioned nicks that do not appear in the body
+func prefixMentions(body string, nicks []string) string {
+	var missing []string
+	for _, nick := range nicks {
+		if nick != "" && mentionIndex(body, nick, true) < 0 {
+			missing = append(missing, nick)
+		}
+	}
+	if len(missing) > 0 {
+		body = strings.Join(missing, mentionSeparator) + mentionTerminator + body
+	}
+	return body
+}
+
+// mentionsIn returns the mentioned nicks that appear in the body
+func mentionsIn(body string, nicks []string) []string {
+	var found []string
+	for _, nick := range nicks {
+		if nick != "" && mentionIndex(body, nick, true) >= 0 {
+			found = append(found, nick)
+		}
+	}
+	return found
+}
@@ -0,0 +1,120 @@
+package xmpp
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"gosrc.io/xmpp/stanza"
+)
+
+func TestMentionIndex(t *testing.T) {
+	tests := []struct {
+		body  string
+		nick  string
+		found bool
+	}{
+		{"bot: status please", "bot", true},
+		{"hey @Bot, ping", "bot", true},
+		{"ask the bot.", "bot", true},
+		{"the robot is down", "bot", false},
+		{"bots everywhere", "bot", false},
+		{"bot-2 is the other one", "bot", false},
+		{"Ünïcode nick Zoë!", "zoë", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			assert.Equal(t, tt.found, mentionIndex(tt.body, tt.nick, false) >= 0)
+		})
+	}
+}
+
+func TestIsMentioned_Reference(t *testing.T) {
+	msg := decodeMessage(t, `<message from="ops@conference.example.com/alice" type="groupchat">
+		<body>Ops Bot please restart</body>
+		<reference xmlns="urn:xmpp:reference:0" type="mention" uri="xmpp:ops@conference.example.com/Ops%20Bot" begin="0" end="7"/>
+	</message>`)
+	assert.True(t, isMentioned(msg, "ops@conference.example.com", "Ops Bot", "bot@example.com"))
+
+	msg = decodeMessage(t, `<message from="ops@conference.example.com/alice" type="groupchat">
+		<body>hey you</body>
+		<reference xmlns="urn:xmpp:reference:0" type="mention" uri="xmpp:bot@example.com"/>
+	</message>`)
+	assert.True(t, isMentioned(msg, "ops@conference.example.com", "OpsBot", "bot@example.com"))
+
+	msg = decodeMessage(t, `<message from="ops@conference.example.com/alice" type="groupchat">
+		<body>hey carol</body>
+		<reference xmlns="urn:xmpp:reference:0" type="mention" uri="xmpp:ops@conference.example.com/carol"/>
+	</message>`)
+	assert.False(t, isMentioned(msg, "ops@conference.example.com", "OpsBot", "bot@example.com"))
+}
+
+func TestClient_FilterGroupchat(t *testing.T) {
+	cfg := &config.Config{
+		XMPP: config.XMPPConfig{JID: "bot@example.com"},
+		MUC: config.MUCConfig{
+			Nick:          "bot",
+			Trigger:       config.TriggerMention,
+			CommandPrefix: "!",
+			Rooms: []config.MUCRoomConfig{
+				{JID: "all@conference.example.com", Trigger: config.TriggerAll},
+				{JID: "cmd@conference.example.com", Trigger: config.TriggerCommandPrefix, CommandPrefix: "/"},
+			},
+		},
+	}
+	client := NewClient(cfg, zaptest.NewLogger(t))
+
+	tests := []struct {
+		name      string
+		from      string
+		body      string
+		forward   bool
+		mentioned bool
+	}{
+		{"mention room without mention", "ops@conference.example.com/alice", "hello everyone", false, false},
+		{"mention room with mention", "ops@conference.example.com/alice", "bot: deploy", true, true},
+		{"all room", "all@conference.example.com/alice", "hello everyone", true, false},
+		{"command room with prefix", "cmd@conference.example.com/alice", "/deploy prod", true, false},
+		{"command room with default prefix", "cmd@conference.example.com/alice", "!deploy prod", false, false},
+		{"command room mention only", "cmd@conference.example.com/alice", "bot: deploy", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := stanza.Message{
+				Attrs: stanza.Attrs{From: tt.from, Type: stanza.MessageTypeGroupchat},
+				Body:  tt.body,
+			}
+
+			forward, mentioned := client.filterGroupchat(msg)
+			assert.Equal(t, tt.forward, forward)
+			assert.Equal(t, tt.mentioned, mentioned)
+		})
+	}
+}
+
+func TestBuildMentions(t *testing.T) {
+	body, refs := buildMentions("ops@conference.example.com", "Zoë please take INC-1234", []string{"Zoë", "on call"})
+
+	assert.Equal(t, "on call: Zoë please take INC-1234", body)
+	require.Len(t, refs, 2)
+
+	first := refs[0].(Reference)
+	assert.Equal(t, "xmpp:ops@conference.example.com/Zo%C3%AB", first.URI)
+	assert.Equal(t, 9, *first.Begin)
+	assert.Equal(t, 12, *first.End)
+
+	second := refs[1].(Reference)
+	assert.Equal(t, "xmpp:ops@conference.example.com/on%20call", second.URI)
+	assert.Equal(t, 0, *second.Begin)
+	assert.Equal(t, 7, *second.End)
+
+	data, err := xml.Marshal(stanza.Message{Body: body, Extensions: refs})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<reference xmlns="urn:xmpp:reference:0" type="mention" uri="xmpp:ops@conference.example.com/on%20call" begin="0" end="7"></reference>`)
+}
@@ -0,0 +1,182 @@
+package xmpp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+	"gosrc.io/xmpp/stanza"
+)
+
+const (
+	eventTypeOccupantJoined = "occupant_joined"
+	eventTypeOccupantLeft   = "occupant_left"
+)
+
+// MUC presence status codes (XEP-0045 15.6.2)
+const (
+	mucStatusSelfPresence = 110
+	mucStatusBanned       = 301
+	mucStatusNickChange   = 303
+	mucStatusKicked       = 307
+)
+
+// GetRoomState returns the bot's own role and affiliation and the current occupants of a joined room
+func (c *Client) GetRoomState(room string) (*models.MUCRoomState, error) {
+	room = bareJID(room)
+
+	c.roomsMu.RLock()
+	defer c.roomsMu.RUnlock()
+
+	joined, ok := c.rooms[room]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRoomNotJoined, room)
+	}
+
+	state := &models.MUCRoomState{
+		Room:        joined.JID,
+		Nick:        joined.Nick,
+		Role:        joined.Role,
+		Affiliation: joined.Affiliation,
+		Occupants:   make([]models.Occupant, 0, len(joined.Occupants)),
+	}
+	for _, occupant := range joined.Occupants {
+		state.Occupants = append(state.Occupants, *occupant)
+	}
+	slices.SortFunc(state.Occupants, func(a, b models.Occupant) int {
+		return strings.Compare(a.Nick, b.Nick)
+	})
+
+	return state, nil
+}
+
+// handleMUCPresence updates the occupant list of a joined room and emits join and leave events
+func (c *Client) handleMUCPresence(pres stanza.Presence) {
+	room := bareJID(pres.From)
+	nick := jidResource(pres.From)
+	if nick == "" {
+		return
+	}
+
+	var user MUCUser
+	pres.Get(&user)
+
+	occupant := &models.Occupant{
+		Room:   room,
+		Nick:   nick,
+		Show:   string(pres.Show),
+		Status: pres.Status,
+	}
+	var newNick string
+	if len(user.Items) > 0 {
+		item := user.Items[0]
+		occupant.JID = item.JID
+		occupant.Role = item.Role
+		occupant.Affiliation = item.Affiliation
+		newNick = item.Nick
+	}
+
+	c.roomsMu.Lock()
+	joined, ok := c.rooms[room]
+	if !ok {
+		c.roomsMu.Unlock()
+		return
+	}
+
+	self := nick == joined.Nick || hasMUCStatus(user, mucStatusSelfPresence)
+	var event string
+
+	switch {
+	case pres.Type == stanza.PresenceTypeError:
+		if self {
+			// Joining failed (e.g. wrong password or members-only), forget the room
+			delete(c.rooms, room)
+			c.logger.Error("Failed to join MUC room",
+				zap.String("room", room),
+				zap.String("error", pres.Error.Reason),
+			)
+		}
+
+	case pres.Type == stanza.PresenceTypeUnavailable:
+		_, known := joined.Occupants[nick]
+		delete(joined.Occupants, nick)
+
+		if hasMUCStatus(user, mucStatusNickChange) && newNick != "" {
+			// Move the occupant now so the following available presence under the
+			// new nick is not reported as a join
+			occupant.Nick = newNick
+			joined.Occupants[newNick] = occupant
+			if self {
+				joined.Nick = newNick
+			}
+			break
+		}
+
+		if self {
+			// Kicked, banned or the room was destroyed
+			delete(c.rooms, room)
+			c.logger.Warn("Removed from MUC room",
+				zap.String("room", room),
+				zap.Bool("kicked", hasMUCStatus(user, mucStatusKicked)),
+				zap.Bool("banned", hasMUCStatus(user, mucStatusBanned)),
+			)
+		} else if known && joined.synced {
+			event = eventTypeOccupantLeft
+		}
+
+	default:
+		_, known := joined.Occupants[nick]
+		joined.Occupants[nick] = occupant
+
+		if self {
+			// The service may have changed the nick we asked for
+			joined.Nick = nick
+			joined.Role = occupant.Role
+			joined.Affiliation = occupant.Affiliation
+			joined.synced = true
+		} else if !known && joined.synced {
+			event = eventTypeOccupantJoined
+		}
+	}
+	c.roomsMu.Unlock()
+
+	if event == "" {
+		return
+	}
+
+	c.logger.Debug("MUC occupant presence changed",
+		zap.String("room", room),
+		zap.String("nick", nick),
+		zap.String("event", event),
+	)
+
+	message := models.Message{
+		ID:       pres.Id,
+		From:     pres.From,
+		To:       pres.To,
+		Type:     string(stanza.MessageTypeGroupchat),
+		Event:    event,
+		Occupant: occupant,
+	}
+
+	select {
+	case c.messageChan <- message:
+	default:
+		c.logger.Warn("Message channel full, dropping occupant event",
+			zap.String("room", room),
+			zap.String("event", event),
+		)
+	}
+}
+
+func hasMUCStatus(user MUCUser, code int) bool {
+	for _, status := range user.Statuses {
+		if status.Code == code {
+			return true
+		}
+	}
+	return false
+}
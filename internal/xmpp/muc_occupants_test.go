@@ -0,0 +1,129 @@
+package xmpp
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"gosrc.io/xmpp/stanza"
+)
+
+const testRoom = "inc-1234@conference.example.com"
+
+func decodePresence(t *testing.T, raw string) stanza.Presence {
+	t.Helper()
+	var pres stanza.Presence
+	require.NoError(t, xml.Unmarshal([]byte(raw), &pres))
+	return pres
+}
+
+func newRoomClient(t *testing.T) *Client {
+	t.Helper()
+	client := NewClient(&config.Config{}, zaptest.NewLogger(t))
+	client.rooms[testRoom] = &MUCRoom{JID: testRoom, Nick: "bot", Occupants: make(map[string]*models.Occupant)}
+	return client
+}
+
+func drainEvents(client *Client) []models.Message {
+	var events []models.Message
+	for {
+		select {
+		case msg := <-client.messageChan:
+			events = append(events, msg)
+		default:
+			return events
+		}
+	}
+}
+
+func TestClient_HandleMUCPresence_JoinAndLeave(t *testing.T) {
+	client := newRoomClient(t)
+
+	// Occupants already present are reported before our own presence and raise no events
+	client.handleMUCPresence(decodePresence(t, `<presence from="inc-1234@conference.example.com/alice">
+		<x xmlns="http://jabber.org/protocol/muc#user"><item affiliation="owner" role="moderator" jid="alice@example.com/laptop"/></x>
+	</presence>`))
+	client.handleMUCPresence(decodePresence(t, `<presence from="inc-1234@conference.example.com/bot">
+		<x xmlns="http://jabber.org/protocol/muc#user"><item affiliation="member" role="participant"/><status code="110"/></x>
+	</presence>`))
+	assert.Empty(t, drainEvents(client))
+
+	client.handleMUCPresence(decodePresence(t, `<presence from="inc-1234@conference.example.com/oncall">
+		<show>away</show><status>On a call</status>
+		<x xmlns="http://jabber.org/protocol/muc#user"><item affiliation="none" role="participant"/></x>
+	</presence>`))
+
+	events := drainEvents(client)
+	require.Len(t, events, 1)
+	assert.Equal(t, eventTypeOccupantJoined, events[0].Event)
+	assert.Equal(t, "oncall", events[0].Occupant.Nick)
+	assert.Equal(t, "away", events[0].Occupant.Show)
+
+	state, err := client.GetRoomState(testRoom)
+	require.NoError(t, err)
+	assert.Equal(t, "participant", state.Role)
+	assert.Equal(t, "member", state.Affiliation)
+	require.Len(t, state.Occupants, 3)
+	assert.Equal(t, "alice", state.Occupants[0].Nick)
+	assert.Equal(t, "alice@example.com/laptop", state.Occupants[0].JID)
+
+	client.handleMUCPresence(decodePresence(t, `<presence from="inc-1234@conference.example.com/oncall" type="unavailable">
+		<x xmlns="http://jabber.org/protocol/muc#user"><item affiliation="none" role="none"/></x>
+	</presence>`))
+
+	events = drainEvents(client)
+	require.Len(t, events, 1)
+	assert.Equal(t, eventTypeOccupantLeft, events[0].Event)
+
+	state, err = client.GetRoomState(testRoom)
+	require.NoError(t, err)
+	assert.Len(t, state.Occupants, 2)
+}
+
+func TestClient_HandleMUCPresence_NickChange(t *testing.T) {
+	client := newRoomClient(t)
+	client.rooms[testRoom].synced = true
+	client.rooms[testRoom].Occupants["alice"] = &models.Occupant{Room: testRoom, Nick: "alice"}
+
+	client.handleMUCPresence(decodePresence(t, `<presence from="inc-1234@conference.example.com/alice" type="unavailable">
+		<x xmlns="http://jabber.org/protocol/muc#user"><item affiliation="none" role="participant" nick="alice-away"/><status code="303"/></x>
+	</presence>`))
+	client.handleMUCPresence(decodePresence(t, `<presence from="inc-1234@conference.example.com/alice-away">
+		<x xmlns="http://jabber.org/protocol/muc#user"><item affiliation="none" role="participant"/></x>
+	</presence>`))
+
+	assert.Empty(t, drainEvents(client))
+
+	state, err := client.GetRoomState(testRoom)
+	require.NoError(t, err)
+	require.Len(t, state.Occupants, 1)
+	assert.Equal(t, "alice-away", state.Occupants[0].Nick)
+}
+
+func TestClient_HandleMUCPresence_Kicked(t *testing.T) {
+	client := newRoomClient(t)
+	client.rooms[testRoom].synced = true
+
+	client.handleMUCPresence(decodePresence(t, `<presence from="inc-1234@conference.example.com/bot" type="unavailable">
+		<x xmlns="http://jabber.org/protocol/muc#user"><item affiliation="none" role="none"/><status code="307"/><status code="110"/></x>
+	</presence>`))
+
+	assert.Empty(t, client.JoinedRooms())
+
+	_, err := client.GetRoomState(testRoom)
+	assert.ErrorIs(t, err, ErrRoomNotJoined)
+}
+
+func TestClient_HandleMUCPresence_UnknownRoom(t *testing.T) {
+	client := newRoomClient(t)
+
+	client.handleMUCPresence(decodePresence(t, `<presence from="other@conference.example.com/alice"/>`))
+
+	assert.Empty(t, drainEvents(client))
+	assert.Equal(t, []string{testRoom}, client.JoinedRooms())
+}
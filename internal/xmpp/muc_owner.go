@@ -0,0 +1,222 @@
+package xmpp
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+	"gosrc.io/xmpp/stanza"
+)
+
+const nsMUCOwner = "http://jabber.org/protocol/muc#owner"
+
+// Room configuration form fields (XEP-0045 15.5.3)
+const (
+	roomConfigName         = "muc#roomconfig_roomname"
+	roomConfigDescription  = "muc#roomconfig_roomdesc"
+	roomConfigMembersOnly  = "muc#roomconfig_membersonly"
+	roomConfigPersistent   = "muc#roomconfig_persistentroom"
+	roomConfigPasswordProt = "muc#roomconfig_passwordprotectedroom"
+	roomConfigSecret       = "muc#roomconfig_roomsecret"
+	roomConfigWhois        = "muc#roomconfig_whois"
+	roomConfigArchiving    = "muc#roomconfig_enablearchiving"
+	roomConfigArchivingMAM = "mam"
+	formFieldFormType      = "FORM_TYPE"
+	formTypeRoomConfig     = "http://jabber.org/protocol/muc#roomconfig"
+	formBoolTrue           = "1"
+	formBoolFalse          = "0"
+)
+
+// MUCOwnerQuery is the muc#owner IQ payload used to configure and destroy rooms
+type MUCOwnerQuery struct {
+	XMLName xml.Name     `xml:"http://jabber.org/protocol/muc#owner query"`
+	Form    *stanza.Form `xml:"jabber:x:data x,omitempty"`
+	Destroy *MUCDestroy  `xml:"destroy,omitempty"`
+}
+
+func (q MUCOwnerQuery) Namespace() string {
+	return nsMUCOwner
+}
+
+func (q MUCOwnerQuery) GetSet() *stanza.ResultSet {
+	return nil
+}
+
+// MUCDestroy requests the destruction of a room, optionally pointing occupants to an alternate venue
+type MUCDestroy struct {
+	XMLName xml.Name `xml:"destroy"`
+	JID     string   `xml:"jid,attr,omitempty"`
+	Reason  string   `xml:"reason,omitempty"`
+}
+
+func init() {
+	stanza.TypeRegistry.MapExtension(stanza.PKTIQ, xml.Name{Space: nsMUCOwner, Local: "query"}, MUCOwnerQuery{})
+}
+
+// CreateRoom creates a room by joining it and submitting the configuration form.
+// Joining an existing room the bot owns simply reconfigures it.
+func (c *Client) CreateRoom(room, nick string, settings models.MUCRoomSettings) error {
+	password := ""
+	if settings.Password != nil {
+		password = *settings.Password
+	}
+
+	if err := c.JoinRoom(room, nick, password); err != nil {
+		return err
+	}
+
+	if err := c.ConfigureRoom(room, settings); err != nil {
+		return err
+	}
+
+	c.logger.Info("MUC room created", zap.String("room", bareJID(room)))
+	return nil
+}
+
+// ConfigureRoom fetches the room configuration form, applies the settings and submits it
+func (c *Client) ConfigureRoom(room string, settings models.MUCRoomSettings) error {
+	if !c.isConnected() {
+		return fmt.Errorf("XMPP client is not connected")
+	}
+
+	room = bareJID(room)
+
+	iq := newIQ("muc-owner", stanza.IQTypeGet, room, &MUCOwnerQuery{})
+	resp, err := c.sendIQ(iq, defaultIQTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to get room configuration: %w", err)
+	}
+
+	query, ok := resp.Payload.(*MUCOwnerQuery)
+	if !ok || query.Form == nil {
+		return fmt.Errorf("failed to get room configuration: no configuration form returned")
+	}
+
+	form, err := applyRoomSettings(query.Form, settings)
+	if err != nil {
+		return err
+	}
+
+	iq = newIQ("muc-owner", stanza.IQTypeSet, room, &MUCOwnerQuery{Form: form})
+	if _, err := c.sendIQ(iq, defaultIQTimeout); err != nil {
+		return fmt.Errorf("failed to submit room configuration: %w", err)
+	}
+
+	if settings.Password != nil {
+		c.roomsMu.Lock()
+		if joined, ok := c.rooms[room]; ok {
+			joined.Password = *settings.Password
+		}
+		c.roomsMu.Unlock()
+	}
+
+	c.logger.Info("MUC room configured", zap.String("room", room))
+	return nil
+}
+
+// DestroyRoom destroys a room. Occupants are pointed to the alternate venue if one is given.
+func (c *Client) DestroyRoom(room, alternateVenue, reason string) error {
+	if !c.isConnected() {
+		return fmt.Errorf("XMPP client is not connected")
+	}
+
+	room = bareJID(room)
+
+	iq := newIQ("muc-owner", stanza.IQTypeSet, room, &MUCOwnerQuery{
+		Destroy: &MUCDestroy{JID: alternateVenue, Reason: reason},
+	})
+	if _, err := c.sendIQ(iq, defaultIQTimeout); err != nil {
+		return fmt.Errorf("failed to destroy room: %w", err)
+	}
+
+	c.roomsMu.Lock()
+	delete(c.rooms, room)
+	c.roomsMu.Unlock()
+
+	c.logger.Info("MUC room destroyed",
+		zap.String("room", room),
+		zap.String("alternate_venue", alternateVenue),
+	)
+
+	return nil
+}
+
+// applyRoomSettings builds a submit form from the form offered by the server.
+// Settings the server does not offer are reported as an error rather than silently dropped.
+func applyRoomSettings(offered *stanza.Form, settings models.MUCRoomSettings) (*stanza.Form, error) {
+	values := make(map[string][]string)
+	var order []string
+	for _, field := range offered.Fields {
+		if field.Var == "" || field.Type == stanza.FieldTypeFixed {
+			continue
+		}
+		values[field.Var] = field.ValuesList
+		order = append(order, field.Var)
+	}
+
+	var unsupported []string
+	set := func(setting string, value string, vars ...string) {
+		for _, v := range vars {
+			if _, ok := values[v]; ok {
+				values[v] = []string{value}
+				return
+			}
+		}
+		unsupported = append(unsupported, setting)
+	}
+
+	if settings.Name != nil {
+		set("name", *settings.Name, roomConfigName)
+	}
+	if settings.Description != nil {
+		set("description", *settings.Description, roomConfigDescription)
+	}
+	if settings.MembersOnly != nil {
+		set("members_only", formBool(*settings.MembersOnly), roomConfigMembersOnly)
+	}
+	if settings.Persistent != nil {
+		set("persistent", formBool(*settings.Persistent), roomConfigPersistent)
+	}
+	if settings.Password != nil {
+		set("password", *settings.Password, roomConfigSecret)
+		// Not every server offers a separate flag, the secret alone is enough there
+		if _, ok := values[roomConfigPasswordProt]; ok {
+			values[roomConfigPasswordProt] = []string{formBool(*settings.Password != "")}
+		}
+	}
+	if settings.Whois != "" {
+		set("whois", settings.Whois, roomConfigWhois)
+	}
+	if settings.Archiving != nil {
+		// Prosody uses the muc#roomconfig field, ejabberd a plain "mam" field
+		set("archiving", formBool(*settings.Archiving), roomConfigArchiving, roomConfigArchivingMAM)
+	}
+
+	if len(unsupported) > 0 {
+		return nil, fmt.Errorf("room configuration not supported by server: %s", strings.Join(unsupported, ", "))
+	}
+
+	form := &stanza.Form{Type: stanza.FormTypeSubmit}
+	if _, ok := values[formFieldFormType]; !ok {
+		form.Fields = append(form.Fields, &stanza.Field{
+			Var:        formFieldFormType,
+			Type:       stanza.FieldTypeHidden,
+			ValuesList: []string{formTypeRoomConfig},
+		})
+	}
+	for _, v := range order {
+		form.Fields = append(form.Fields, &stanza.Field{Var: v, ValuesList: values[v]})
+	}
+
+	return form, nil
+}
+
+func formBool(b bool) string {
+	if b {
+		return formBoolTrue
+	}
+	return formBoolFalse
+}
@@ -0,0 +1,120 @@
+package xmpp
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"gosrc.io/xmpp/stanza"
+)
+
+const roomConfigFormIQ = `<iq type="result" id="muc-owner-1" from="inc-1234@conference.example.com">
+	<query xmlns="http://jabber.org/protocol/muc#owner">
+		<x xmlns="jabber:x:data" type="form">
+			<title>Room configuration</title>
+			<field var="FORM_TYPE" type="hidden"><value>http://jabber.org/protocol/muc#roomconfig</value></field>
+			<field type="fixed"><value>General</value></field>
+			<field var="muc#roomconfig_roomname" type="text-single"><value></value></field>
+			<field var="muc#roomconfig_roomdesc" type="text-single"><value></value></field>
+			<field var="muc#roomconfig_membersonly" type="boolean"><value>0</value></field>
+			<field var="muc#roomconfig_persistentroom" type="boolean"><value>0</value></field>
+			<field var="muc#roomconfig_roomsecret" type="text-private"><value></value></field>
+			<field var="muc#roomconfig_whois" type="list-single"><value>moderators</value></field>
+			<field var="mam" type="boolean"><value>0</value></field>
+		</x>
+	</query>
+</iq>`
+
+func decodeRoomConfigForm(t *testing.T) *stanza.Form {
+	t.Helper()
+	var iq stanza.IQ
+	require.NoError(t, xml.Unmarshal([]byte(roomConfigFormIQ), &iq))
+
+	query, ok := iq.Payload.(*MUCOwnerQuery)
+	require.True(t, ok)
+	require.NotNil(t, query.Form)
+	return query.Form
+}
+
+func formValues(form *stanza.Form) map[string][]string {
+	values := make(map[string][]string)
+	for _, field := range form.Fields {
+		values[field.Var] = field.ValuesList
+	}
+	return values
+}
+
+func TestApplyRoomSettings(t *testing.T) {
+	name := "INC-1234"
+	password := "secret"
+	yes := true
+
+	form, err := applyRoomSettings(decodeRoomConfigForm(t), models.MUCRoomSettings{
+		Name:        &name,
+		MembersOnly: &yes,
+		Persistent:  &yes,
+		Password:    &password,
+		Whois:       "anyone",
+		Archiving:   &yes,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, stanza.FormTypeSubmit, form.Type)
+
+	values := formValues(form)
+	assert.Equal(t, []string{formTypeRoomConfig}, values[formFieldFormType])
+	assert.Equal(t, []string{"INC-1234"}, values[roomConfigName])
+	assert.Equal(t, []string{"1"}, values[roomConfigMembersOnly])
+	assert.Equal(t, []string{"1"}, values[roomConfigPersistent])
+	assert.Equal(t, []string{"secret"}, values[roomConfigSecret])
+	assert.Equal(t, []string{"anyone"}, values[roomConfigWhois])
+	assert.Equal(t, []string{"1"}, values[roomConfigArchivingMAM])
+	// Untouched fields keep the server defaults and fixed fields are dropped
+	assert.Equal(t, []string{""}, values[roomConfigDescription])
+	assert.NotContains(t, values, "")
+}
+
+func TestApplyRoomSettings_Unsupported(t *testing.T) {
+	yes := true
+	offered := &stanza.Form{Type: stanza.FormTypeForm, Fields: []*stanza.Field{
+		{Var: roomConfigName},
+	}}
+
+	_, err := applyRoomSettings(offered, models.MUCRoomSettings{Archiving: &yes})
+	assert.ErrorContains(t, err, "archiving")
+}
+
+func TestApplyRoomSettings_AddsFormType(t *testing.T) {
+	form, err := applyRoomSettings(&stanza.Form{Type: stanza.FormTypeForm}, models.MUCRoomSettings{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{formTypeRoomConfig}, formValues(form)[formFieldFormType])
+}
+
+func TestMUCOwnerQuery_MarshalDestroy(t *testing.T) {
+	iq := newIQ("muc-owner", stanza.IQTypeSet, "inc-1234@conference.example.com", &MUCOwnerQuery{
+		Destroy: &MUCDestroy{JID: "postmortem@conference.example.com", Reason: "Resolved"},
+	})
+
+	data, err := xml.Marshal(iq)
+	require.NoError(t, err)
+
+	out := string(data)
+	assert.Contains(t, out, `xmlns="http://jabber.org/protocol/muc#owner"`)
+	assert.Contains(t, out, `<destroy jid="postmortem@conference.example.com"><reason>Resolved</reason></destroy>`)
+	assert.NotContains(t, out, "jabber:x:data")
+}
+
+func TestClient_MUCOwner_NotConnected(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	client := NewClient(&config.Config{}, logger)
+
+	assert.ErrorContains(t, client.CreateRoom("room@conference.example.com", "", models.MUCRoomSettings{}), "not connected")
+	assert.ErrorContains(t, client.ConfigureRoom("room@conference.example.com", models.MUCRoomSettings{}), "not connected")
+	assert.ErrorContains(t, client.DestroyRoom("room@conference.example.com", "", ""), "not connected")
+}
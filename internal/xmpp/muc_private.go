@@ -0,0 +1,61 @@
+package xmpp
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"gosrc.io/xmpp/stanza"
+)
+
+// SendMUCPrivateMessage sends a private message to a room occupant (XEP-0045 7.5)
+func (c *Client) SendMUCPrivateMessage(room, nick, body string) error {
+	if !c.isConnected() {
+		return fmt.Errorf("XMPP client is not connected")
+	}
+
+	to := bareJID(room) + "/" + nick
+
+	msg := stanza.Message{
+		Attrs: stanza.Attrs{
+			To:   to,
+			Type: stanza.MessageTypeChat,
+		},
+		Body: body,
+		// The empty muc#user element marks the message as a MUC PM for the recipient's client
+		Extensions: []stanza.MsgExtension{MUCUser{}, stanza.StateActive{}},
+	}
+
+	if err := c.stream().Send(msg); err != nil {
+		c.logger.Error("Failed to send MUC private message",
+			zap.String("to", to),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to send private message: %w", err)
+	}
+
+	c.logger.Info("MUC private message sent",
+		zap.String("to", to),
+		zap.Int("body_length", len(body)),
+	)
+
+	return nil
+}
+
+// isOccupantJID reports whether a full JID is an occupant of a room the bot has joined
+func (c *Client) isOccupantJID(jid string) bool {
+	if jidResource(jid) == "" {
+		return false
+	}
+	_, ok := c.getRoom(bareJID(jid))
+	return ok
+}
+
+// isMUCPrivateMessage reports whether a chat message was sent privately by a room occupant
+func (c *Client) isMUCPrivateMessage(msg stanza.Message) bool {
+	if msg.Type == stanza.MessageTypeGroupchat {
+		return false
+	}
+
+	var user MUCUser
+	return msg.Get(&user) || c.isOccupantJID(msg.From)
+}
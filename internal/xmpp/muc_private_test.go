@@ -0,0 +1,54 @@
+package xmpp
+
+import (
+	"testing"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestClient_IsMUCPrivateMessage(t *testing.T) {
+	client := newRoomClient(t)
+
+	tests := []struct {
+		name    string
+		raw     string
+		private bool
+	}{
+		{
+			name:    "occupant of joined room",
+			raw:     `<message from="inc-1234@conference.example.com/alice" type="chat"><body>psst</body></message>`,
+			private: true,
+		},
+		{
+			name:    "marked with muc#user",
+			raw:     `<message from="other@conference.example.com/bob" type="chat"><body>psst</body><x xmlns="http://jabber.org/protocol/muc#user"/></message>`,
+			private: true,
+		},
+		{
+			name:    "regular chat",
+			raw:     `<message from="alice@example.com/laptop" type="chat"><body>hi</body></message>`,
+			private: false,
+		},
+		{
+			name:    "groupchat",
+			raw:     `<message from="inc-1234@conference.example.com/alice" type="groupchat"><body>hi all</body></message>`,
+			private: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.private, client.isMUCPrivateMessage(decodeMessage(t, tt.raw)))
+		})
+	}
+}
+
+func TestClient_SendMUCPrivateMessage_NotConnected(t *testing.T) {
+	client := NewClient(&config.Config{}, zaptest.NewLogger(t))
+
+	err := client.SendMUCPrivateMessage(testRoom, "alice", "psst")
+	assert.ErrorContains(t, err, "not connected")
+}
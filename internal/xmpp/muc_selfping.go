@@ -0,0 +1,184 @@
+package xmpp
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+	"gosrc.io/xmpp/stanza"
+)
+
+const nsPing = "urn:xmpp:ping"
+
+// Ping is the XMPP ping IQ payload (XEP-0199), used for MUC self-ping (XEP-0410)
+type Ping struct {
+	XMLName xml.Name `xml:"urn:xmpp:ping ping"`
+}
+
+func (p Ping) Namespace() string {
+	return nsPing
+}
+
+func (p Ping) GetSet() *stanza.ResultSet {
+	return nil
+}
+
+func init() {
+	stanza.TypeRegistry.MapExtension(stanza.PKTIQ, xml.Name{Space: nsPing, Local: "ping"}, Ping{})
+}
+
+// selfPingResult is what a self-ping response says about our presence in a room
+type selfPingResult int
+
+const (
+	pingJoined       selfPingResult = iota
+	pingNotJoined                   // the room no longer knows us, rejoin
+	pingInconclusive                // the room could not be reached, try again on the next tick
+)
+
+// roomHealth holds the self-ping state of a joined room
+type roomHealth struct {
+	healthy   bool
+	lastPing  time.Time
+	lastError string
+	rejoins   int
+}
+
+// RoomsHealth returns the self-ping health of all joined rooms
+func (c *Client) RoomsHealth() []models.MUCRoomHealth {
+	c.roomsMu.RLock()
+	defer c.roomsMu.RUnlock()
+
+	rooms := make([]models.MUCRoomHealth, 0, len(c.rooms))
+	for _, joined := range c.rooms {
+		health := models.MUCRoomHealth{
+			Room:      joined.JID,
+			Nick:      joined.Nick,
+			Joined:    joined.synced,
+			Healthy:   joined.health.healthy,
+			LastError: joined.health.lastError,
+			Rejoins:   joined.health.rejoins,
+		}
+		if !joined.health.lastPing.IsZero() {
+			health.LastPing = joined.health.lastPing.UTC().Format(time.RFC3339)
+		}
+		rooms = append(rooms, health)
+	}
+	slices.SortFunc(rooms, func(a, b models.MUCRoomHealth) int {
+		return strings.Compare(a.Room, b.Room)
+	})
+
+	return rooms
+}
+
+// selfPingLoop periodically checks that the bot is still joined to its rooms and rejoins
+// rooms it was silently removed from, e.g. after a MUC service restart
+func (c *Client) selfPingLoop(ctx context.Context) {
+	interval := c.config.MUC.SelfPingInterval
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !c.isConnected() {
+				continue
+			}
+			for _, room := range c.JoinedRooms() {
+				c.selfPingRoom(room)
+			}
+		}
+	}
+}
+
+// selfPingRoom pings our own occupant JID and rejoins the room if we are no longer in it
+func (c *Client) selfPingRoom(room string) {
+	joined, ok := c.getRoom(room)
+	if !ok {
+		return
+	}
+	nick, password := joined.Nick, joined.Password
+
+	iq := newIQ("self-ping", stanza.IQTypeGet, room+"/"+nick, &Ping{})
+	_, err := c.sendIQ(iq, defaultIQTimeout)
+	result := selfPingResultOf(err)
+
+	c.roomsMu.Lock()
+	if joined, ok := c.rooms[room]; ok {
+		joined.health.lastPing = time.Now()
+		joined.health.lastError = ""
+		if result != pingJoined {
+			joined.health.lastError = err.Error()
+		}
+		// An inconclusive ping keeps the health of the previous one
+		if result != pingInconclusive {
+			joined.health.healthy = result == pingJoined
+		}
+	}
+	c.roomsMu.Unlock()
+
+	switch result {
+	case pingJoined:
+		return
+	case pingInconclusive:
+		c.logger.Debug("MUC self-ping inconclusive, retrying on the next tick",
+			zap.String("room", room),
+			zap.Error(err),
+		)
+		return
+	}
+
+	c.logger.Warn("MUC self-ping failed, rejoining room",
+		zap.String("room", room),
+		zap.Error(err),
+	)
+
+	if err := c.JoinRoom(room, nick, password); err != nil {
+		c.logger.Error("Failed to rejoin MUC room",
+			zap.String("room", room),
+			zap.Error(err),
+		)
+		return
+	}
+
+	c.roomsMu.Lock()
+	if joined, ok := c.rooms[room]; ok {
+		joined.health.rejoins++
+	}
+	c.roomsMu.Unlock()
+}
+
+// selfPingResultOf interprets a self-ping response (XEP-0410 section 3.2). A result, or an error
+// from our own client forwarded by the room, means we are still joined. Timeouts and errors of
+// the servers on the way are inconclusive; any other error from the room means we are not joined.
+func selfPingResultOf(err error) selfPingResult {
+	if err == nil {
+		return pingJoined
+	}
+
+	var iqErr *IQError
+	if !errors.As(err, &iqErr) {
+		return pingInconclusive
+	}
+
+	switch iqErr.Condition {
+	case "service-unavailable", "feature-not-implemented":
+		return pingJoined
+	case "remote-server-not-found", "remote-server-timeout":
+		return pingInconclusive
+	default:
+		return pingNotJoined
+	}
+}
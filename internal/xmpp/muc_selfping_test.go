@@ -0,0 +1,39 @@
+package xmpp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfPingResultOf(t *testing.T) {
+	assert.Equal(t, pingJoined, selfPingResultOf(nil))
+	assert.Equal(t, pingJoined, selfPingResultOf(&IQError{Condition: "service-unavailable"}))
+	assert.Equal(t, pingJoined, selfPingResultOf(&IQError{Condition: "feature-not-implemented"}))
+	assert.Equal(t, pingNotJoined, selfPingResultOf(&IQError{Condition: "not-acceptable"}))
+	assert.Equal(t, pingNotJoined, selfPingResultOf(&IQError{Condition: "item-not-found"}))
+	assert.Equal(t, pingInconclusive, selfPingResultOf(&IQError{Condition: "remote-server-timeout"}))
+	assert.Equal(t, pingInconclusive, selfPingResultOf(errors.New("timeout waiting for IQ response")))
+}
+
+func TestClient_RoomsHealth(t *testing.T) {
+	client := newRoomClient(t)
+	lastPing := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	joined := client.rooms[testRoom]
+	joined.synced = true
+	joined.health = roomHealth{healthy: false, lastPing: lastPing, lastError: "not-acceptable", rejoins: 2}
+
+	rooms := client.RoomsHealth()
+
+	require.Len(t, rooms, 1)
+	assert.Equal(t, testRoom, rooms[0].Room)
+	assert.True(t, rooms[0].Joined)
+	assert.False(t, rooms[0].Healthy)
+	assert.Equal(t, "2026-01-02T03:04:05Z", rooms[0].LastPing)
+	assert.Equal(t, "not-acceptable", rooms[0].LastError)
+	assert.Equal(t, 2, rooms[0].Rejoins)
+}
@@ -0,0 +1,187 @@
+package xmpp
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"gosrc.io/xmpp/stanza"
+)
+
+func decodeMessage(t *testing.T, raw string) stanza.Message {
+	t.Helper()
+	var msg stanza.Message
+	require.NoError(t, xml.Unmarshal([]byte(raw), &msg))
+	return msg
+}
+
+func TestParseInvitation_Direct(t *testing.T) {
+	msg := decodeMessage(t, `<message from="alice@example.com/laptop" to="bot@example.com">
+		<x xmlns="jabber:x:conference" jid="inc-1234@conference.example.com" password="secret" reason="Incident"/>
+	</message>`)
+
+	invite, password, ok := parseInvitation(msg)
+
+	require.True(t, ok)
+	assert.Equal(t, "inc-1234@conference.example.com", invite.Room)
+	assert.Equal(t, "alice@example.com/laptop", invite.Inviter)
+	assert.Equal(t, "Incident", invite.Reason)
+	assert.False(t, invite.Mediated)
+	assert.Equal(t, "secret", password)
+}
+
+func TestParseInvitation_Mediated(t *testing.T) {
+	msg := decodeMessage(t, `<message from="inc-1234@conference.example.com" to="bot@example.com">
+		<x xmlns="http://jabber.org/protocol/muc#user">
+			<invite from="alice@example.com/laptop"><reason>Join us</reason></invite>
+			<password>secret</password>
+		</x>
+	</message>`)
+
+	invite, password, ok := parseInvitation(msg)
+
+	require.True(t, ok)
+	assert.Equal(t, "inc-1234@conference.example.com", invite.Room)
+	assert.Equal(t, "alice@example.com/laptop", invite.Inviter)
+	assert.Equal(t, "Join us", invite.Reason)
+	assert.True(t, invite.Mediated)
+	assert.Equal(t, "secret", password)
+}
+
+func TestParseInvitation_NotInvitation(t *testing.T) {
+	msg := decodeMessage(t, `<message from="alice@example.com" to="bot@example.com" type="chat"><body>Hello</body></message>`)
+
+	_, _, ok := parseInvitation(msg)
+	assert.False(t, ok)
+}
+
+func TestIsInviteAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.InvitationConfig
+		inviter string
+		allowed bool
+	}{
+		{
+			name:    "auto accept disabled",
+			cfg:     config.InvitationConfig{AutoAccept: false},
+			inviter: "alice@example.com",
+			allowed: false,
+		},
+		{
+			name:    "empty allowlists accept anyone",
+			cfg:     config.InvitationConfig{AutoAccept: true},
+			inviter: "mallory@evil.org",
+			allowed: true,
+		},
+		{
+			name:    "allowed domain",
+			cfg:     config.InvitationConfig{AutoAccept: true, AllowedDomains: []string{"example.com"}},
+			inviter: "alice@example.com/laptop",
+			allowed: true,
+		},
+		{
+			name:    "allowed inviter is case insensitive",
+			cfg:     config.InvitationConfig{AutoAccept: true, AllowedInviters: []string{"Alice@Example.com"}},
+			inviter: "alice@example.com/laptop",
+			allowed: true,
+		},
+		{
+			name:    "inviter not on allowlist",
+			cfg:     config.InvitationConfig{AutoAccept: true, AllowedDomains: []string{"example.com"}, AllowedInviters: []string{"bob@other.org"}},
+			inviter: "mallory@evil.org",
+			allowed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, isInviteAllowed(tt.cfg, tt.inviter))
+		})
+	}
+}
+
+func TestClient_HandleInvitation_Direct(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	client := NewClient(&config.Config{}, logger)
+
+	msg := decodeMessage(t, `<message from="alice@example.com/laptop" to="bot@example.com">
+		<x xmlns="jabber:x:conference" jid="inc-1234@conference.example.com"/>
+	</message>`)
+
+	require.True(t, client.handleInvitation(msg))
+
+	event := <-client.messageChan
+	assert.Equal(t, "alice@example.com/laptop", event.From)
+	require.NotNil(t, event.Invite)
+	assert.False(t, event.Invite.Accepted)
+}
+
+func TestClient_HandleInvitation_MediatedNotFromRoom(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	client := NewClient(&config.Config{
+		MUC: config.MUCConfig{
+			Rooms:       []config.MUCRoomConfig{{JID: "ops@conference.example.com"}},
+			Invitations: config.InvitationConfig{AutoAccept: true},
+		},
+	}, logger)
+
+	for _, from := range []string{"mallory@example.com/laptop", "conference.example.com", "inc-1234@conference.example.com/alice"} {
+		msg := decodeMessage(t, `<message from="`+from+`" to="bot@example.com">
+			<x xmlns="http://jabber.org/protocol/muc#user"><invite from="admin@example.com"/></x>
+		</message>`)
+
+		assert.True(t, client.handleInvitation(msg), from)
+	}
+
+	assert.Empty(t, client.messageChan)
+}
+
+func TestClient_IsMUCService(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	client := NewClient(&config.Config{
+		MUC: config.MUCConfig{Rooms: []config.MUCRoomConfig{{JID: "ops@conference.example.com"}}},
+	}, logger)
+	client.rooms["team@muc.example.org"] = &MUCRoom{JID: "team@muc.example.org"}
+	client.mucServices["rooms.example.net"] = true
+
+	assert.True(t, client.isMUCService("conference.example.com"))
+	assert.True(t, client.isMUCService("MUC.example.org"))
+	assert.True(t, client.isMUCService("rooms.example.net"))
+
+	// Unknown domains need service discovery, which fails while disconnected
+	assert.False(t, client.isMUCService("evil.example.com"))
+}
+
+func TestClient_JoinRoom_NotConnected(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	client := NewClient(&config.Config{}, logger)
+
+	err := client.JoinRoom("room@conference.example.com", "bot", "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not connected")
+	assert.Empty(t, client.JoinedRooms())
+}
+
+func TestClient_DefaultNick(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	client := NewClient(&config.Config{XMPP: config.XMPPConfig{JID: "bot@example.com"}}, logger)
+	assert.Equal(t, "bot", client.defaultNick())
+
+	client = NewClient(&config.Config{MUC: config.MUCConfig{Nick: "Alerts"}}, logger)
+	assert.Equal(t, "Alerts", client.defaultNick())
+}
+
+func TestManager_InviteToRoom_NoDefaultClient(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	manager := NewManager(&config.Config{}, logger)
+
+	err := manager.InviteToRoom("", "room@conference.example.com", []string{"alice@example.com"}, "", false)
+	assert.Equal(t, ErrNoDefaultClient, err)
+}
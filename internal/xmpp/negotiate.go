@@ -0,0 +1,333 @@
+package xmpp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"jabber-bot/internal/config"
+
+	"gosrc.io/xmpp/stanza"
+)
+
+const (
+	defaultDirectTLSPort = 5223
+	dialTimeout          = 15 * time.Second
+	negotiateTimeout     = 30 * time.Second
+)
+
+const (
+	clientStreamHeader = "<?xml version='1.0'?><stream:stream to='%s' xmlns='" + stanza.NSClient + "' xmlns:stream='" + stanza.NSStream + "' version='1.0'>"
+	nsTLS              = "urn:ietf:params:xml:ns:xmpp-tls"
+)
+
+// negotiatedDialer returns the dialer of a stream the bot negotiates itself: it connects to a
+// server, secures the stream with Direct TLS or STARTTLS and authenticates with the configured
+// SASL mechanism. The library receives the authenticated connection and goes on with the stream
+// restart and resource binding.
+func (c *Client) negotiatedDialer(proxyURL *url.URL, directTLS bool, tlsConfig *tls.Config) func(network, address string) (net.Conn, error) {
+	return func(network, address string) (net.Conn, error) {
+		var conn net.Conn
+		var err error
+		if proxyURL != nil {
+			conn, err = dialProxy(proxyURL, address)
+		} else {
+			conn, err = net.DialTimeout(network, address, dialTimeout)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		negotiated, err := negotiateStream(conn, jidDomain(c.config.XMPP.JID), directTLS, tlsConfig, c.config.XMPP)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return negotiated, nil
+	}
+}
+
+// negotiatedAddress returns the address of a target with the default port of its TLS mode
+func negotiatedAddress(target serverTarget) string {
+	if _, _, err := net.SplitHostPort(target.Address); err == nil {
+		return target.Address
+	}
+	port := defaultClientPort
+	if target.DirectTLS {
+		port = defaultDirectTLSPort
+	}
+	return net.JoinHostPort(target.Address, strconv.Itoa(port))
+}
+
+// negotiateStream secures and authenticates a client stream on conn and returns the connection
+// to continue the stream on, right after SASL success
+func negotiateStream(conn net.Conn, domain string, directTLS bool, tlsConfig *tls.Config, cfg config.XMPPConfig) (net.Conn, error) {
+	//goland:noinspection GoUnhandledErrorResult
+	conn.SetDeadline(time.Now().Add(negotiateTimeout))
+
+	tlsConfig = tlsConfig.Clone()
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = domain
+	}
+
+	if directTLS {
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	s := newXMLStream(conn)
+	features, err := s.open(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	if !directTLS {
+		if _, ok := features.DoesStartTLS(); !ok {
+			return nil, errors.New("server does not offer STARTTLS")
+		}
+		if _, err := fmt.Fprintf(conn, "<starttls xmlns='%s'/>", nsTLS); err != nil {
+			return nil, err
+		}
+		reply, err := s.nextNonza()
+		if err != nil {
+			return nil, err
+		}
+		if reply.XMLName.Local != "proceed" {
+			return nil, errors.New("server refused STARTTLS")
+		}
+
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+
+		s = newXMLStream(conn)
+		if features, err = s.open(domain); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.authenticate(features, cfg); err != nil {
+		return nil, err
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	conn.SetDeadline(time.Time{})
+	return s.conn(), nil
+}
+
+// xmlStream reads the top-level elements of an XMPP stream. Reads go through a buffered reader
+// that is handed on with the connection, so nothing the decoder buffered is lost.
+type xmlStream struct {
+	raw     net.Conn
+	reader  *bufio.Reader
+	decoder *xml.Decoder
+}
+
+func newXMLStream(conn net.Conn) *xmlStream {
+	reader := bufio.NewReader(conn)
+	return &xmlStream{raw: conn, reader: reader, decoder: xml.NewDecoder(reader)}
+}
+
+// conn returns the connection with the bytes read ahead
+func (s *xmlStream) conn() net.Conn {
+	return &bufferedConn{Conn: s.raw, reader: s.reader}
+}
+
+// open sends the stream header and returns the stream features of the server
+func (s *xmlStream) open(domain string) (stanza.StreamFeatures, error) {
+	var features stanza.StreamFeatures
+	if _, err := fmt.Fprintf(s.raw, clientStreamHeader, domain); err != nil {
+		return features, err
+	}
+	if err := s.awaitHeader(); err != nil {
+		return features, err
+	}
+
+	el, err := s.next()
+	if err != nil {
+		return features, err
+	}
+	if el.Name.Space != stanza.NSStream || el.Name.Local != "features" {
+		return features, fmt.Errorf("expected stream features, got <%s>", el.Name.Local)
+	}
+	if err := s.decoder.DecodeElement(&features, &el); err != nil {
+		return features, fmt.Errorf("failed to parse stream features: %w", err)
+	}
+	return features, nil
+}
+
+// awaitHeader reads up to the stream header of the peer
+func (s *xmlStream) awaitHeader() error {
+	for {
+		token, err := s.decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read stream header: %w", err)
+		}
+		if el, ok := token.(xml.StartElement); ok {
+			if el.Name.Space != stanza.NSStream || el.Name.Local != "stream" {
+				return fmt.Errorf("expected stream header, got <%s>", el.Name.Local)
+			}
+			return nil
+		}
+	}
+}
+
+// next returns the start of the next top-level element. A stream error is returned as error.
+func (s *xmlStream) next() (xml.StartElement, error) {
+	for {
+		token, err := s.decoder.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		switch el := token.(type) {
+		case xml.StartElement:
+			if el.Name.Space == stanza.NSStream && el.Name.Local == "error" {
+				var streamErr nonza
+				if err := s.decoder.DecodeElement(&streamErr, &el); err != nil {
+					return xml.StartElement{}, err
+				}
+				return xml.StartElement{}, fmt.Errorf("stream error: %s", streamErr.reason())
+			}
+			return el, nil
+		case xml.EndElement:
+			return xml.StartElement{}, errors.New("server closed the stream")
+		}
+	}
+}
+
+// nextNonza reads the next top-level element
+func (s *xmlStream) nextNonza() (nonza, error) {
+	var n nonza
+	el, err := s.next()
+	if err != nil {
+		return n, err
+	}
+	err = s.decoder.DecodeElement(&n, &el)
+	return n, err
+}
+
+// authenticate runs the SASL exchange of the configured mechanism
+func (s *xmlStream) authenticate(features stanza.StreamFeatures, cfg config.XMPPConfig) error {
+	mechanism := cfg.SASL.Mechanism
+	if !slices.Contains(features.Mechanisms.Mechanism, mechanism) {
+		return fmt.Errorf("server does not offer SASL mechanism %s (offered: %s)",
+			mechanism, strings.Join(features.Mechanisms.Mechanism, ", "))
+	}
+
+	var initial string
+	var scram *scramClient
+	switch mechanism {
+	case config.SASLPlain, config.SASLOAuth2:
+		initial = "\x00" + jidLocal(cfg.JID) + "\x00" + cfg.Password
+	case config.SASLScramSHA1, config.SASLScramSHA256:
+		var err error
+		if scram, err = newScramClient(mechanism, jidLocal(cfg.JID), cfg.Password); err != nil {
+			return err
+		}
+		initial = scram.first()
+	}
+
+	// EXTERNAL sends an empty response: the server takes the identity from the client certificate
+	payload := "="
+	if initial != "" {
+		payload = base64.StdEncoding.EncodeToString([]byte(initial))
+	}
+	if _, err := fmt.Fprintf(s.raw, "<auth xmlns='%s' mechanism='%s'>%s</auth>", stanza.NSSASL, mechanism, payload); err != nil {
+		return err
+	}
+
+	verified := false
+	for {
+		reply, err := s.nextNonza()
+		if err != nil {
+			return fmt.Errorf("SASL %s: %w", mechanism, err)
+		}
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(reply.Data))
+		if err != nil {
+			return fmt.Errorf("SASL %s: invalid %s data", mechanism, reply.XMLName.Local)
+		}
+
+		switch reply.XMLName.Local {
+		case "challenge":
+			if scram == nil || verified {
+				return fmt.Errorf("SASL %s: unexpected challenge", mechanism)
+			}
+			response := ""
+			if scram.serverSignature == nil {
+				if response, err = scram.final(string(data)); err != nil {
+					return fmt.Errorf("SASL %s: %w", mechanism, err)
+				}
+			} else {
+				// Some servers send the server-final-message as a challenge before success
+				if err := scram.verify(string(data)); err != nil {
+					return fmt.Errorf("SASL %s: %w", mechanism, err)
+				}
+				verified = true
+			}
+			if _, err := fmt.Fprintf(s.raw, "<response xmlns='%s'>%s</response>",
+				stanza.NSSASL, base64.StdEncoding.EncodeToString([]byte(response))); err != nil {
+				return err
+			}
+		case "success":
+			if scram != nil && !verified {
+				if scram.serverSignature == nil {
+					return fmt.Errorf("SASL %s: server skipped the challenge", mechanism)
+				}
+				if err := scram.verify(string(data)); err != nil {
+					return fmt.Errorf("SASL %s: %w", mechanism, err)
+				}
+			}
+			return nil
+		case "failure":
+			return fmt.Errorf("authentication with %s failed: %s", mechanism, reply.reason())
+		default:
+			return fmt.Errorf("SASL %s: unexpected <%s>", mechanism, reply.XMLName.Local)
+		}
+	}
+}
+
+// nonza is a top-level stream element: a STARTTLS or SASL reply, or a stream error
+type nonza struct {
+	XMLName   xml.Name
+	Data      string `xml:",chardata"`
+	Text      string `xml:"text"`
+	Condition struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+// reason returns the defined condition and text of a failure or stream error
+func (n nonza) reason() string {
+	reason := n.Condition.XMLName.Local
+	if reason == "" {
+		reason = "unknown"
+	}
+	if n.Text != "" {
+		reason += " (" + n.Text + ")"
+	}
+	return reason
+}
+
+// bufferedConn reads a connection through the reader that already buffered part of it
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
@@ -0,0 +1,301 @@
+package xmpp
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"testing"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gosrc.io/xmpp/stanza"
+)
+
+const serverStreamHeader = "<?xml version='1.0'?><stream:stream from='%s' id='%s' xmlns='" + stanza.NSClient + "' xmlns:stream='" + stanza.NSStream + "' version='1.0'>"
+
+// fakeServer is an XMPP server that negotiates TLS and SASL with one client and binds its
+// resource
+type fakeServer struct {
+	directTLS  bool
+	startTLS   bool     // offer STARTTLS
+	mechanisms []string // offered SASL mechanisms
+	password   string
+	cert       tls.Certificate
+}
+
+// listen starts the server on a loopback port; the result of the negotiation is sent to done
+func (f *fakeServer) listen(t *testing.T) (addr string, done chan error) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	done = make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		t.Cleanup(func() { conn.Close() })
+		done <- f.serve(conn)
+	}()
+
+	return listener.Addr().String(), done
+}
+
+func (f *fakeServer) serve(conn net.Conn) error {
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{f.cert},
+		ClientAuth:   tls.RequestClientCert,
+	}
+	if f.directTLS {
+		conn = tls.Server(conn, tlsConfig)
+	}
+
+	s := newXMLStream(conn)
+	if err := s.awaitHeader(); err != nil {
+		return err
+	}
+
+	if !f.directTLS {
+		features := ""
+		if f.startTLS {
+			features = "<starttls xmlns='" + nsTLS + "'><required/></starttls>"
+		}
+		f.writeFeatures(conn, features)
+		if _, err := s.nextNonza(); err != nil {
+			return err
+		}
+		fmt.Fprintf(conn, "<proceed xmlns='%s'/>", nsTLS)
+
+		conn = tls.Server(conn, tlsConfig)
+		s = newXMLStream(conn)
+		if err := s.awaitHeader(); err != nil {
+			return err
+		}
+	}
+
+	mechanisms := ""
+	for _, mechanism := range f.mechanisms {
+		mechanisms += "<mechanism>" + mechanism + "</mechanism>"
+	}
+	f.writeFeatures(conn, "<mechanisms xmlns='"+stanza.NSSASL+"'>"+mechanisms+"</mechanisms>")
+
+	if err := f.authenticate(conn, s); err != nil {
+		fmt.Fprintf(conn, "<failure xmlns='%s'><not-authorized/><text>%s</text></failure>", stanza.NSSASL, err)
+		return err
+	}
+
+	// The stream restart of the client, followed by resource binding
+	if err := s.awaitHeader(); err != nil {
+		return err
+	}
+	f.writeFeatures(conn, "<bind xmlns='"+stanza.NSBind+"'/>")
+
+	el, err := s.next()
+	if err != nil {
+		return err
+	}
+	var iq struct {
+		ID string `xml:"id,attr"`
+	}
+	if err := s.decoder.DecodeElement(&iq, &el); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(conn, "<iq type='result' id='%s'><bind xmlns='%s'><jid>bot@example.org/bot</jid></bind></iq>", iq.ID, stanza.NSBind); err != nil {
+		return err
+	}
+
+	// Answer the stream close of the client, which the library waits for on disconnect
+	go func() {
+		for {
+			if _, err := s.next(); err != nil {
+				fmt.Fprint(conn, "</stream:stream>")
+				return
+			}
+			s.decoder.Skip()
+		}
+	}()
+	return nil
+}
+
+func (f *fakeServer) writeFeatures(conn net.Conn, features string) {
+	fmt.Fprintf(conn, serverStreamHeader+"<stream:features>%s</stream:features>", "example.org", "test", features)
+}
+
+// authenticate runs the server side of the SASL exchange
+func (f *fakeServer) authenticate(conn net.Conn, s *xmlStream) error {
+	el, err := s.next()
+	if err != nil {
+		return err
+	}
+	var auth struct {
+		Mechanism string `xml:"mechanism,attr"`
+		Data      string `xml:",chardata"`
+	}
+	if err := s.decoder.DecodeElement(&auth, &el); err != nil {
+		return err
+	}
+
+	switch auth.Mechanism {
+	case config.SASLPlain:
+		data, _ := base64.StdEncoding.DecodeString(auth.Data)
+		if string(data) != "\x00bot\x00"+f.password {
+			return fmt.Errorf("wrong password")
+		}
+	case config.SASLExternal:
+		if auth.Data != "=" || len(conn.(*tls.Conn).ConnectionState().PeerCertificates) == 0 {
+			return fmt.Errorf("no client certificate")
+		}
+	case config.SASLScramSHA1, config.SASLScramSHA256:
+		data, _ := base64.StdEncoding.DecodeString(auth.Data)
+		nonce := scramAttributes(string(data))["r"]
+
+		// A client with the same nonce computes the proof the server expects
+		expected, err := newScramClient(auth.Mechanism, "bot", f.password)
+		if err != nil {
+			return err
+		}
+		expected.nonce = nonce
+		if expected.first() != string(data) {
+			return fmt.Errorf("unexpected client-first-message %q", data)
+		}
+		serverFirst := "r=" + nonce + "server,s=QSXCR+Q6sek8bf92,i=4096"
+		want, err := expected.final(serverFirst)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(conn, "<challenge xmlns='%s'>%s</challenge>", stanza.NSSASL, base64.StdEncoding.EncodeToString([]byte(serverFirst)))
+		reply, err := s.nextNonza()
+		if err != nil {
+			return err
+		}
+		got, _ := base64.StdEncoding.DecodeString(reply.Data)
+		if string(got) != want {
+			return fmt.Errorf("wrong password")
+		}
+		fmt.Fprintf(conn, "<success xmlns='%s'>%s</success>", stanza.NSSASL,
+			base64.StdEncoding.EncodeToString([]byte("v="+base64.StdEncoding.EncodeToString(expected.serverSignature))))
+		return nil
+	default:
+		return fmt.Errorf("unexpected mechanism %s", auth.Mechanism)
+	}
+
+	_, err = fmt.Fprintf(conn, "<success xmlns='%s'/>", stanza.NSSASL)
+	return err
+}
+
+func TestClient_Dial_NegotiatedStream(t *testing.T) {
+	serverCert, serverKey, _ := writeSelfSignedCert(t, "example.org")
+	clientCert, clientKey, _ := writeSelfSignedCert(t, "bot.example.org")
+	cert, err := tls.LoadX509KeyPair(serverCert, serverKey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		directTLS bool
+		mechanism string
+	}{
+		{"STARTTLS with SCRAM-SHA-256", false, config.SASLScramSHA256},
+		{"STARTTLS with SCRAM-SHA-1", false, config.SASLScramSHA1},
+		{"Direct TLS with PLAIN", true, config.SASLPlain},
+		{"Direct TLS with EXTERNAL", true, config.SASLExternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &fakeServer{
+				directTLS:  tt.directTLS,
+				startTLS:   true,
+				mechanisms: []string{config.SASLPlain, config.SASLScramSHA1, config.SASLScramSHA256, config.SASLExternal},
+				password:   "secret",
+				cert:       cert,
+			}
+			addr, done := server.listen(t)
+
+			cfg := &config.Config{XMPP: config.XMPPConfig{
+				JID:      "bot@example.org",
+				Password: "secret",
+				Server:   addr,
+				TLS:      config.XMPPTLSConfig{Mode: config.TLSModeStartTLS, CAFile: serverCert},
+			}}
+			if tt.directTLS {
+				cfg.XMPP.TLS.Mode = config.TLSModeDirect
+			}
+			if tt.mechanism == config.SASLExternal {
+				cfg.XMPP.TLS.CertFile, cfg.XMPP.TLS.KeyFile = clientCert, clientKey
+			}
+			cfg.XMPP.SASL.Mechanism = tt.mechanism
+
+			// The stream log monitor outlives the test, so it must not log to t
+			client := NewClient(cfg, zap.NewNop())
+			require.NoError(t, client.dial(context.Background()))
+			t.Cleanup(func() { client.client.Disconnect() })
+
+			require.NoError(t, <-done)
+		})
+	}
+}
+
+func TestNegotiateStream_Failures(t *testing.T) {
+	serverCert, serverKey, _ := writeSelfSignedCert(t, "example.org")
+	cert, err := tls.LoadX509KeyPair(serverCert, serverKey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		server    fakeServer
+		mechanism string
+		password  string
+		errMsg    string
+	}{
+		{
+			name:      "no STARTTLS",
+			server:    fakeServer{mechanisms: []string{config.SASLPlain}},
+			mechanism: config.SASLPlain,
+			errMsg:    "server does not offer STARTTLS",
+		},
+		{
+			name:      "mechanism not offered",
+			server:    fakeServer{startTLS: true, mechanisms: []string{config.SASLPlain}},
+			mechanism: config.SASLScramSHA256,
+			errMsg:    "server does not offer SASL mechanism SCRAM-SHA-256 (offered: PLAIN)",
+		},
+		{
+			name:      "wrong password",
+			server:    fakeServer{startTLS: true, mechanisms: []string{config.SASLScramSHA256}, password: "secret"},
+			mechanism: config.SASLScramSHA256,
+			password:  "wrong",
+			errMsg:    "authentication with SCRAM-SHA-256 failed: not-authorized (wrong password)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.server.cert = cert
+			addr, _ := tt.server.listen(t)
+
+			tlsConfig, err := buildTLSConfig(config.XMPPTLSConfig{CAFile: serverCert})
+			require.NoError(t, err)
+
+			conn, err := net.Dial("tcp", addr)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			_, err = negotiateStream(conn, "example.org", false, tlsConfig, config.XMPPConfig{
+				JID:      "bot@example.org",
+				Password: tt.password,
+				SASL:     config.SASLConfig{Mechanism: tt.mechanism},
+			})
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
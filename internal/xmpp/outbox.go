@@ -0,0 +1,316 @@
+package xmpp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/fileutil"
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+	"gosrc.io/xmpp/stanza"
+)
+
+const outboxFlushInterval = time.Second
+
+// ErrOutboxFull is returned when a message cannot be queued because the outbox is full
+var ErrOutboxFull = &XMPPError{
+	Code:    "OUTBOX_FULL",
+	Message: "Outbound queue is full",
+}
+
+// errUnsendable marks a queued message that fails however often it is retried, like one with an
+// invalid address. Other send failures are caused by the connection and retried.
+var errUnsendable = errors.New("queued message cannot be sent")
+
+// outboundMessage is a message waiting for the connection of its account
+type outboundMessage struct {
+	ID        string    `json:"id"`
+	Account   string    `json:"account"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	Room      bool      `json:"room,omitempty"` // sent with SendMUCMessage
+	Body      string    `json:"body"`
+	Type      string    `json:"type"`
+	Subject   string    `json:"subject,omitempty"`
+	Mentions  []string  `json:"mentions,omitempty"`
+	QueuedAt  time.Time `json:"queued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// outbox holds outbound messages in send order, optionally persisted to a file so they
+// survive a restart
+type outbox struct {
+	mu       sync.Mutex
+	messages []outboundMessage
+	path     string
+	ttl      time.Duration
+	maxSize  int
+	seq      uint64
+	logger   *zap.Logger
+}
+
+// newOutbox creates the outbox and loads the messages persisted by a previous run
+func newOutbox(cfg config.OutboxConfig, logger *zap.Logger) (*outbox, error) {
+	o := &outbox{
+		path:    cfg.Path,
+		ttl:     cfg.TTL,
+		maxSize: cfg.MaxSize,
+		logger:  logger,
+	}
+	if o.path == "" {
+		return o, nil
+	}
+
+	data, err := os.ReadFile(o.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &o.messages); err != nil {
+			return nil, fmt.Errorf("failed to parse outbox %s: %w", o.path, err)
+		}
+	}
+	return o, nil
+}
+
+// add queues a message and returns it with its ID and expiry
+func (o *outbox) add(msg outboundMessage) (outboundMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	o.dropExpired(now)
+	if len(o.messages) >= o.maxSize {
+		return outboundMessage{}, ErrOutboxFull
+	}
+
+	msg.ID = fmt.Sprintf("outbox-%d-%d", now.UnixNano(), atomic.AddUint64(&o.seq, 1))
+	msg.QueuedAt = now
+	msg.ExpiresAt = now.Add(o.ttl)
+	o.messages = append(o.messages, msg)
+
+	if err := o.save(); err != nil {
+		o.messages = o.messages[:len(o.messages)-1]
+		return outboundMessage{}, err
+	}
+	return msg, nil
+}
+
+// next returns the oldest unexpired message of an account
+func (o *outbox) next(account string) (outboundMessage, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.dropExpired(time.Now())
+	for _, msg := range o.messages {
+		if msg.Account == account {
+			return msg, true
+		}
+	}
+	return outboundMessage{}, false
+}
+
+// remove deletes a sent or undeliverable message
+func (o *outbox) remove(id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i, msg := range o.messages {
+		if msg.ID == id {
+			o.messages = append(o.messages[:i], o.messages[i+1:]...)
+			return o.save()
+		}
+	}
+	return nil
+}
+
+// pending returns the number of queued messages per account
+func (o *outbox) pending() map[string]int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, msg := range o.messages {
+		counts[msg.Account]++
+	}
+	return counts
+}
+
+// dropExpired removes messages past their expiry. The caller must hold the lock.
+func (o *outbox) dropExpired(now time.Time) {
+	kept := o.messages[:0]
+	for _, msg := range o.messages {
+		if now.Before(msg.ExpiresAt) {
+			kept = append(kept, msg)
+			continue
+		}
+		o.logger.Warn("Queued message expired before the account reconnected, dropped",
+			zap.String("account", msg.Account),
+			zap.String("id", msg.ID),
+			zap.String("to", msg.To),
+			zap.Time("queued_at", msg.QueuedAt),
+		)
+	}
+	if len(kept) != len(o.messages) {
+		o.messages = kept
+		if err := o.save(); err != nil {
+			o.logger.Error("Failed to update outbox", zap.Error(err))
+		}
+	}
+}
+
+// save writes the queue to disk. The caller must hold the lock.
+func (o *outbox) save() error {
+	if o.path == "" {
+		return nil
+	}
+	if err := fileutil.WriteJSONAtomic(o.path, o.messages); err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	return nil
+}
+
+// queueMessage puts a message into the outbox while its account is disconnected. Messages are
+// also queued while older messages of the account are still waiting, to keep the send order.
+func (m *Manager) queueMessage(client *Client, msg outboundMessage) (models.SendResult, bool, error) {
+	if m.outbox == nil {
+		return models.SendResult{}, false, nil
+	}
+	if msg.Account == "" {
+		msg.Account = config.DefaultAccount
+	}
+
+	if m.outbox.pending()[msg.Account] == 0 && client.IsConnected() {
+		return models.SendResult{}, false, nil
+	}
+
+	// Reject invalid senders now rather than failing at every flush
+	if _, err := client.senderJID(msg.From); err != nil {
+		return models.SendResult{}, false, err
+	}
+
+	msg, err := m.outbox.add(msg)
+	if err != nil {
+		return models.SendResult{}, false, err
+	}
+
+	m.logger.Info("XMPP account disconnected, message queued",
+		zap.String("account", msg.Account),
+		zap.String("id", msg.ID),
+		zap.String("to", msg.To),
+		zap.Time("expires_at", msg.ExpiresAt),
+	)
+
+	return models.SendResult{Queued: true, MessageID: msg.ID, ExpiresAt: msg.ExpiresAt}, true, nil
+}
+
+// flushOutbox sends queued messages in order once their account is connected again
+func (m *Manager) flushOutbox() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(outboxFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+			for account := range m.outbox.pending() {
+				m.flushAccount(account)
+			}
+		}
+	}
+}
+
+// flushAccount sends the queued messages of an account until the queue is empty or a send fails
+// because of the connection. Messages that cannot be sent at all are dropped, so they do not hold
+// up the queue.
+func (m *Manager) flushAccount(account string) {
+	m.mu.RLock()
+	client, exists := m.clients[account]
+	m.mu.RUnlock()
+
+	for {
+		msg, ok := m.outbox.next(account)
+		if !ok {
+			return
+		}
+
+		if !exists {
+			// The account was removed from the configuration since the message was queued
+			m.logger.Warn("Dropping queued message of unknown account",
+				zap.String("account", account),
+				zap.String("id", msg.ID),
+			)
+			m.removeQueued(msg.ID)
+			continue
+		}
+
+		if !client.IsConnected() {
+			return
+		}
+
+		// Queued messages are not rejected by the rate limit, the flush just slows down
+		//goland:noinspection GoUnhandledErrorResult
+		m.pace(msg.To, msg.Room || msg.Type == "groupchat", false)
+
+		if err := m.sendQueued(client, msg); errors.Is(err, errUnsendable) {
+			m.logger.Error("Dropping queued message that cannot be sent",
+				zap.String("account", account),
+				zap.String("id", msg.ID),
+				zap.String("to", msg.To),
+				zap.Error(err),
+			)
+			m.removeQueued(msg.ID)
+			continue
+		} else if err != nil {
+			m.logger.Warn("Failed to send queued message, will retry",
+				zap.String("account", account),
+				zap.String("id", msg.ID),
+				zap.Error(err),
+			)
+			return
+		}
+
+		m.logger.Info("Queued message sent",
+			zap.String("account", account),
+			zap.String("id", msg.ID),
+			zap.Duration("queued_for", time.Since(msg.QueuedAt)),
+		)
+		m.removeQueued(msg.ID)
+	}
+}
+
+// sendQueued sends a queued message the way it was requested. Messages that cannot be sent
+// however often they are retried fail with errUnsendable.
+func (m *Manager) sendQueued(client *Client, msg outboundMessage) error {
+	if _, err := stanza.NewJid(msg.To); err != nil {
+		return fmt.Errorf("%w: invalid recipient: %v", errUnsendable, err)
+	}
+	// The configuration may have changed since the message was queued
+	if _, err := client.senderJID(msg.From); err != nil {
+		return fmt.Errorf("%w: %v", errUnsendable, err)
+	}
+
+	if msg.Room {
+		return client.SendMUCMessage(msg.To, msg.Body, msg.Subject, msg.Mentions)
+	}
+	return client.SendMessage(msg.From, msg.To, msg.Body, msg.Type)
+}
+
+func (m *Manager) removeQueued(id string) {
+	if err := m.outbox.remove(id); err != nil {
+		m.logger.Error("Failed to update outbox", zap.String("id", id), zap.Error(err))
+	}
+}
@@ -0,0 +1,202 @@
+package xmpp
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+	"gosrc.io/xmpp/stanza"
+)
+
+func TestOutbox_Persistence(t *testing.T) {
+	cfg := config.OutboxConfig{
+		Path:    filepath.Join(t.TempDir(), "outbox.json"),
+		TTL:     time.Hour,
+		MaxSize: 10,
+	}
+
+	box, err := newOutbox(cfg, zap.NewNop())
+	require.NoError(t, err)
+	first, err := box.add(outboundMessage{Account: "default", To: "alice@example.org", Body: "one"})
+	require.NoError(t, err)
+	_, err = box.add(outboundMessage{Account: "default", To: "alice@example.org", Body: "two"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.ID)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), first.ExpiresAt, time.Minute)
+
+	// A restarted bot finds the queue in the same order
+	box, err = newOutbox(cfg, zap.NewNop())
+	require.NoError(t, err)
+	msg, ok := box.next("default")
+	require.True(t, ok)
+	assert.Equal(t, first.ID, msg.ID)
+	assert.Equal(t, "one", msg.Body)
+
+	require.NoError(t, box.remove(msg.ID))
+	box, err = newOutbox(cfg, zap.NewNop())
+	require.NoError(t, err)
+	msg, ok = box.next("default")
+	require.True(t, ok)
+	assert.Equal(t, "two", msg.Body)
+	assert.Equal(t, map[string]int{"default": 1}, box.pending())
+}
+
+func TestOutbox_Persistence_CreatesDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "outbox.json")
+	box, err := newOutbox(config.OutboxConfig{Path: path, TTL: time.Hour, MaxSize: 10}, zap.NewNop())
+	require.NoError(t, err)
+
+	_, err = box.add(outboundMessage{Account: "default", To: "alice@example.org", Body: "one"})
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+}
+
+func TestOutbox_Limits(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	box, err := newOutbox(config.OutboxConfig{TTL: time.Hour, MaxSize: 1}, zap.New(core))
+	require.NoError(t, err)
+
+	_, err = box.add(outboundMessage{Account: "default", To: "alice@example.org", Body: "one"})
+	require.NoError(t, err)
+	_, err = box.add(outboundMessage{Account: "default", To: "alice@example.org", Body: "two"})
+	assert.ErrorIs(t, err, ErrOutboxFull)
+
+	// Expired messages are dropped and free their slot
+	box.messages[0].ExpiresAt = time.Now().Add(-time.Second)
+	_, ok := box.next("default")
+	assert.False(t, ok)
+	require.Equal(t, 1, logs.FilterMessageSnippet("expired").Len())
+	assert.Equal(t, "alice@example.org", logs.All()[0].ContextMap()["to"])
+	_, err = box.add(outboundMessage{Account: "default", To: "alice@example.org", Body: "three"})
+	assert.NoError(t, err)
+}
+
+func TestManager_OutboxFlush(t *testing.T) {
+	cfg := &config.Config{Outbox: config.OutboxConfig{Enabled: true, TTL: time.Hour, MaxSize: 10}}
+	manager := NewManager(cfg, zaptest.NewLogger(t))
+	box, err := newOutbox(cfg.Outbox, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	manager.outbox = box
+
+	client, stream := newStreamClient(t, cfg)
+	client.setConnected(false)
+	manager.clients[config.DefaultAccount] = client
+
+	for _, body := range []string{"one", "two"} {
+		result, err := manager.SendMessage("", "", "alice@example.org", body, "chat")
+		require.NoError(t, err)
+		assert.True(t, result.Queued)
+		assert.NotEmpty(t, result.MessageID)
+	}
+
+	// Once reconnected, new messages still wait behind the queued ones
+	client.setConnected(true)
+	result, err := manager.SendMessage("", "", "alice@example.org", "three", "chat")
+	require.NoError(t, err)
+	assert.True(t, result.Queued)
+	assert.Empty(t, stream.sent)
+
+	manager.flushAccount(config.DefaultAccount)
+
+	var bodies []string
+	for _, packet := range stream.sent {
+		bodies = append(bodies, packet.(stanza.Message).Body)
+	}
+	assert.Equal(t, []string{"one", "two", "three"}, bodies)
+	assert.Empty(t, box.pending())
+
+	// With an empty queue messages are sent directly
+	result, err = manager.SendMessage("", "", "alice@example.org", "four", "chat")
+	require.NoError(t, err)
+	assert.False(t, result.Queued)
+	assert.Len(t, stream.sent, 4)
+}
+
+func TestManager_OutboxFlush_MUC(t *testing.T) {
+	cfg := &config.Config{Outbox: config.OutboxConfig{Enabled: true, TTL: time.Hour, MaxSize: 10}}
+	manager := NewManager(cfg, zaptest.NewLogger(t))
+	box, err := newOutbox(cfg.Outbox, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	manager.outbox = box
+
+	client, stream := newStreamClient(t, cfg)
+	client.setConnected(false)
+	manager.clients[config.DefaultAccount] = client
+
+	result, err := manager.SendMUCMessage("", "room@conference.example.org", "hello", "Status", []string{"alice"})
+	require.NoError(t, err)
+	assert.True(t, result.Queued)
+	assert.Empty(t, stream.sent)
+
+	client.setConnected(true)
+	manager.flushAccount(config.DefaultAccount)
+
+	require.Len(t, stream.sent, 1)
+	msg := stream.sent[0].(stanza.Message)
+	assert.Equal(t, "room@conference.example.org", msg.To)
+	assert.Equal(t, stanza.StanzaType("groupchat"), msg.Type)
+	assert.Equal(t, "Status", msg.Subject)
+	assert.Equal(t, "alice: hello", msg.Body)
+	assert.Empty(t, box.pending())
+}
+
+func TestManager_OutboxFlush_PoisonedHead(t *testing.T) {
+	cfg := &config.Config{Outbox: config.OutboxConfig{Enabled: true, TTL: time.Hour, MaxSize: 10}}
+	manager := NewManager(cfg, zaptest.NewLogger(t))
+	box, err := newOutbox(cfg.Outbox, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	manager.outbox = box
+
+	client, stream := newStreamClient(t, cfg)
+	manager.clients[config.DefaultAccount] = client
+
+	// Queued by an earlier run: an invalid recipient, and a sender only valid in component mode
+	for _, msg := range []outboundMessage{
+		{Account: config.DefaultAccount, To: "@example.org", Body: "bad recipient", Type: "chat"},
+		{Account: config.DefaultAccount, From: "alerts", To: "alice@example.org", Body: "bad sender", Type: "chat"},
+		{Account: config.DefaultAccount, To: "alice@example.org", Body: "good", Type: "chat"},
+	} {
+		_, err := box.add(msg)
+		require.NoError(t, err)
+	}
+
+	// Messages that can never be sent are dropped instead of blocking the ones behind them
+	manager.flushAccount(config.DefaultAccount)
+
+	require.Len(t, stream.sent, 1)
+	assert.Equal(t, "good", stream.sent[0].(stanza.Message).Body)
+	assert.Empty(t, box.pending())
+
+	// New messages are sent directly again
+	result, err := manager.SendMessage("", "", "alice@example.org", "next", "chat")
+	require.NoError(t, err)
+	assert.False(t, result.Queued)
+	assert.Len(t, stream.sent, 2)
+}
+
+func TestManager_OutboxFlush_Disconnected(t *testing.T) {
+	cfg := &config.Config{Outbox: config.OutboxConfig{Enabled: true, TTL: time.Hour, MaxSize: 10}}
+	manager := NewManager(cfg, zaptest.NewLogger(t))
+	box, err := newOutbox(cfg.Outbox, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	manager.outbox = box
+
+	client, stream := newStreamClient(t, cfg)
+	client.setConnected(false)
+	manager.clients[config.DefaultAccount] = client
+
+	_, err = box.add(outboundMessage{Account: config.DefaultAccount, To: "alice@example.org", Body: "one", Type: "chat"})
+	require.NoError(t, err)
+
+	// Connection failures keep the message for the next flush
+	manager.flushAccount(config.DefaultAccount)
+	assert.Empty(t, stream.sent)
+	assert.Equal(t, map[string]int{config.DefaultAccount: 1}, box.pending())
+}
@@ -0,0 +1,168 @@
+package xmpp
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const proxyDialTimeout = 15 * time.Second
+
+// dialProxy opens a TCP connection to address through an HTTP (CONNECT) or SOCKS5 proxy
+func dialProxy(proxyURL *url.URL, address string) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", proxyURL.Host, proxyDialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxyURL.Host, err)
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	conn.SetDeadline(time.Now().Add(proxyDialTimeout))
+	switch proxyURL.Scheme {
+	case "http":
+		err = httpConnect(conn, proxyURL, address)
+	case "socks5", "socks5h":
+		err = socks5Connect(conn, proxyURL, address)
+	default:
+		err = fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %w", proxyURL.Host, err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	conn.SetDeadline(time.Time{})
+
+	return conn, nil
+}
+
+// httpConnect asks an HTTP proxy to open a tunnel to address
+func httpConnect(conn net.Conn, proxyURL *url.URL, address string) error {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+
+	// The reader is discarded after the response. Nothing is lost: the XMPP server sends
+	// nothing before the client opens the stream.
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("CONNECT %s: %s", address, resp.Status)
+	}
+	return nil
+}
+
+// socks5Connect performs a SOCKS5 handshake (RFC 1928) with optional username/password
+// authentication (RFC 1929). The host name is resolved by the proxy.
+func socks5Connect(conn net.Conn, proxyURL *url.URL, address string) error {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("invalid port in %s", address)
+	}
+	if len(host) > 255 {
+		return fmt.Errorf("host name too long: %s", host)
+	}
+
+	method := byte(0x00) // no authentication
+	if proxyURL.User != nil {
+		method = 0x02 // username/password
+	}
+	if _, err := conn.Write([]byte{0x05, 0x01, method}); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != 0x05 || reply[1] != method {
+		return errors.New("SOCKS5 proxy rejected the authentication method")
+	}
+
+	if method == 0x02 {
+		username := proxyURL.User.Username()
+		password, _ := proxyURL.User.Password()
+		auth := []byte{0x01, byte(len(username))}
+		auth = append(auth, username...)
+		auth = append(auth, byte(len(password)))
+		auth = append(auth, password...)
+		if _, err := conn.Write(auth); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return err
+		}
+		if reply[1] != 0x00 {
+			return errors.New("SOCKS5 authentication failed")
+		}
+	}
+
+	request := []byte{0x05, 0x01, 0x00, 0x03, byte(len(host))}
+	request = append(request, host...)
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[1] != 0x00 {
+		return fmt.Errorf("SOCKS5 connect to %s failed with code %d", address, header[1])
+	}
+
+	// Skip the bound address
+	var skip int
+	switch header[3] {
+	case 0x01:
+		skip = net.IPv4len + 2
+	case 0x04:
+		skip = net.IPv6len + 2
+	case 0x03:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return err
+		}
+		skip = int(length[0]) + 2
+	default:
+		return fmt.Errorf("invalid SOCKS5 address type %d", header[3])
+	}
+	_, err = io.ReadFull(conn, make([]byte, skip))
+	return err
+}
+
+// proxyDialer returns the dialer of TCP connections through the proxy, or nil to dial directly
+func proxyDialer(proxyURL *url.URL) func(network, address string) (net.Conn, error) {
+	if proxyURL == nil {
+		return nil
+	}
+	return func(_, address string) (net.Conn, error) {
+		return dialProxy(proxyURL, address)
+	}
+}
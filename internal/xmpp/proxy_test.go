@@ -0,0 +1,176 @@
+package xmpp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoServer answers every connection by echoing its input
+func echoServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				//goland:noinspection GoUnhandledErrorResult
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// fakeProxy accepts proxy connections, runs the handshake and relays to the requested target
+func fakeProxy(t *testing.T, handshake func(conn net.Conn) (string, error)) (addr string, targets chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	targets = make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				target, err := handshake(conn)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				targets <- target
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				go pipe(upstream, conn)
+				pipe(conn, upstream)
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), targets
+}
+
+// httpConnectHandshake is the proxy side of an HTTP CONNECT request
+func httpConnectHandshake(conn net.Conn) (string, error) {
+	req, err := http.ReadRequest(bufio.NewReader(conn))
+	if err != nil {
+		return "", err
+	}
+	if req.Header.Get("Proxy-Authorization") != "Basic dXNlcjpzZWNyZXQ=" {
+		_, _ = conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\n\r\n"))
+		return "", io.EOF
+	}
+	_, err = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+	return req.Host, err
+}
+
+// socks5Handshake is the proxy side of a SOCKS5 CONNECT without authentication
+func socks5Handshake(conn net.Conn) (string, error) {
+	greeting := make([]byte, 3)
+	if _, err := io.ReadFull(conn, greeting); err != nil {
+		return "", err
+	}
+	if _, err := conn.Write([]byte{0x05, 0x00}); err != nil {
+		return "", err
+	}
+
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	host := make([]byte, header[4]+2)
+	if _, err := io.ReadFull(conn, host); err != nil {
+		return "", err
+	}
+	port := binary.BigEndian.Uint16(host[len(host)-2:])
+
+	_, err := conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+	return net.JoinHostPort(string(host[:len(host)-2]), strconv.Itoa(int(port))), err
+}
+
+// pipe copies from src to dst and closes both when src is exhausted
+func pipe(dst, src net.Conn) {
+	//goland:noinspection GoUnhandledErrorResult
+	io.Copy(dst, src)
+	dst.Close()
+	src.Close()
+}
+
+// assertEcho checks that data sent on conn is echoed back
+func assertEcho(t *testing.T, conn net.Conn) {
+	t.Helper()
+
+	_, err := conn.Write([]byte("<stream:stream>"))
+	require.NoError(t, err)
+	buf := make([]byte, len("<stream:stream>"))
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "<stream:stream>", string(buf))
+}
+
+func TestDialProxy_HTTPConnect(t *testing.T) {
+	target := echoServer(t)
+	proxyAddr, targets := fakeProxy(t, httpConnectHandshake)
+	proxyURL := &url.URL{Scheme: "http", Host: proxyAddr, User: url.UserPassword("user", "secret")}
+
+	conn, err := dialProxy(proxyURL, target)
+	require.NoError(t, err)
+	//goland:noinspection GoUnhandledErrorResult
+	defer conn.Close()
+	assertEcho(t, conn)
+	assert.Equal(t, target, <-targets)
+
+	// The proxy refuses wrong credentials
+	proxyURL.User = url.UserPassword("user", "wrong")
+	_, err = dialProxy(proxyURL, target)
+	assert.ErrorContains(t, err, "407")
+}
+
+func TestDialProxy_SOCKS5(t *testing.T) {
+	target := echoServer(t)
+	proxyAddr, targets := fakeProxy(t, socks5Handshake)
+	proxyURL := &url.URL{Scheme: "socks5", Host: proxyAddr}
+
+	conn, err := proxyDialer(proxyURL)("tcp", target)
+	require.NoError(t, err)
+	//goland:noinspection GoUnhandledErrorResult
+	defer conn.Close()
+	assertEcho(t, conn)
+	assert.Equal(t, target, <-targets)
+}
+
+func TestDialProxy_ProxyUnreachable(t *testing.T) {
+	proxyURL := &url.URL{Scheme: "socks5", Host: net.JoinHostPort("127.0.0.1", strconv.Itoa(unusedPort(t)))}
+
+	_, err := dialProxy(proxyURL, "example.org:5222")
+	assert.ErrorContains(t, err, "failed to connect to proxy")
+}
+
+func TestProxyDialer_NoProxy(t *testing.T) {
+	assert.Nil(t, proxyDialer(nil))
+}
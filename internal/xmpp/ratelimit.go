@@ -0,0 +1,183 @@
+package xmpp
+
+import (
+	"sync"
+	"time"
+
+	"jabber-bot/internal/config"
+)
+
+// maxIdleBuckets is the number of per-recipient and per-room buckets above which idle ones are
+// dropped. An idle bucket is full, so dropping it does not change the limits.
+const maxIdleBuckets = 1000
+
+// ErrRateLimited is returned when a send would have to wait but the rate limit queue is full
+var ErrRateLimited = &XMPPError{
+	Code:    "RATE_LIMITED",
+	Message: "Outbound rate limit exceeded",
+}
+
+// tokenBucket is a token bucket kept as the theoretical arrival time of the next message
+// (GCRA): a message conforms once tat minus the burst tolerance has passed.
+type tokenBucket struct {
+	interval  time.Duration
+	tolerance time.Duration
+	tat       time.Time
+}
+
+func newTokenBucket(limit config.RateLimit) *tokenBucket {
+	if limit.Rate <= 0 {
+		return nil
+	}
+	interval := time.Duration(float64(time.Second) / limit.Rate)
+	return &tokenBucket{
+		interval:  interval,
+		tolerance: time.Duration(limit.Burst-1) * interval,
+	}
+}
+
+// allowedAt returns the earliest time the bucket lets a message through
+func (b *tokenBucket) allowedAt(now time.Time) time.Time {
+	if at := b.tat.Add(-b.tolerance); at.After(now) {
+		return at
+	}
+	return now
+}
+
+// take consumes a token for a message sent at the given time
+func (b *tokenBucket) take(at time.Time) {
+	if b.tat.Before(at) {
+		b.tat = at
+	}
+	b.tat = b.tat.Add(b.interval)
+}
+
+// rateLimiter paces outbound messages with a global bucket and a bucket per recipient or room
+type rateLimiter struct {
+	mu        sync.Mutex
+	config    config.RateLimitConfig
+	global    *tokenBucket
+	buckets   map[string]*tokenBucket
+	waiting   int
+	now       func() time.Time
+	stop      <-chan struct{}
+	sleepFunc func(d time.Duration, stop <-chan struct{})
+}
+
+func newRateLimiter(cfg config.RateLimitConfig, stop <-chan struct{}) *rateLimiter {
+	return &rateLimiter{
+		config:    cfg,
+		global:    newTokenBucket(cfg.Global),
+		buckets:   make(map[string]*tokenBucket),
+		now:       time.Now,
+		stop:      stop,
+		sleepFunc: sleep,
+	}
+}
+
+// reserve books the next free slot for a message to a recipient (room false) or to a room and
+// returns how long the message has to wait for it. Waiting messages count against the queue
+// bound unless bounded is false; release must be called once a waiting message was sent.
+func (l *rateLimiter) reserve(target string, room, bounded bool) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	bucket := l.bucket(target, room, now)
+
+	at := now
+	for _, b := range []*tokenBucket{l.global, bucket} {
+		if b != nil {
+			if allowed := b.allowedAt(now); allowed.After(at) {
+				at = allowed
+			}
+		}
+	}
+
+	delay := at.Sub(now)
+	if delay > 0 {
+		if bounded && l.waiting >= l.config.MaxQueue {
+			return 0, ErrRateLimited
+		}
+		l.waiting++
+	}
+
+	for _, b := range []*tokenBucket{l.global, bucket} {
+		if b != nil {
+			b.take(at)
+		}
+	}
+	return delay, nil
+}
+
+func (l *rateLimiter) release() {
+	l.mu.Lock()
+	l.waiting--
+	l.mu.Unlock()
+}
+
+// bucket returns the bucket of a recipient or room, nil if it is not limited. Stanzas without a
+// single recipient, like presence broadcasts, have an empty target and only the global limit.
+// The caller must hold the lock.
+func (l *rateLimiter) bucket(target string, room bool, now time.Time) *tokenBucket {
+	if target == "" {
+		return nil
+	}
+
+	limit := l.config.PerRecipient
+	key := "jid:" + bareJID(target)
+	if room {
+		limit = l.config.PerRoom
+		key = "room:" + bareJID(target)
+	}
+	if limit.Rate <= 0 {
+		return nil
+	}
+
+	if bucket, ok := l.buckets[key]; ok {
+		return bucket
+	}
+
+	if len(l.buckets) >= maxIdleBuckets {
+		for k, b := range l.buckets {
+			if !b.tat.After(now) {
+				delete(l.buckets, k)
+			}
+		}
+	}
+
+	bucket := newTokenBucket(limit)
+	l.buckets[key] = bucket
+	return bucket
+}
+
+// wait blocks until the message may be sent and returns how long it waited
+func (l *rateLimiter) wait(target string, room, bounded bool) (time.Duration, error) {
+	delay, err := l.reserve(target, room, bounded)
+	if err != nil || delay == 0 {
+		return 0, err
+	}
+	defer l.release()
+
+	l.sleepFunc(delay, l.stop)
+	return delay, nil
+}
+
+// sleep waits for the given duration or until stop is closed
+func sleep(d time.Duration, stop <-chan struct{}) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-stop:
+	}
+}
+
+// pace waits for the rate limit of the target, if rate limiting is enabled
+func (m *Manager) pace(target string, room, bounded bool) (time.Duration, error) {
+	if m.limiter == nil {
+		return 0, nil
+	}
+	return m.limiter.wait(target, room, bounded)
+}
@@ -0,0 +1,193 @@
+package xmpp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// newTestLimiter returns a limiter on a frozen clock that does not sleep
+func newTestLimiter(cfg config.RateLimitConfig) (*rateLimiter, *time.Time) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(cfg, make(chan struct{}))
+	limiter.now = func() time.Time { return now }
+	limiter.sleepFunc = func(time.Duration, <-chan struct{}) {}
+	return limiter, &now
+}
+
+func TestRateLimiter_Global(t *testing.T) {
+	limiter, now := newTestLimiter(config.RateLimitConfig{
+		Global:   config.RateLimit{Rate: 10, Burst: 2},
+		MaxQueue: 10,
+	})
+
+	var delays []time.Duration
+	for _, to := range []string{"alice@example.org", "bob@example.org", "carol@example.org", "dave@example.org"} {
+		delay, err := limiter.wait(to, false, true)
+		require.NoError(t, err)
+		delays = append(delays, delay)
+	}
+	assert.Equal(t, []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond}, delays)
+
+	// The bucket refills while idle
+	*now = now.Add(time.Second)
+	delay, err := limiter.wait("alice@example.org", false, true)
+	require.NoError(t, err)
+	assert.Zero(t, delay)
+}
+
+func TestRateLimiter_PerRecipientAndRoom(t *testing.T) {
+	limiter, _ := newTestLimiter(config.RateLimitConfig{
+		PerRecipient: config.RateLimit{Rate: 1, Burst: 1},
+		PerRoom:      config.RateLimit{Rate: 0.5, Burst: 1},
+		MaxQueue:     10,
+	})
+
+	delay, _ := limiter.wait("alice@example.org/phone", false, true)
+	assert.Zero(t, delay)
+	// Resources share the bucket of the bare JID
+	delay, _ = limiter.wait("alice@example.org/laptop", false, true)
+	assert.Equal(t, time.Second, delay)
+	delay, _ = limiter.wait("bob@example.org", false, true)
+	assert.Zero(t, delay)
+
+	delay, _ = limiter.wait("ops@conference.example.org", true, true)
+	assert.Zero(t, delay)
+	delay, _ = limiter.wait("ops@conference.example.org", true, true)
+	assert.Equal(t, 2*time.Second, delay)
+}
+
+func TestRateLimiter_QueueFull(t *testing.T) {
+	limiter, _ := newTestLimiter(config.RateLimitConfig{
+		Global:   config.RateLimit{Rate: 1, Burst: 1},
+		MaxQueue: 1,
+	})
+
+	delay, err := limiter.reserve("alice@example.org", false, true)
+	require.NoError(t, err)
+	assert.Zero(t, delay)
+
+	// The second message waits, the third finds the queue full
+	delay, err = limiter.reserve("alice@example.org", false, true)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, delay)
+	_, err = limiter.reserve("alice@example.org", false, true)
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	// Unbounded reservations always wait
+	delay, err = limiter.reserve("alice@example.org", false, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, delay)
+
+	limiter.release()
+	limiter.release()
+	_, err = limiter.reserve("alice@example.org", false, true)
+	assert.NoError(t, err)
+}
+
+func TestManager_SendMessage_RateLimited(t *testing.T) {
+	cfg := &config.Config{RateLimit: config.RateLimitConfig{
+		Enabled:      true,
+		PerRecipient: config.RateLimit{Rate: 2, Burst: 1},
+		MaxQueue:     10,
+	}}
+	manager := NewManager(cfg, zaptest.NewLogger(t))
+	limiter, _ := newTestLimiter(cfg.RateLimit)
+	manager.limiter = limiter
+
+	client, stream := newStreamClient(t, cfg)
+	manager.clients[config.DefaultAccount] = client
+
+	result, err := manager.SendMessage("", "", "alice@example.org", "one", "chat")
+	require.NoError(t, err)
+	assert.Zero(t, result.Delay)
+
+	result, err = manager.SendMessage("", "", "alice@example.org", "two", "chat")
+	require.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, result.Delay)
+	assert.Len(t, stream.sent, 2)
+}
+
+func TestManager_SendMessage_PacesParts(t *testing.T) {
+	cfg := &config.Config{
+		XMPP: config.XMPPConfig{MaxBodySize: 64},
+		RateLimit: config.RateLimitConfig{
+			Enabled:      true,
+			PerRecipient: config.RateLimit{Rate: 2, Burst: 1},
+			MaxQueue:     10,
+		},
+	}
+	manager := NewManager(cfg, zaptest.NewLogger(t))
+	limiter, _ := newTestLimiter(cfg.RateLimit)
+	manager.limiter = limiter
+
+	client, stream := newStreamClient(t, cfg)
+	client.limiter = limiter
+	manager.clients[config.DefaultAccount] = client
+
+	body := strings.Repeat("a", 50) + "\n\n" + strings.Repeat("b", 50) + "\n\n" + strings.Repeat("c", 50)
+	result, err := manager.SendMessage("", "", "alice@example.org", body, "chat")
+	require.NoError(t, err)
+	assert.Zero(t, result.Delay)
+	assert.Len(t, stream.sent, 3)
+
+	// Every part took a slot, so the next message waits behind all three
+	result, err = manager.SendMessage("", "", "alice@example.org", "next", "chat")
+	require.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, result.Delay)
+}
+
+func TestManager_PacesEverySendPath(t *testing.T) {
+	received := models.Message{ID: "msg-1", From: "alice@example.org/phone", Type: "chat"}
+	tests := []struct {
+		name string
+		send func(m *Manager) error
+	}{
+		{"reaction", func(m *Manager) error { return m.SendReaction(received, []string{"👍"}) }},
+		{"presence", func(m *Manager) error { return m.SetPresence("", "away", "") }},
+		{"direct invite", func(m *Manager) error {
+			return m.InviteToRoom("", "ops@conference.example.org", []string{"bob@example.org"}, "", false)
+		}},
+		{"mediated invite", func(m *Manager) error {
+			return m.InviteToRoom("", "ops@conference.example.org", []string{"bob@example.org"}, "", true)
+		}},
+		{"room subject", func(m *Manager) error { return m.SetRoomSubject("", "ops@conference.example.org", "Status") }},
+		{"MUC private message", func(m *Manager) error {
+			return m.SendMUCPrivateMessage("", "ops@conference.example.org", "bob", "hi")
+		}},
+		{"chat state", func(m *Manager) error { return m.SendChatState("", "alice@example.org", ChatStateComposing) }},
+		{"file", func(m *Manager) error {
+			return m.SendFile("", "alice@example.org", "https://example.org/a.png", "a.png", "image/png")
+		}},
+		{"delivery receipt", func(m *Manager) error { return m.SendDeliveryReceipt("", "", "alice@example.org", "msg-1") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{RateLimit: config.RateLimitConfig{
+				Enabled: true,
+				Global:  config.RateLimit{Rate: 1, Burst: 1},
+			}}
+			manager := NewManager(cfg, zaptest.NewLogger(t))
+			limiter, _ := newTestLimiter(cfg.RateLimit)
+			manager.limiter = limiter
+
+			client, stream := newStreamClient(t, cfg)
+			manager.clients[config.DefaultAccount] = client
+
+			// The message takes the only slot, so the stanza would have to wait and the queue is full
+			_, err := manager.SendMessage("", "", "carol@example.org", "one", "chat")
+			require.NoError(t, err)
+
+			assert.ErrorIs(t, tt.send(manager), ErrRateLimited)
+			assert.Len(t, stream.sent, 1)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package xmpp
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// connectionStats holds the reconnection history of the client
+type connectionStats struct {
+	reconnects     int
+	lastError      string
+	lastErrorAt    time.Time
+	connectedSince time.Time
+}
+
+// ConnectionStats returns the reconnection history of the client
+func (c *Client) ConnectionStats() models.ConnectionStats {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	stats := models.ConnectionStats{
+		Reconnects: c.stats.reconnects,
+		LastError:  c.stats.lastError,
+	}
+	if !c.stats.lastErrorAt.IsZero() {
+		stats.LastErrorAt = c.stats.lastErrorAt.UTC().Format(time.RFC3339)
+	}
+	if !c.stats.connectedSince.IsZero() && atomic.LoadInt32(&c.connected) == 1 {
+		stats.ConnectedSince = c.stats.connectedSince.UTC().Format(time.RFC3339)
+	}
+	return stats
+}
+
+func (c *Client) recordError(err error) {
+	c.statsMu.Lock()
+	c.stats.lastError = err.Error()
+	c.stats.lastErrorAt = time.Now()
+	c.statsMu.Unlock()
+}
+
+func (c *Client) recordConnected(reconnect bool) {
+	c.statsMu.Lock()
+	if reconnect {
+		c.stats.reconnects++
+	}
+	c.stats.connectedSince = time.Now()
+	c.statsMu.Unlock()
+}
+
+// connectionLost marks the connection of the given stream as broken and wakes up the
+// reconnection handler. Errors of streams that are no longer active are ignored.
+func (c *Client) connectionLost(seq uint64, err error) {
+	if seq != atomic.LoadUint64(&c.activeStream) || atomic.LoadInt32(&c.connected) == 0 {
+		return
+	}
+
+	atomic.StoreInt32(&c.libraryConnected, 0)
+	c.setConnected(false)
+	c.recordError(err)
+
+	select {
+	case c.reconnectCh <- struct{}{}:
+	default:
+	}
+}
+
+// handleReconnection reconnects whenever the connection is reported lost. When max_attempts
+// attempts failed, the attempts start over after a cool-down of max_backoff, so the account does
+// not stay offline for good.
+func (c *Client) handleReconnection(ctx context.Context) {
+	if !c.config.Reconnection.Enabled {
+		return
+	}
+
+	c.logger.Info("Reconnection enabled, starting reconnection monitor")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.reconnectCh:
+		}
+
+		for atomic.LoadInt32(&c.connected) == 0 {
+			c.logger.Warn("XMPP connection lost, attempting to reconnect")
+			err := c.reconnect(ctx)
+			if err == nil || ctx.Err() != nil {
+				break
+			}
+
+			c.logger.Error("Reconnection failed, starting over after a cool-down",
+				zap.Error(err),
+				zap.Duration("cooldown", c.config.Reconnection.MaxBackoff),
+			)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.config.Reconnection.MaxBackoff):
+			}
+		}
+	}
+}
+
+// reconnect attempts to reconnect to XMPP server with capped exponential backoff
+func (c *Client) reconnect(ctx context.Context) error {
+	maxAttempts := c.config.Reconnection.MaxAttempts
+	for attempt := 1; maxAttempts < 0 || attempt <= maxAttempts; attempt++ {
+		delay := backoffDelay(c.config.Reconnection, attempt, rand.Float64)
+		c.logger.Info("Reconnection attempt",
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", maxAttempts),
+			zap.Duration("delay", delay),
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		if err := c.dial(ctx); err != nil {
+			c.recordError(err)
+			c.logger.Error("Reconnection attempt failed",
+				zap.Int("attempt", attempt),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		c.setConnected(true)
+		atomic.StoreInt32(&c.libraryConnected, 1)
+		c.recordConnected(true)
+		c.logger.Info("Reconnection successful",
+			zap.Int("attempt", attempt),
+			zap.String("server", c.serverAddress()),
+		)
+
+		c.restoreSession()
+		return nil
+	}
+
+	return fmt.Errorf("failed to reconnect after %d attempts", maxAttempts)
+}
+
+// backoffDelay returns the delay before a reconnection attempt: the base backoff doubled for
+// every failed attempt, capped at the maximum. Equal jitter keeps at least half of the delay
+// and randomises the rest, so bots restarted together do not reconnect in lockstep.
+func backoffDelay(cfg config.ReconnectionConfig, attempt int, random func() float64) time.Duration {
+	delay := cfg.Backoff
+	for i := 1; i < attempt && delay < cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if cfg.MaxBackoff > 0 && delay > cfg.MaxBackoff {
+		delay = cfg.MaxBackoff
+	}
+
+	half := delay / 2
+	return half + time.Duration(random()*float64(delay-half))
+}
+
+// restoreSession restores the state of the previous session on a new connection. The initial
+// presence is sent by the library when the stream is established.
+func (c *Client) restoreSession() {
+	type roomJoin struct{ jid, nick, password string }
+
+	c.roomsMu.RLock()
+	rooms := make([]roomJoin, 0, len(c.rooms))
+	joined := make(map[string]bool, len(c.rooms))
+	for _, room := range c.rooms {
+		rooms = append(rooms, roomJoin{room.JID, room.Nick, room.Password})
+		joined[room.JID] = true
+	}
+	c.roomsMu.RUnlock()
+
+	// Configured rooms the previous session failed to join are tried again
+	for _, room := range c.config.MUC.Rooms {
+		if !joined[bareJID(room.JID)] {
+			rooms = append(rooms, roomJoin{room.JID, room.Nick, room.Password})
+		}
+	}
+
+	for _, room := range rooms {
+		if err := c.JoinRoom(room.jid, room.nick, room.password); err != nil {
+			c.logger.Error("Failed to rejoin MUC room",
+				zap.String("room", room.jid),
+				zap.Error(err),
+			)
+		}
+	}
+
+	c.enableCarbons()
+}
@@ -0,0 +1,286 @@
+package xmpp
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/xml"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"gosrc.io/xmpp"
+	"gosrc.io/xmpp/stanza"
+)
+
+// newStreamClient returns a connected client whose stream records sent packets
+func newStreamClient(t *testing.T, cfg *config.Config) (*Client, *recordingStream) {
+	t.Helper()
+	client := NewClient(cfg, zaptest.NewLogger(t))
+
+	stream := &recordingStream{}
+	client.client = stream
+	client.setConnected(true)
+	atomic.StoreUint64(&client.activeStream, 1)
+	return client, stream
+}
+
+func TestBackoffDelay(t *testing.T) {
+	cfg := config.ReconnectionConfig{Backoff: time.Second, MaxBackoff: 10 * time.Second}
+	full := func() float64 { return 1 }
+	none := func() float64 { return 0 }
+
+	var delays []time.Duration
+	for attempt := 1; attempt <= 6; attempt++ {
+		delays = append(delays, backoffDelay(cfg, attempt, full))
+	}
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second,
+	}, delays)
+
+	// The jitter keeps at least half of the delay
+	assert.Equal(t, 2*time.Second, backoffDelay(cfg, 3, none))
+	assert.Equal(t, 5*time.Second, backoffDelay(cfg, 100, none))
+}
+
+func TestClient_ConnectionLost(t *testing.T) {
+	client, _ := newStreamClient(t, &config.Config{})
+
+	// Errors of a replaced stream are ignored
+	client.connectionLost(0, errors.New("stale"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&client.connected))
+	assert.Empty(t, client.ConnectionStats().LastError)
+
+	client.connectionLost(1, errors.New("connection reset by peer"))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&client.connected))
+	assert.Len(t, client.reconnectCh, 1)
+
+	stats := client.ConnectionStats()
+	assert.Equal(t, "connection reset by peer", stats.LastError)
+	assert.NotEmpty(t, stats.LastErrorAt)
+
+	// A second report of the same outage does not queue another reconnect
+	client.connectionLost(1, errors.New("EOF"))
+	assert.Len(t, client.reconnectCh, 1)
+}
+
+func TestClient_ConnectStream_ReplacesPrevious(t *testing.T) {
+	client, previous := newStreamClient(t, &config.Config{})
+
+	next := &recordingStream{}
+	require.NoError(t, client.connectStream(next, "example.org:5222", 2))
+	assert.Equal(t, xmpp.StreamClient(next), client.stream())
+	assert.Equal(t, "example.org:5222", client.serverAddress())
+
+	// The previous stream is closed, and its late errors are ignored
+	assert.Eventually(t, previous.isDisconnected, time.Second, 5*time.Millisecond)
+	client.connectionLost(1, errors.New("EOF"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&client.connected))
+}
+
+func TestClient_HandleConnectionEvent_StreamError(t *testing.T) {
+	client, _ := newStreamClient(t, &config.Config{})
+
+	require.NoError(t, client.handleConnectionEvent(1, &xmpp.Event{StreamError: "system-shutdown"}))
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&client.connected))
+	assert.Equal(t, "stream error: system-shutdown", client.ConnectionStats().LastError)
+}
+
+func TestClient_RestoreSession(t *testing.T) {
+	cfg := &config.Config{MUC: config.MUCConfig{
+		Nick: "bot",
+		Rooms: []config.MUCRoomConfig{
+			{JID: "ops@conference.example.org"},
+			{JID: "alerts@conference.example.org", Nick: "alerter"},
+		},
+	}}
+	client, stream := newStreamClient(t, cfg)
+
+	// ops was joined before the outage, alerts failed to join and a room was joined on invitation
+	require.NoError(t, client.JoinRoom("ops@conference.example.org", "", ""))
+	require.NoError(t, client.JoinRoom("invited@conference.example.org", "guest", "secret"))
+	stream.sent = nil
+
+	client.recordConnected(true)
+	client.restoreSession()
+
+	var joins []string
+	for _, packet := range stream.sent {
+		if presence, ok := packet.(stanza.Presence); ok {
+			joins = append(joins, presence.To)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"ops@conference.example.org/bot",
+		"invited@conference.example.org/guest",
+		"alerts@conference.example.org/alerter",
+	}, joins)
+
+	room, ok := client.getRoom("invited@conference.example.org")
+	require.True(t, ok)
+	assert.Equal(t, "secret", room.Password)
+	assert.Equal(t, 1, client.ConnectionStats().Reconnects)
+}
+
+func parseMessage(t *testing.T, raw string) stanza.Message {
+	t.Helper()
+	packet, err := stanza.NextPacket(xml.NewDecoder(strings.NewReader(raw)))
+	require.NoError(t, err)
+	msg, ok := packet.(stanza.Message)
+	require.True(t, ok)
+	return msg
+}
+
+func TestClient_UnwrapCarbon(t *testing.T) {
+	client := NewClient(&config.Config{XMPP: config.XMPPConfig{JID: "bot@example.org/bot"}}, zaptest.NewLogger(t))
+
+	received := parseMessage(t, `<message xmlns="jabber:client" from="bot@example.org" to="bot@example.org/bot">
+  <received xmlns="urn:xmpp:carbons:2">
+    <forwarded xmlns="urn:xmpp:forward:0">
+      <message xmlns="jabber:client" from="alice@example.org/phone" to="bot@example.org/desktop" type="chat" id="m1">
+        <body>Hello</body>
+      </message>
+    </forwarded>
+  </received>
+</message>`)
+	msg, ok := client.unwrapCarbon(received)
+	require.True(t, ok)
+	assert.Equal(t, "alice@example.org/phone", msg.From)
+	assert.Equal(t, "Hello", msg.Body)
+
+	// Carbons from anyone but our own account are forged
+	received.From = "mallory@example.org"
+	_, ok = client.unwrapCarbon(received)
+	assert.False(t, ok)
+
+	sent := parseMessage(t, `<message xmlns="jabber:client" from="bot@example.org" to="bot@example.org/bot">
+  <sent xmlns="urn:xmpp:carbons:2">
+    <forwarded xmlns="urn:xmpp:forward:0">
+      <message xmlns="jabber:client" from="bot@example.org/desktop" to="alice@example.org" type="chat"><body>Hi</body></message>
+    </forwarded>
+  </sent>
+</message>`)
+	_, ok = client.unwrapCarbon(sent)
+	assert.False(t, ok)
+
+	plain := parseMessage(t, `<message xmlns="jabber:client" from="alice@example.org/phone" type="chat"><body>Hi</body></message>`)
+	msg, ok = client.unwrapCarbon(plain)
+	require.True(t, ok)
+	assert.Equal(t, "Hi", msg.Body)
+}
+
+// countingResolver points every SRV lookup to an address nothing listens on
+type countingResolver struct {
+	port    uint16
+	lookups int32
+}
+
+func (r *countingResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if service != "xmpp-client" {
+		return "", nil, errors.New("no such host")
+	}
+	atomic.AddInt32(&r.lookups, 1)
+	return "_" + service + "._" + proto + "." + name, []*net.SRV{{Target: "127.0.0.1.", Port: r.port}}, nil
+}
+
+func TestClient_ConnectOrRetry(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	resolver := &countingResolver{port: uint16(listener.Addr().(*net.TCPAddr).Port)}
+	listener.Close()
+
+	cfg := &config.Config{
+		XMPP: config.XMPPConfig{JID: "bot@example.org", Password: "secret"},
+		Reconnection: config.ReconnectionConfig{
+			Enabled:     true,
+			MaxAttempts: 2,
+			Backoff:     10 * time.Millisecond,
+			MaxBackoff:  10 * time.Millisecond,
+		},
+	}
+
+	// The stream log monitor outlives the test, so it must not log to t
+	client := NewClient(cfg, zap.NewNop())
+	client.resolver = resolver
+	require.Error(t, client.ConnectOrRetry(context.Background()))
+	assert.False(t, client.IsConnected())
+	assert.NotEmpty(t, client.ConnectionStats().LastError)
+
+	// The failed account keeps connecting in the background, and starts over after max_attempts
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&resolver.lookups) >= 5 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, client.Disconnect())
+}
+
+// switchingResolver points SRV lookups to a port that can be changed while the client runs
+type switchingResolver struct {
+	port    atomic.Int32
+	lookups atomic.Int32
+}
+
+func (r *switchingResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if service != "xmpp-client" {
+		return "", nil, errors.New("no such host")
+	}
+	r.lookups.Add(1)
+	return "_" + service + "._" + proto + "." + name, []*net.SRV{{Target: "127.0.0.1.", Port: uint16(r.port.Load())}}, nil
+}
+
+func TestClient_Reconnect_AfterMaxAttempts(t *testing.T) {
+	serverCert, serverKey, _ := writeSelfSignedCert(t, "example.org")
+	cert, err := tls.LoadX509KeyPair(serverCert, serverKey)
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	resolver := &switchingResolver{}
+	resolver.port.Store(int32(listener.Addr().(*net.TCPAddr).Port))
+	listener.Close()
+
+	cfg := &config.Config{
+		XMPP: config.XMPPConfig{
+			JID:      "bot@example.org",
+			Password: "secret",
+			TLS:      config.XMPPTLSConfig{Mode: config.TLSModeStartTLS, CAFile: serverCert},
+			SASL:     config.SASLConfig{Mechanism: config.SASLPlain},
+		},
+		Reconnection: config.ReconnectionConfig{
+			Enabled:     true,
+			MaxAttempts: 1,
+			Backoff:     10 * time.Millisecond,
+			MaxBackoff:  100 * time.Millisecond,
+		},
+	}
+
+	// The stream log monitor outlives the test, so it must not log to t
+	client := NewClient(cfg, zap.NewNop())
+	client.resolver = resolver
+	require.Error(t, client.ConnectOrRetry(context.Background()))
+	//goland:noinspection GoUnhandledErrorResult
+	defer client.Disconnect()
+
+	// The only attempt fails as well
+	require.Eventually(t, func() bool { return resolver.lookups.Load() >= 2 }, 5*time.Second, 5*time.Millisecond)
+
+	// Once the server is back, the next round after the cool-down connects
+	server := &fakeServer{startTLS: true, mechanisms: []string{config.SASLPlain}, password: "secret", cert: cert}
+	addr, done := server.listen(t)
+	_, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	resolver.port.Store(int32(portNumber))
+
+	require.NoError(t, <-done)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&client.connected) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, client.ConnectionStats().Reconnects)
+}
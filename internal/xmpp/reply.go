@@ -0,0 +1,164 @@
+package xmpp
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"jabber-bot/internal/models"
+
+	"go.uber.org/zap"
+	"gosrc.io/xmpp/stanza"
+)
+
+const nsReactions = "urn:xmpp:reactions:0"
+
+// Reactions is a set of emoji reactions to a message (XEP-0444). An update replaces all
+// previous reactions of the sender to the message.
+type Reactions struct {
+	XMLName   xml.Name `xml:"urn:xmpp:reactions:0 reactions"`
+	ID        string   `xml:"id,attr"`
+	Reactions []string `xml:"reaction"`
+}
+
+// Presence show values accepted by SetPresence (RFC 6121 4.7.2.1)
+var presenceShows = map[string]stanza.PresenceShow{
+	"":     "",
+	"away": stanza.PresenceShowAway,
+	"chat": stanza.PresenceShowChat,
+	"dnd":  stanza.PresenceShowDND,
+	"xa":   stanza.PresenceShowXA,
+}
+
+func init() {
+	stanza.TypeRegistry.MapExtension(stanza.PKTMessage, xml.Name{Space: nsReactions, Local: "reactions"}, Reactions{})
+}
+
+// ReplyTarget returns where answers to a received message go and their message type: the room
+// for groupchat messages, otherwise the sender, which for MUC private messages is the occupant
+func ReplyTarget(msg models.Message) (string, string) {
+	if msg.Type == string(stanza.MessageTypeGroupchat) {
+		return bareJID(msg.From), string(stanza.MessageTypeGroupchat)
+	}
+	return msg.From, string(stanza.MessageTypeChat)
+}
+
+// replySender returns the address answers are sent from. Components answer from the address
+// the message was sent to.
+func (c *Client) replySender(msg models.Message) string {
+	if c.isComponent() {
+		return bareJID(msg.To)
+	}
+	return ""
+}
+
+// messageTypeFor returns groupchat for the bare JID of a joined room, otherwise chat
+func (c *Client) messageTypeFor(to string) stanza.StanzaType {
+	if jidResource(to) == "" {
+		if _, ok := c.getRoom(to); ok {
+			return stanza.MessageTypeGroupchat
+		}
+	}
+	return stanza.MessageTypeChat
+}
+
+// SendReply answers a received message in its conversation and thread
+func (c *Client) SendReply(msg models.Message, body string) error {
+	if !c.isConnected() {
+		return fmt.Errorf("XMPP client is not connected")
+	}
+
+	to, messageType := ReplyTarget(msg)
+	send := func(part string) error {
+		if messageType == string(stanza.MessageTypeGroupchat) {
+			return c.sendMUCMessage(to, part, "", nil, msg.Thread)
+		}
+		return c.sendMessage(c.replySender(msg), to, part, messageType, msg.Thread)
+	}
+
+	if limit := c.config.XMPP.MaxBodySize; limit > 0 && len(body) > limit {
+		return c.sendLongBody(to, messageType == string(stanza.MessageTypeGroupchat), body, send)
+	}
+	return send(body)
+}
+
+// SendReaction reacts to a received message with emojis (XEP-0444). Rooms match the reaction
+// against the ID the sender gave the message.
+func (c *Client) SendReaction(msg models.Message, emojis []string) error {
+	if !c.isConnected() {
+		return fmt.Errorf("XMPP client is not connected")
+	}
+	if msg.ID == "" {
+		return fmt.Errorf("message has no ID to react to")
+	}
+
+	to, messageType := ReplyTarget(msg)
+	reaction := stanza.Message{
+		Attrs: stanza.Attrs{
+			From: c.replySender(msg),
+			To:   to,
+			Type: stanza.StanzaType(messageType),
+		},
+		Extensions: []stanza.MsgExtension{
+			Reactions{ID: msg.ID, Reactions: emojis},
+		},
+	}
+
+	if err := c.stream().Send(reaction); err != nil {
+		c.logger.Error("Failed to send reaction",
+			zap.String("to", to),
+			zap.String("message_id", msg.ID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to send reaction: %w", err)
+	}
+
+	c.logger.Info("Reaction sent",
+		zap.String("to", to),
+		zap.String("message_id", msg.ID),
+		zap.Strings("reactions", emojis),
+	)
+
+	return nil
+}
+
+// SetPresence changes the availability and status message of the bot, for its contacts and
+// in the rooms it has joined
+func (c *Client) SetPresence(show, status string) error {
+	if !c.isConnected() {
+		return fmt.Errorf("XMPP client is not connected")
+	}
+
+	// Components have no roster to broadcast presence to
+	if c.isComponent() {
+		return fmt.Errorf("presence cannot be set in component mode")
+	}
+
+	presenceShow, ok := presenceShows[show]
+	if !ok {
+		return fmt.Errorf("invalid presence show %q: must be one of away, chat, dnd, xa", show)
+	}
+
+	presence := stanza.Presence{Show: presenceShow, Status: status}
+	if err := c.stream().Send(presence); err != nil {
+		return fmt.Errorf("failed to send presence: %w", err)
+	}
+
+	// Room occupants only see presence sent to the room
+	for _, room := range c.JoinedRooms() {
+		joined, ok := c.getRoom(room)
+		if !ok {
+			continue
+		}
+		presence.Attrs = stanza.Attrs{To: room + "/" + joined.Nick}
+		if err := c.stream().Send(presence); err != nil {
+			return fmt.Errorf("failed to send presence to %s: %w", room, err)
+		}
+	}
+
+	c.logger.Info("Presence changed",
+		zap.String("show", show),
+		zap.String("status", status),
+	)
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package xmpp
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"jabber-bot/internal/config"
+	"jabber-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gosrc.io/xmpp/stanza"
+)
+
+func TestClient_SendReply(t *testing.T) {
+	client, stream := newStreamClient(t, &config.Config{})
+	client.rooms[testRoom] = &MUCRoom{JID: testRoom, Nick: "bot", Occupants: make(map[string]*models.Occupant)}
+
+	// Groupchat messages are answered in the room, in their thread
+	require.NoError(t, client.SendReply(models.Message{
+		From:   testRoom + "/alice",
+		Type:   "groupchat",
+		Thread: "deploy-42",
+	}, "On it"))
+	require.Len(t, stream.sent, 1)
+	reply := stream.sent[0].(stanza.Message)
+	assert.Equal(t, testRoom, reply.To)
+	assert.Equal(t, stanza.MessageTypeGroupchat, reply.Type)
+	assert.Equal(t, "deploy-42", reply.Thread)
+	assert.Equal(t, "On it", reply.Body)
+
+	// Private messages from occupants are answered privately
+	require.NoError(t, client.SendReply(models.Message{From: testRoom + "/alice", Type: "chat", MUCPrivate: true}, "Sure"))
+	reply = stream.sent[1].(stanza.Message)
+	assert.Equal(t, testRoom+"/alice", reply.To)
+	assert.Equal(t, stanza.MessageTypeChat, reply.Type)
+	assert.Contains(t, reply.Extensions, MUCUser{})
+
+	require.NoError(t, client.SendReply(models.Message{From: "bob@example.org/phone", Type: "chat"}, "Hi Bob"))
+	reply = stream.sent[2].(stanza.Message)
+	assert.Equal(t, "bob@example.org/phone", reply.To)
+	assert.Empty(t, reply.Thread)
+}
+
+func TestClient_SendReaction(t *testing.T) {
+	client, stream := newStreamClient(t, &config.Config{})
+
+	require.NoError(t, client.SendReaction(models.Message{ID: "msg-1", From: "bob@example.org/phone", Type: "chat"}, []string{"👍"}))
+	require.Len(t, stream.sent, 1)
+
+	data, err := xml.Marshal(stream.sent[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<reactions xmlns="urn:xmpp:reactions:0" id="msg-1"><reaction>👍</reaction></reactions>`)
+
+	assert.Error(t, client.SendReaction(models.Message{From: "bob@example.org/phone"}, []string{"👍"}))
+}
+
+func TestClient_SetPresence(t *testing.T) {
+	client, stream := newStreamClient(t, &config.Config{})
+	client.rooms[testRoom] = &MUCRoom{JID: testRoom, Nick: "bot", Occupants: make(map[string]*models.Occupant)}
+
+	require.NoError(t, client.SetPresence("dnd", "Deploying"))
+	require.Len(t, stream.sent, 2)
+	broadcast := stream.sent[0].(stanza.Presence)
+	assert.Empty(t, broadcast.To)
+	assert.Equal(t, stanza.PresenceShowDND, broadcast.Show)
+	assert.Equal(t, "Deploying", broadcast.Status)
+	assert.Equal(t, testRoom+"/bot", stream.sent[1].(stanza.Presence).To)
+
+	assert.Error(t, client.SetPresence("busy", ""))
+}
@@ -0,0 +1,131 @@
+package xmpp
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"jabber-bot/internal/config"
+)
+
+// scramGS2Header is the GS2 header of a client without channel binding
+const scramGS2Header = "n,,"
+
+// scramClient runs the client side of a SCRAM exchange (RFC 5802, RFC 7677) without channel
+// binding
+type scramClient struct {
+	hash     func() hash.Hash
+	username string
+	password string
+	nonce    string
+
+	clientFirstBare string
+	serverSignature []byte
+}
+
+// newScramClient creates a SCRAM client for SCRAM-SHA-1 or SCRAM-SHA-256
+func newScramClient(mechanism, username, password string) (*scramClient, error) {
+	var h func() hash.Hash
+	switch mechanism {
+	case config.SASLScramSHA1:
+		h = sha1.New
+	case config.SASLScramSHA256:
+		h = sha256.New
+	default:
+		return nil, fmt.Errorf("unsupported SCRAM mechanism %s", mechanism)
+	}
+
+	nonce := make([]byte, 24)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate SCRAM nonce: %w", err)
+	}
+
+	return &scramClient{
+		hash:     h,
+		username: username,
+		password: password,
+		nonce:    base64.RawStdEncoding.EncodeToString(nonce),
+	}, nil
+}
+
+// first returns the client-first-message
+func (s *scramClient) first() string {
+	escaped := strings.NewReplacer("=", "=3D", ",", "=2C").Replace(s.username)
+	s.clientFirstBare = "n=" + escaped + ",r=" + s.nonce
+	return scramGS2Header + s.clientFirstBare
+}
+
+// final answers the server-first-message with the client-final-message
+func (s *scramClient) final(serverFirst string) (string, error) {
+	attrs := scramAttributes(serverFirst)
+	nonce, salt64, iterations := attrs["r"], attrs["s"], attrs["i"]
+	if !strings.HasPrefix(nonce, s.nonce) || len(nonce) == len(s.nonce) {
+		return "", errors.New("server returned an invalid SCRAM nonce")
+	}
+	salt, err := base64.StdEncoding.DecodeString(salt64)
+	if err != nil || len(salt) == 0 {
+		return "", errors.New("server returned an invalid SCRAM salt")
+	}
+	iter, err := strconv.Atoi(iterations)
+	if err != nil || iter < 1 {
+		return "", fmt.Errorf("server returned an invalid SCRAM iteration count %q", iterations)
+	}
+
+	salted, err := pbkdf2.Key(s.hash, s.password, salt, iter, s.hash().Size())
+	if err != nil {
+		return "", fmt.Errorf("failed to derive SCRAM key: %w", err)
+	}
+
+	withoutProof := "c=" + base64.StdEncoding.EncodeToString([]byte(scramGS2Header)) + ",r=" + nonce
+	authMessage := []byte(s.clientFirstBare + "," + serverFirst + "," + withoutProof)
+
+	clientKey := s.hmac(salted, []byte("Client Key"))
+	storedKey := s.hash()
+	storedKey.Write(clientKey)
+	proof := s.hmac(storedKey.Sum(nil), authMessage)
+	subtle.XORBytes(proof, proof, clientKey)
+
+	s.serverSignature = s.hmac(s.hmac(salted, []byte("Server Key")), authMessage)
+
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+// verify checks the server signature of the server-final-message, which proves the server
+// knows the password too
+func (s *scramClient) verify(serverFinal string) error {
+	attrs := scramAttributes(serverFinal)
+	if e, ok := attrs["e"]; ok {
+		return fmt.Errorf("SCRAM authentication failed: %s", e)
+	}
+	signature, err := base64.StdEncoding.DecodeString(attrs["v"])
+	if err != nil || !hmac.Equal(signature, s.serverSignature) {
+		return errors.New("server signature mismatch: the server does not know the password")
+	}
+	return nil
+}
+
+func (s *scramClient) hmac(key, data []byte) []byte {
+	mac := hmac.New(s.hash, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// scramAttributes parses the comma separated attributes of a SCRAM message
+func scramAttributes(msg string) map[string]string {
+	attrs := make(map[string]string)
+	for _, field := range strings.Split(msg, ",") {
+		if name, value, ok := strings.Cut(field, "="); ok && len(name) == 1 {
+			attrs[name] = value
+		}
+	}
+	return attrs
+}
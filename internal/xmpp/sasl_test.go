@@ -0,0 +1,87 @@
+package xmpp
+
+import (
+	"testing"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScramClient(t *testing.T) {
+	// Test vectors of RFC 5802 section 5 and RFC 7677 section 3
+	tests := []struct {
+		mechanism   string
+		nonce       string
+		serverFirst string
+		clientFinal string
+		serverFinal string
+	}{
+		{
+			mechanism:   config.SASLScramSHA1,
+			nonce:       "fyko+d2lbbFgONRv9qkxdawL",
+			serverFirst: "r=fyko+d2lbbFgONRv9qkxdawL3rfcNHYJY1ZVvWVs7j,s=QSXCR+Q6sek8bf92,i=4096",
+			clientFinal: "c=biws,r=fyko+d2lbbFgONRv9qkxdawL3rfcNHYJY1ZVvWVs7j,p=v0X8v3Bz2T0CJGbJQyF0X+HI4Ts=",
+			serverFinal: "v=rmF9pqV8S7suAoZWja4dJRkFsKQ=",
+		},
+		{
+			mechanism:   config.SASLScramSHA256,
+			nonce:       "rOprNGfwEbeRWgbNEkqO",
+			serverFirst: "r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096",
+			clientFinal: "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=",
+			serverFinal: "v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mechanism, func(t *testing.T) {
+			scram, err := newScramClient(tt.mechanism, "user", "pencil")
+			require.NoError(t, err)
+			scram.nonce = tt.nonce
+
+			assert.Equal(t, "n,,n=user,r="+tt.nonce, scram.first())
+
+			clientFinal, err := scram.final(tt.serverFirst)
+			require.NoError(t, err)
+			assert.Equal(t, tt.clientFinal, clientFinal)
+
+			assert.NoError(t, scram.verify(tt.serverFinal))
+			assert.ErrorContains(t, scram.verify("v=AAAA"), "server signature mismatch")
+			assert.ErrorContains(t, scram.verify("e=invalid-proof"), "invalid-proof")
+		})
+	}
+}
+
+func TestScramClient_InvalidServerFirst(t *testing.T) {
+	tests := []struct {
+		name        string
+		serverFirst string
+		errMsg      string
+	}{
+		{"foreign nonce", "r=other,s=QSXCR+Q6sek8bf92,i=4096", "invalid SCRAM nonce"},
+		{"nonce not extended", "r=abc,s=QSXCR+Q6sek8bf92,i=4096", "invalid SCRAM nonce"},
+		{"missing salt", "r=abcdef,i=4096", "invalid SCRAM salt"},
+		{"bad iteration count", "r=abcdef,s=QSXCR+Q6sek8bf92,i=0", "invalid SCRAM iteration count"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scram, err := newScramClient(config.SASLScramSHA256, "user", "pencil")
+			require.NoError(t, err)
+			scram.nonce = "abc"
+			scram.first()
+
+			_, err = scram.final(tt.serverFirst)
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestScramClient_EscapesUsername(t *testing.T) {
+	scram, err := newScramClient(config.SASLScramSHA1, "a=b,c", "pencil")
+	require.NoError(t, err)
+	scram.nonce = "abc"
+
+	assert.Equal(t, "n,,n=a=3Db=2Cc,r=abc", scram.first())
+}
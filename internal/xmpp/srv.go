@@ -0,0 +1,124 @@
+package xmpp
+
+import (
+	"context"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultClientPort = 5222
+	srvLookupTimeout  = 10 * time.Second
+)
+
+// srvResolver looks up DNS SRV records; *net.Resolver implements it
+type srvResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// serverTarget is a candidate address of the XMPP server
+type serverTarget struct {
+	Address   string
+	DirectTLS bool // from an _xmpps-client record (XEP-0368), TLS from the first byte
+}
+
+// resolveServer returns the addresses to try for a JID domain. The records of _xmpps-client
+// (XEP-0368) and _xmpp-client (RFC 6120 3.2.1) are merged in priority order, records of the same
+// priority are ordered by weighted random selection (RFC 2782), and the domain itself on the
+// default port is tried last.
+func resolveServer(ctx context.Context, resolver srvResolver, domain string, logger *zap.Logger) []serverTarget {
+	ctx, cancel := context.WithTimeout(ctx, srvLookupTimeout)
+	defer cancel()
+
+	var records []srvRecord
+	for _, service := range []string{"xmpps-client", "xmpp-client"} {
+		_, srvs, err := resolver.LookupSRV(ctx, service, "tcp", domain)
+		if err != nil {
+			logger.Debug("SRV lookup failed",
+				zap.String("service", service),
+				zap.String("domain", domain),
+				zap.Error(err),
+			)
+			continue
+		}
+		for _, srv := range srvs {
+			records = append(records, srvRecord{srv: srv, directTLS: service == "xmpps-client"})
+		}
+	}
+	records = orderSRVRecords(records)
+
+	targets := make([]serverTarget, 0, len(records)+1)
+	fallback := net.JoinHostPort(domain, strconv.Itoa(defaultClientPort))
+	hasFallback := false
+	for _, r := range records {
+		host := strings.TrimSuffix(r.srv.Target, ".")
+		// A target of "." means the service is decidedly not available at this domain
+		if host == "" {
+			continue
+		}
+		address := net.JoinHostPort(host, strconv.Itoa(int(r.srv.Port)))
+		if address == fallback && !r.directTLS {
+			hasFallback = true
+		}
+		targets = append(targets, serverTarget{Address: address, DirectTLS: r.directTLS})
+	}
+
+	if !hasFallback {
+		targets = append(targets, serverTarget{Address: fallback})
+	}
+
+	return targets
+}
+
+// srvRecord is an SRV record of either service
+type srvRecord struct {
+	srv       *net.SRV
+	directTLS bool
+}
+
+// orderSRVRecords sorts records by priority and orders the records of each priority by weighted
+// random selection (RFC 2782): records of weight 0 go first, then a record is picked with a
+// probability proportional to its weight until none is left
+func orderSRVRecords(records []srvRecord) []srvRecord {
+	slices.SortStableFunc(records, func(a, b srvRecord) int {
+		if a.srv.Priority != b.srv.Priority {
+			return int(a.srv.Priority) - int(b.srv.Priority)
+		}
+		// Weight 0 first, so it only gets picked when the running sum is 0
+		return min(int(a.srv.Weight), 1) - min(int(b.srv.Weight), 1)
+	})
+
+	ordered := make([]srvRecord, 0, len(records))
+	for start := 0; start < len(records); {
+		end := start
+		for end < len(records) && records[end].srv.Priority == records[start].srv.Priority {
+			end++
+		}
+
+		group := slices.Clone(records[start:end])
+		for len(group) > 0 {
+			total := 0
+			for _, r := range group {
+				total += int(r.srv.Weight)
+			}
+			pick := rand.IntN(total + 1)
+			sum := 0
+			for i, r := range group {
+				sum += int(r.srv.Weight)
+				if sum >= pick {
+					ordered = append(ordered, r)
+					group = slices.Delete(group, i, i+1)
+					break
+				}
+			}
+		}
+		start = end
+	}
+	return ordered
+}
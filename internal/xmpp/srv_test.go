@@ -0,0 +1,177 @@
+package xmpp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// fakeResolver returns SRV records keyed by service name
+type fakeResolver struct {
+	records map[string][]*net.SRV
+	lookups int
+}
+
+func (r *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.lookups++
+	records, ok := r.records[service]
+	if !ok {
+		return "", nil, errors.New("no such host")
+	}
+	return "_" + service + "._" + proto + "." + name, records, nil
+}
+
+func TestResolveServer(t *testing.T) {
+	resolver := &fakeResolver{records: map[string][]*net.SRV{
+		"xmpps-client": {
+			{Target: "tls.example.org.", Port: 5223, Priority: 5, Weight: 0},
+		},
+		"xmpp-client": {
+			{Target: "node1.example.org.", Port: 5222, Priority: 10, Weight: 50},
+			{Target: "backup.example.org.", Port: 5222, Priority: 20, Weight: 0},
+		},
+	}}
+
+	targets := resolveServer(context.Background(), resolver, "example.org", zaptest.NewLogger(t))
+
+	// Records of both services are kept, each with its own TLS mode
+	assert.Equal(t, []serverTarget{
+		{Address: "tls.example.org:5223", DirectTLS: true},
+		{Address: "node1.example.org:5222"},
+		{Address: "backup.example.org:5222"},
+		{Address: "example.org:5222"},
+	}, targets)
+}
+
+func TestResolveServer_Weights(t *testing.T) {
+	resolver := &fakeResolver{records: map[string][]*net.SRV{
+		"xmpps-client": {
+			{Target: "tls.example.org.", Port: 5223, Priority: 10, Weight: 10},
+		},
+		"xmpp-client": {
+			{Target: "node1.example.org.", Port: 5222, Priority: 10, Weight: 90},
+			{Target: "idle.example.org.", Port: 5222, Priority: 10, Weight: 0},
+			{Target: "backup.example.org.", Port: 5222, Priority: 20, Weight: 100},
+		},
+	}}
+
+	first := make(map[string]int)
+	for range 1000 {
+		targets := resolveServer(context.Background(), resolver, "example.org", zaptest.NewLogger(t))
+		require.Len(t, targets, 5)
+		first[targets[0].Address]++
+
+		// Priority always comes before weight
+		assert.ElementsMatch(t, []string{"tls.example.org:5223", "node1.example.org:5222", "idle.example.org:5222"},
+			[]string{targets[0].Address, targets[1].Address, targets[2].Address})
+		assert.Equal(t, "backup.example.org:5222", targets[3].Address)
+	}
+
+	// Within a priority, records are picked in proportion to their weight, but every one is
+	// picked first now and then
+	assert.Greater(t, first["node1.example.org:5222"], 800)
+	assert.Greater(t, first["tls.example.org:5223"], 40)
+	assert.Less(t, first["tls.example.org:5223"], 200)
+	assert.Less(t, first["idle.example.org:5222"], 50)
+}
+
+func TestResolveServer_Fallback(t *testing.T) {
+	// Without records the domain itself is used
+	targets := resolveServer(context.Background(), &fakeResolver{}, "example.org", zaptest.NewLogger(t))
+	assert.Equal(t, []serverTarget{{Address: "example.org:5222"}}, targets)
+
+	// A "." target means the service is not offered and is skipped
+	resolver := &fakeResolver{records: map[string][]*net.SRV{
+		"xmpp-client": {{Target: ".", Port: 0}},
+	}}
+	targets = resolveServer(context.Background(), resolver, "example.org", zaptest.NewLogger(t))
+	assert.Equal(t, []serverTarget{{Address: "example.org:5222"}}, targets)
+
+	// The fallback is not repeated when a record already points at the domain
+	resolver = &fakeResolver{records: map[string][]*net.SRV{
+		"xmpp-client": {{Target: "example.org.", Port: 5222}},
+	}}
+	targets = resolveServer(context.Background(), resolver, "example.org", zaptest.NewLogger(t))
+	assert.Equal(t, []serverTarget{{Address: "example.org:5222"}}, targets)
+}
+
+// unusedPort returns a local port nothing listens on, so connecting to it fails immediately
+func unusedPort(t *testing.T) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	return port
+}
+
+func TestClient_Dial_TriesResolvedTargets(t *testing.T) {
+	port1, port2 := unusedPort(t), unusedPort(t)
+
+	cfg := &config.Config{XMPP: config.XMPPConfig{
+		Mode:     config.ModeClient,
+		JID:      "bot@127.0.0.1",
+		Password: "secret",
+		TLS:      config.XMPPTLSConfig{MinVersion: "1.2"},
+	}}
+	client := NewClient(cfg, zaptest.NewLogger(t))
+	resolver := &fakeResolver{records: map[string][]*net.SRV{
+		"xmpp-client": {
+			{Target: "127.0.0.1.", Port: uint16(port1), Priority: 1},
+			{Target: "127.0.0.1.", Port: uint16(port2), Priority: 2},
+		},
+	}}
+	client.resolver = resolver
+
+	err := client.dial(context.Background())
+	require.Error(t, err)
+	// All records failed, so the domain fallback is the last address tried
+	assert.ErrorContains(t, err, "127.0.0.1:"+strconv.Itoa(defaultClientPort))
+
+	// Every dial resolves the records again
+	_ = client.dial(context.Background())
+	assert.Equal(t, 4, resolver.lookups)
+}
+
+func TestClient_Dial_DirectTLSRecordWithStartTLSMode(t *testing.T) {
+	serverCert, serverKey, _ := writeSelfSignedCert(t, "example.org")
+	cert, err := tls.LoadX509KeyPair(serverCert, serverKey)
+	require.NoError(t, err)
+
+	server := &fakeServer{directTLS: true, mechanisms: []string{config.SASLPlain}, password: "secret", cert: cert}
+	addr, done := server.listen(t)
+	_, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	cfg := &config.Config{XMPP: config.XMPPConfig{
+		JID:      "bot@example.org",
+		Password: "secret",
+		TLS:      config.XMPPTLSConfig{Mode: config.TLSModeStartTLS, CAFile: serverCert},
+		SASL:     config.SASLConfig{Mechanism: config.SASLPlain},
+	}}
+	// The stream log monitor outlives the test, so it must not log to t
+	client := NewClient(cfg, zap.NewNop())
+	client.resolver = &fakeResolver{records: map[string][]*net.SRV{
+		"xmpps-client": {{Target: "127.0.0.1.", Port: uint16(portNum), Priority: 1}},
+		"xmpp-client":  {{Target: "127.0.0.1.", Port: uint16(unusedPort(t)), Priority: 2}},
+	}}
+
+	// The _xmpps-client record is used with Direct TLS although the configured mode is STARTTLS
+	require.NoError(t, client.dial(context.Background()))
+	defer client.Disconnect()
+	require.NoError(t, <-done)
+}
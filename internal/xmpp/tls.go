@@ -0,0 +1,114 @@
+package xmpp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"jabber-bot/internal/config"
+
+	"gosrc.io/xmpp"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// buildTLSConfig creates the TLS configuration of the session, for STARTTLS and Direct TLS
+func buildTLSConfig(cfg config.XMPPTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.MinVersion != "" {
+		version, ok := tlsVersions[cfg.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS version %q", cfg.MinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.ServerName != "" {
+		tlsConfig.ServerName = cfg.ServerName
+		if !cfg.InsecureSkipVerify {
+			// The library checks the certificate against the JID domain after the handshake, which
+			// fails when the server presents a certificate for another name. Skip the built-in
+			// verification and verify the chain against the configured name instead.
+			tlsConfig.InsecureSkipVerify = true
+			tlsConfig.VerifyConnection = verifyServerName(cfg.ServerName, tlsConfig.RootCAs)
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// verifyServerName verifies the server certificate chain for the given host name
+func verifyServerName(serverName string, roots *x509.CertPool) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return fmt.Errorf("server presented no certificate")
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range state.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+			DNSName:       serverName,
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		return err
+	}
+}
+
+// credential returns the SASL credential for the configured mechanism
+func credential(cfg config.XMPPConfig) xmpp.Credential {
+	if cfg.SASL.Mechanism == config.SASLOAuth2 {
+		return xmpp.OAuthToken(cfg.Password)
+	}
+	return xmpp.Password(cfg.Password)
+}
+
+// describeConnectError turns TLS and SASL negotiation failures into errors that name the setting
+// the server could not satisfy
+func describeConnectError(cfg config.XMPPConfig, err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "no matching authentication"):
+		return fmt.Errorf("server does not offer SASL mechanism %s: %w", cfg.SASL.Mechanism, err)
+	case strings.Contains(msg, "does not advertise support for starttls"):
+		return fmt.Errorf("server does not offer STARTTLS: %w", err)
+	case strings.Contains(msg, "x509:"):
+		return fmt.Errorf("server certificate rejected: %w", err)
+	case strings.Contains(msg, "failed to negotiate TLS session"):
+		return fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package xmpp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSignedCert writes a self-signed certificate for the given host and its key to a temp dir
+func writeSelfSignedCert(t *testing.T, host string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	return certFile, keyFile, cert
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	certFile, keyFile, _ := writeSelfSignedCert(t, "bot.example.org")
+
+	tlsConfig, err := buildTLSConfig(config.XMPPTLSConfig{
+		CAFile:     certFile,
+		MinVersion: "1.3",
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+	assert.NotNil(t, tlsConfig.RootCAs)
+	assert.Len(t, tlsConfig.Certificates, 1)
+	assert.False(t, tlsConfig.InsecureSkipVerify)
+	assert.Empty(t, tlsConfig.ServerName)
+}
+
+func TestBuildTLSConfig_Invalid(t *testing.T) {
+	emptyFile := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(emptyFile, nil, 0600))
+
+	_, err := buildTLSConfig(config.XMPPTLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "failed to read CA bundle")
+
+	_, err = buildTLSConfig(config.XMPPTLSConfig{CAFile: emptyFile})
+	assert.ErrorContains(t, err, "no certificates found")
+
+	_, err = buildTLSConfig(config.XMPPTLSConfig{CertFile: emptyFile, KeyFile: emptyFile})
+	assert.ErrorContains(t, err, "failed to load client certificate")
+}
+
+func TestBuildTLSConfig_ServerName(t *testing.T) {
+	certFile, _, cert := writeSelfSignedCert(t, "xmpp.internal.example.org")
+
+	tlsConfig, err := buildTLSConfig(config.XMPPTLSConfig{
+		CAFile:     certFile,
+		ServerName: "xmpp.internal.example.org",
+	})
+	require.NoError(t, err)
+
+	// The library's check against the JID domain is replaced by our own verification
+	assert.Equal(t, "xmpp.internal.example.org", tlsConfig.ServerName)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+	require.NotNil(t, tlsConfig.VerifyConnection)
+
+	state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	assert.NoError(t, tlsConfig.VerifyConnection(state))
+
+	// A certificate for another host is rejected
+	_, _, other := writeSelfSignedCert(t, "other.example.org")
+	assert.Error(t, tlsConfig.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{other}}))
+
+	// With verification disabled the override is only used for SNI
+	tlsConfig, err = buildTLSConfig(config.XMPPTLSConfig{ServerName: "xmpp.internal.example.org", InsecureSkipVerify: true})
+	require.NoError(t, err)
+	assert.Nil(t, tlsConfig.VerifyConnection)
+}
+
+func TestDescribeConnectError(t *testing.T) {
+	cfg := config.XMPPConfig{SASL: config.SASLConfig{Mechanism: config.SASLOAuth2}}
+
+	err := describeConnectError(cfg, errors.New("no matching authentication ([X-OAUTH2]) supported by server: [SCRAM-SHA-1]"))
+	assert.ErrorContains(t, err, "server does not offer SASL mechanism X-OAUTH2")
+
+	err = describeConnectError(cfg, errors.New("failed to negotiate TLS session : XMPP server does not advertise support for starttls"))
+	assert.ErrorContains(t, err, "server does not offer STARTTLS")
+
+	err = describeConnectError(cfg, errors.New("failed to negotiate TLS session : x509: certificate signed by unknown authority"))
+	assert.ErrorContains(t, err, "server certificate rejected")
+
+	original := errors.New("connection refused")
+	assert.Equal(t, original, describeConnectError(cfg, original))
+}
@@ -0,0 +1,112 @@
+package xmpp
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	relWebSocket     = "urn:xmpp:alt-connections:websocket"
+	hostMetaTimeout  = 10 * time.Second
+	maxHostMetaBytes = 64 * 1024
+)
+
+// hostMetaJSON is the JRD form of a host-meta document (RFC 6415)
+type hostMetaJSON struct {
+	Links []hostMetaLink `json:"links"`
+}
+
+// hostMetaXML is the XRD form of a host-meta document (RFC 6415)
+type hostMetaXML struct {
+	Links []hostMetaLink `xml:"Link"`
+}
+
+type hostMetaLink struct {
+	Rel  string `json:"rel" xml:"rel,attr"`
+	Href string `json:"href" xml:"href,attr"`
+}
+
+// isWebSocketURL reports whether a server address is a WebSocket endpoint (RFC 7395)
+func isWebSocketURL(address string) bool {
+	return strings.HasPrefix(address, "ws://") || strings.HasPrefix(address, "wss://")
+}
+
+// newHTTPTransport creates the HTTP transport used for WebSocket connections and host-meta
+// discovery, honouring the TLS settings and the outbound proxy
+func newHTTPTransport(tlsConfig *tls.Config, proxyURL *url.URL) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return transport
+}
+
+// discoverWebSocket looks up the WebSocket endpoint of a domain in its host-meta document
+// (XEP-0156). The JSON form is tried first, then the XML form. Only secure endpoints are used.
+func discoverWebSocket(ctx context.Context, httpClient *http.Client, domain string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, hostMetaTimeout)
+	defer cancel()
+
+	var errs []string
+	for _, path := range []string{"/.well-known/host-meta.json", "/.well-known/host-meta"} {
+		links, err := fetchHostMeta(ctx, httpClient, "https://"+domain+path)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		for _, link := range links {
+			if link.Rel == relWebSocket && strings.HasPrefix(link.Href, "wss://") {
+				return link.Href, nil
+			}
+		}
+		errs = append(errs, fmt.Sprintf("%s: no secure WebSocket endpoint", path))
+	}
+
+	return "", fmt.Errorf("no WebSocket endpoint found for %s: %s", domain, strings.Join(errs, "; "))
+}
+
+func fetchHostMeta(ctx context.Context, httpClient *http.Client, hostMetaURL string) ([]hostMetaLink, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hostMetaURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", hostMetaURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHostMetaBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(hostMetaURL, ".json") {
+		var doc hostMetaJSON
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", hostMetaURL, err)
+		}
+		return doc.Links, nil
+	}
+
+	var doc hostMetaXML
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", hostMetaURL, err)
+	}
+	return doc.Links, nil
+}
@@ -0,0 +1,98 @@
+package xmpp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"jabber-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// hostMetaServer serves host-meta documents keyed by path over TLS and returns its domain
+func hostMetaServer(t *testing.T, docs map[string]string) (*httptest.Server, string) {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, ok := docs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(doc))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, strings.TrimPrefix(server.URL, "https://")
+}
+
+func TestDiscoverWebSocket_JSON(t *testing.T) {
+	server, domain := hostMetaServer(t, map[string]string{
+		"/.well-known/host-meta.json": `{"links": [
+			{"rel": "urn:xmpp:alt-connections:xbosh", "href": "https://example.org/http-bind"},
+			{"rel": "urn:xmpp:alt-connections:websocket", "href": "ws://example.org/xmpp-websocket"},
+			{"rel": "urn:xmpp:alt-connections:websocket", "href": "wss://example.org/xmpp-websocket"}
+		]}`,
+	})
+
+	endpoint, err := discoverWebSocket(context.Background(), server.Client(), domain)
+	require.NoError(t, err)
+	// Insecure endpoints are ignored
+	assert.Equal(t, "wss://example.org/xmpp-websocket", endpoint)
+}
+
+func TestDiscoverWebSocket_XML(t *testing.T) {
+	server, domain := hostMetaServer(t, map[string]string{
+		"/.well-known/host-meta": `<?xml version='1.0' encoding='utf-8'?>
+<XRD xmlns='http://docs.oasis-open.org/ns/xri/xrd-1.0'>
+  <Link rel="urn:xmpp:alt-connections:websocket" href="wss://example.org/ws" />
+</XRD>`,
+	})
+
+	endpoint, err := discoverWebSocket(context.Background(), server.Client(), domain)
+	require.NoError(t, err)
+	assert.Equal(t, "wss://example.org/ws", endpoint)
+}
+
+func TestDiscoverWebSocket_NotFound(t *testing.T) {
+	server, domain := hostMetaServer(t, map[string]string{
+		"/.well-known/host-meta.json": `{"links": []}`,
+	})
+
+	_, err := discoverWebSocket(context.Background(), server.Client(), domain)
+	assert.ErrorContains(t, err, "no WebSocket endpoint found")
+}
+
+func TestIsWebSocketURL(t *testing.T) {
+	assert.True(t, isWebSocketURL("wss://example.org/xmpp-websocket"))
+	assert.True(t, isWebSocketURL("ws://localhost:5280/ws"))
+	assert.False(t, isWebSocketURL("example.org:5222"))
+}
+
+func TestClient_Dial_WebSocketUsesAccountProxy(t *testing.T) {
+	requests := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r.URL.String()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(proxy.Close)
+
+	cfg := &config.Config{XMPP: config.XMPPConfig{
+		JID:       "bot@example.org",
+		Password:  "secret",
+		Server:    "ws://xmpp.example.org/ws",
+		Transport: config.TransportWebSocket,
+		Proxy:     proxy.URL,
+	}}
+	client := NewClient(cfg, zaptest.NewLogger(t))
+
+	assert.Error(t, client.dial(context.Background()))
+	assert.Equal(t, "http://xmpp.example.org/ws", <-requests)
+	// The proxy is set on the account's own HTTP client, never on the shared default client
+	assert.Nil(t, http.DefaultClient.Transport)
+}
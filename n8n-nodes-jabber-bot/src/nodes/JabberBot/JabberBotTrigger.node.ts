@@ -1,4 +1,6 @@
+import { createHmac, timingSafeEqual } from 'crypto';
 import {
+  IHookFunctions,
   IWebhookFunctions,
   INodeType,
   INodeTypeDescription,
@@ -6,6 +8,30 @@ import {
   IDataObject,
 } from 'n8n-workflow';
 
+async function botApi(this: IHookFunctions): Promise<{ baseUrl: string; apiKey: string }> {
+  const credentials = await this.getCredentials('jabberBotApi');
+  return {
+    baseUrl: (credentials.baseUrl as string).replace(/\/$/, ''),
+    apiKey: credentials.apiKey as string,
+  };
+}
+
+// Deliveries are signed with HMAC-SHA256 over "<X-Webhook-Timestamp>.<body>" (see pkg/webhooksig)
+function validSignature(secret: string, header: string, timestamp: string, body: Buffer): boolean {
+  const expected = Buffer.from(
+    'sha256=' + createHmac('sha256', secret).update(`${timestamp}.`).update(body).digest('hex'),
+  );
+  return header
+    .split(',')
+    .map((signature) => Buffer.from(signature.trim()))
+    .some((signature) => signature.length === expected.length && timingSafeEqual(signature, expected));
+}
+
+function splitList(value: string | undefined): string[] | undefined {
+  const items = (value ?? '').split(',').map((item) => item.trim()).filter((item) => item !== '');
+  return items.length > 0 ? items : undefined;
+}
+
 export class JabberBotTrigger implements INodeType {
   description: INodeTypeDescription = {
     displayName: 'Jabber Bot Trigger',
@@ -20,6 +46,17 @@ export class JabberBotTrigger implements INodeType {
     },
     inputs: [],
     outputs: ['main'],
+    credentials: [
+      {
+        name: 'jabberBotApi',
+        required: true,
+        displayOptions: {
+          show: {
+            register: [true],
+          },
+        },
+      },
+    ],
     webhooks: [
       {
         name: 'default',
@@ -29,10 +66,192 @@ export class JabberBotTrigger implements INodeType {
         responseData: 'allEntries',
       },
     ],
-    properties: [],
+    properties: [
+      {
+        displayName: 'Register With Bot',
+        name: 'register',
+        type: 'boolean',
+        default: false,
+        description:
+          'Whether to register this webhook with the bot when the workflow is activated. Otherwise point webhook.url of the bot to it.',
+      },
+      {
+        displayName: 'Filters',
+        name: 'filters',
+        type: 'collection',
+        placeholder: 'Add Filter',
+        default: {},
+        displayOptions: {
+          show: {
+            register: [true],
+          },
+        },
+        options: [
+          {
+            displayName: 'From',
+            name: 'from',
+            type: 'string',
+            default: '',
+            placeholder: 'alice@example.com, example.org',
+            description: 'Comma-separated sender JIDs or domains',
+          },
+          {
+            displayName: 'Rooms',
+            name: 'rooms',
+            type: 'string',
+            default: '',
+            placeholder: 'ops@conference.example.com',
+            description: 'Comma-separated room JIDs',
+          },
+          {
+            displayName: 'Message Types',
+            name: 'types',
+            type: 'multiOptions',
+            options: [
+              { name: 'Chat', value: 'chat' },
+              { name: 'Groupchat', value: 'groupchat' },
+              { name: 'Normal', value: 'normal' },
+              { name: 'Headline', value: 'headline' },
+            ],
+            default: [],
+          },
+          {
+            displayName: 'Events',
+            name: 'events',
+            type: 'multiOptions',
+            options: [
+              { name: 'Message', value: 'message' },
+              { name: 'Invite', value: 'invite' },
+              { name: 'Occupant Joined', value: 'occupant_joined' },
+              { name: 'Occupant Left', value: 'occupant_left' },
+            ],
+            default: [],
+          },
+          {
+            displayName: 'Body Pattern',
+            name: 'body',
+            type: 'string',
+            default: '',
+            placeholder: '^!deploy',
+            description: 'Regular expression the message body must match',
+          },
+        ],
+      },
+    ],
+  };
+
+  webhookMethods = {
+    default: {
+      async checkExists(this: IHookFunctions): Promise<boolean> {
+        // Nothing to register, the bot is configured with the webhook URL
+        if (!(this.getNodeParameter('register', false) as boolean)) {
+          return true;
+        }
+
+        const webhookData = this.getWorkflowStaticData('node');
+        if (webhookData.subscriptionId === undefined) {
+          return false;
+        }
+
+        const { baseUrl, apiKey } = await botApi.call(this);
+        const response = await this.helpers.httpRequest({
+          method: 'GET',
+          url: `${baseUrl}/webhooks/subscriptions`,
+          headers: { 'API-Key': apiKey },
+          json: true,
+        });
+
+        const webhookUrl = this.getNodeWebhookUrl('default');
+        const subscriptions = (response.data ?? []) as Array<{ id: string; url: string }>;
+        return subscriptions.some(
+          (subscription) => subscription.id === webhookData.subscriptionId && subscription.url === webhookUrl,
+        );
+      },
+
+      async create(this: IHookFunctions): Promise<boolean> {
+        if (!(this.getNodeParameter('register', false) as boolean)) {
+          return true;
+        }
+
+        const filters = this.getNodeParameter('filters', {}) as {
+          from?: string;
+          rooms?: string;
+          types?: string[];
+          events?: string[];
+          body?: string;
+        };
+
+        const { baseUrl, apiKey } = await botApi.call(this);
+        const response = await this.helpers.httpRequest({
+          method: 'POST',
+          url: `${baseUrl}/webhooks/subscriptions`,
+          headers: { 'API-Key': apiKey },
+          body: {
+            url: this.getNodeWebhookUrl('default'),
+            filters: {
+              from: splitList(filters.from),
+              rooms: splitList(filters.rooms),
+              types: filters.types?.length ? filters.types : undefined,
+              events: filters.events?.length ? filters.events : undefined,
+              body: filters.body || undefined,
+            },
+          },
+          json: true,
+        });
+
+        const webhookData = this.getWorkflowStaticData('node');
+        webhookData.subscriptionId = response.data.id as string;
+        webhookData.secret = response.data.secret as string;
+        return true;
+      },
+
+      async delete(this: IHookFunctions): Promise<boolean> {
+        const webhookData = this.getWorkflowStaticData('node');
+        if (webhookData.subscriptionId === undefined) {
+          return true;
+        }
+
+        const { baseUrl, apiKey } = await botApi.call(this);
+        try {
+          await this.helpers.httpRequest({
+            method: 'DELETE',
+            url: `${baseUrl}/webhooks/subscriptions/${webhookData.subscriptionId}`,
+            headers: { 'API-Key': apiKey },
+            json: true,
+          });
+        } catch (error) {
+          // Already removed or expired
+          const { httpCode, response } = error as { httpCode?: string; response?: { status?: number } };
+          if (httpCode !== '404' && response?.status !== 404) {
+            return false;
+          }
+        }
+
+        delete webhookData.subscriptionId;
+        delete webhookData.secret;
+        return true;
+      },
+    },
   };
 
   async webhook(this: IWebhookFunctions): Promise<IWebhookResponseData> {
+    // Deliveries to a registered webhook are signed with the secret of its subscription
+    const webhookData = this.getWorkflowStaticData('node');
+    if (webhookData.secret !== undefined) {
+      const headers = this.getHeaderData();
+      const rawBody = (this.getRequestObject() as { rawBody?: Buffer }).rawBody ?? Buffer.from('');
+      const signed = validSignature(
+        webhookData.secret as string,
+        (headers['x-signature'] as string) ?? '',
+        (headers['x-webhook-timestamp'] as string) ?? '',
+        rawBody,
+      );
+      if (!signed) {
+        this.getResponseObject().status(401).send('Invalid webhook signature');
+        return { noWebhookResponse: true };
+      }
+    }
+
     const body = this.getBodyData();
 
     let outputData: IDataObject = {};
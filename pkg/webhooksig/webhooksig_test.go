@@ -0,0 +1,55 @@
+package webhooksig
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	// printf '2026-03-01T09:00:00Z.{"event":"message"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"sha256=4a93e6b881bbacc5667741ac02606a2a1c6c77df7ce3b194940d9ceb0efc46fe",
+		Sign("secret", "2026-03-01T09:00:00Z", []byte(`{"event":"message"}`)),
+	)
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"message"}`)
+	timestamp := "2026-03-01T09:00:00Z"
+	rotating := Header([]string{"new", "old"}, timestamp, body)
+
+	assert.NoError(t, Verify([]string{"new"}, rotating, timestamp, body))
+	assert.NoError(t, Verify([]string{"old"}, rotating, timestamp, body))
+	assert.ErrorIs(t, Verify([]string{"other"}, rotating, timestamp, body), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify([]string{"new"}, rotating, "2026-03-01T09:00:01Z", body), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify([]string{"new"}, rotating, timestamp, []byte(`{"event":"invite"}`)), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify([]string{"new"}, "", timestamp, body), ErrMissingSignature)
+}
+
+func TestVerifyRequest(t *testing.T) {
+	body := `{"event":"message"}`
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	r := httptest.NewRequest("POST", "/hook", strings.NewReader(body))
+	r.Header.Set(TimestampHeader, timestamp)
+	r.Header.Set(SignatureHeader, Sign("secret", timestamp, []byte(body)))
+
+	got, err := VerifyRequest(r, []string{"secret"}, DefaultTolerance)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(got))
+	rest, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(rest), "the body stays readable")
+
+	old := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	r = httptest.NewRequest("POST", "/hook", strings.NewReader(body))
+	r.Header.Set(TimestampHeader, old)
+	r.Header.Set(SignatureHeader, Sign("secret", old, []byte(body)))
+	_, err = VerifyRequest(r, []string{"secret"}, DefaultTolerance)
+	assert.ErrorIs(t, err, ErrExpired)
+}
@@ -0,0 +1,10 @@
+module gosrc.io/xmpp
+
+go 1.13
+
+require (
+	github.com/google/go-cmp v0.3.1
+	github.com/google/uuid v1.1.1
+	golang.org/x/xerrors v0.0.0-20190717185122-a985d3407aa7
+	nhooyr.io/websocket v1.6.5
+)
@@ -0,0 +1,4 @@
+/*
+XMPP stanza package is used to parse, marshal and unmarshal XMPP stanzas and nonzas.
+*/
+package stanza
@@ -0,0 +1,12 @@
+package stanza
+
+const (
+	NSStream    = "http://etherx.jabber.org/streams"
+	nsTLS       = "urn:ietf:params:xml:ns:xmpp-tls"
+	NSSASL      = "urn:ietf:params:xml:ns:xmpp-sasl"
+	NSBind      = "urn:ietf:params:xml:ns:xmpp-bind"
+	NSSession   = "urn:ietf:params:xml:ns:xmpp-session"
+	NSFraming   = "urn:ietf:params:xml:ns:xmpp-framing"
+	NSClient    = "jabber:client"
+	NSComponent = "jabber:component:accept"
+)
@@ -0,0 +1,14 @@
+package stanza
+
+import (
+	"encoding/xml"
+)
+
+// Used during stream initiation / session establishment
+type TLSProceed struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:xmpp-tls proceed"`
+}
+
+type tlsFailure struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:xmpp-tls failure"`
+}